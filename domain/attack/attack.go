@@ -0,0 +1,307 @@
+package attack
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var (
+	ErrPatternNotFound   = errors.New("the attack pattern was not found")
+	ErrTechniqueNotFound = errors.New("the technique was not found")
+)
+
+// AttackPattern is a CAPEC attack pattern with the weaknesses it exploits and
+// the ATT&CK techniques it maps onto.
+type AttackPattern struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	Cwes       []string `json:"cwes"`
+	Techniques []string `json:"techniques"`
+}
+
+// Technique is a MITRE ATT&CK technique or sub-technique.
+type Technique struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Url     string   `json:"url,omitempty"`
+	Tactics []string `json:"tactics"`
+	Capecs  []string `json:"capecs"`
+}
+
+// Catalogue links vulnerabilities to attack patterns through their CWEs and
+// to ATT&CK techniques through those patterns and through published CVE to
+// ATT&CK mappings.
+type Catalogue struct {
+	patterns   map[string]*AttackPattern
+	techniques map[string]*Technique
+	byCwe      map[string][]string
+	byCve      map[string][]string
+	lock       *sync.RWMutex
+}
+
+func (c *Catalogue) AddPattern(pattern AttackPattern) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	pattern.Id = CapecId(pattern.Id)
+	existing, ok := c.patterns[pattern.Id]
+	if !ok {
+		existing = &AttackPattern{Id: pattern.Id, Cwes: []string{}, Techniques: []string{}}
+		c.patterns[pattern.Id] = existing
+	}
+	if existing.Name == "" {
+		existing.Name = pattern.Name
+	}
+	for _, cwe := range pattern.Cwes {
+		cwe = CweId(cwe)
+		if appendUnique(&existing.Cwes, cwe) {
+			c.byCwe[cwe] = append(c.byCwe[cwe], existing.Id)
+		}
+	}
+	for _, technique := range pattern.Techniques {
+		appendUnique(&existing.Techniques, TechniqueId(technique))
+	}
+}
+
+func (c *Catalogue) AddTechnique(technique Technique) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.addTechnique(technique)
+}
+
+func (c *Catalogue) addTechnique(technique Technique) {
+	technique.Id = TechniqueId(technique.Id)
+	existing, ok := c.techniques[technique.Id]
+	if !ok {
+		existing = &Technique{Id: technique.Id, Tactics: []string{}, Capecs: []string{}}
+		c.techniques[technique.Id] = existing
+	}
+	if existing.Name == "" {
+		existing.Name = technique.Name
+	}
+	if existing.Url == "" {
+		existing.Url = technique.Url
+	}
+	for _, tactic := range technique.Tactics {
+		appendUnique(&existing.Tactics, tactic)
+	}
+	for _, capec := range technique.Capecs {
+		appendUnique(&existing.Capecs, CapecId(capec))
+	}
+}
+
+func (c *Catalogue) AddCveMapping(cveId string, techniqueIds ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cveId = strings.ToUpper(strings.TrimSpace(cveId))
+	mapped := c.byCve[cveId]
+	for _, techniqueId := range techniqueIds {
+		appendUnique(&mapped, TechniqueId(techniqueId))
+	}
+	c.byCve[cveId] = mapped
+}
+
+func (c *Catalogue) Pattern(id string) (AttackPattern, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	pattern, ok := c.patterns[CapecId(id)]
+	if !ok {
+		return AttackPattern{}, ErrPatternNotFound
+	}
+	return *pattern, nil
+}
+
+func (c *Catalogue) Technique(id string) (Technique, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	technique, ok := c.techniques[TechniqueId(id)]
+	if !ok {
+		return Technique{}, ErrTechniqueNotFound
+	}
+	return *technique, nil
+}
+
+// Patterns returns the attack patterns that exploit any of the
+// vulnerability's weaknesses.
+func (c *Catalogue) Patterns(vuln vulnerability.Vulnerability) []AttackPattern {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	patterns := []AttackPattern{}
+	seen := make(map[string]bool)
+	for _, cwe := range vuln.Cwes {
+		for _, capecId := range c.byCwe[CweId(cwe.Id)] {
+			if !seen[capecId] {
+				seen[capecId] = true
+				patterns = append(patterns, *c.patterns[capecId])
+			}
+		}
+	}
+	sort.Slice(patterns, func(a, b int) bool {
+		return capecNumber(patterns[a].Id) < capecNumber(patterns[b].Id)
+	})
+	return patterns
+}
+
+// Techniques returns the ATT&CK techniques the vulnerability enables, sorted
+// by id. Techniques are reached from the CVE mappings directly, and from each
+// CWE through the CAPEC patterns that exploit it, using both the CAPEC
+// taxonomy mappings and the CAPEC references in the ATT&CK bundle.
+func (c *Catalogue) Techniques(vuln vulnerability.Vulnerability) []vulnerability.Technique {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	found := make(map[string]*vulnerability.Technique)
+	link := func(techniqueId string, via string) {
+		technique, ok := found[techniqueId]
+		if !ok {
+			technique = &vulnerability.Technique{Id: techniqueId, Via: []string{}}
+			if known, ok := c.techniques[techniqueId]; ok {
+				technique.Name = known.Name
+				technique.Tactics = append([]string{}, known.Tactics...)
+			}
+			found[techniqueId] = technique
+		}
+		appendUnique(&technique.Via, via)
+	}
+
+	cveId := strings.ToUpper(vuln.CveId)
+	for _, techniqueId := range c.byCve[cveId] {
+		link(techniqueId, cveId)
+	}
+
+	capecTechniques := c.capecTechniques()
+	for _, cwe := range vuln.Cwes {
+		cweId := CweId(cwe.Id)
+		for _, capecId := range c.byCwe[cweId] {
+			via := cweId + "/" + capecId
+			for _, techniqueId := range c.patterns[capecId].Techniques {
+				link(techniqueId, via)
+			}
+			for _, techniqueId := range capecTechniques[capecId] {
+				link(techniqueId, via)
+			}
+		}
+	}
+
+	techniques := make([]vulnerability.Technique, 0, len(found))
+	for _, technique := range found {
+		techniques = append(techniques, *technique)
+	}
+	sort.Slice(techniques, func(a, b int) bool {
+		return techniques[a].Id < techniques[b].Id
+	})
+	return techniques
+}
+
+func (c *Catalogue) capecTechniques() map[string][]string {
+	byCapec := make(map[string][]string)
+	for _, technique := range c.techniques {
+		for _, capecId := range technique.Capecs {
+			byCapec[capecId] = append(byCapec[capecId], technique.Id)
+		}
+	}
+	return byCapec
+}
+
+// Enrich replaces the vulnerability's techniques with those the catalogue
+// links it to, returning whether any were found.
+func (c *Catalogue) Enrich(vuln *vulnerability.Vulnerability) bool {
+	vuln.Techniques = c.Techniques(*vuln)
+	return len(vuln.Techniques) > 0
+}
+
+// Apply enriches every vulnerability in the repository, updating those whose
+// technique list changed.
+func (c *Catalogue) Apply(ctx context.Context, repository vulnerability.VulnerabilityRepository) error {
+	collection, err := repository.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range collection.Vulnerabilities {
+		vuln := &collection.Vulnerabilities[i]
+		before := len(vuln.Techniques)
+		if !c.Enrich(vuln) && before == 0 {
+			continue
+		}
+		if err := repository.Update(ctx, vuln.CveId, vuln); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CapecId normalises "66" and "capec-66" to "CAPEC-66".
+func CapecId(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !strings.HasPrefix(id, "CAPEC-") {
+		id = "CAPEC-" + id
+	}
+	return id
+}
+
+// CweId normalises "89" and "cwe-89" to "CWE-89".
+func CweId(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !strings.HasPrefix(id, "CWE-") {
+		id = "CWE-" + id
+	}
+	return id
+}
+
+// TechniqueId normalises "1190" and "t1190" to "T1190". CAPEC records ATT&CK
+// mappings without the leading T.
+func TechniqueId(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !strings.HasPrefix(id, "T") {
+		id = "T" + id
+	}
+	return id
+}
+
+func capecNumber(id string) int {
+	number := 0
+	for _, r := range strings.TrimPrefix(id, "CAPEC-") {
+		if r < '0' || r > '9' {
+			break
+		}
+		number = number*10 + int(r-'0')
+	}
+	return number
+}
+
+func appendUnique(values *[]string, value string) bool {
+	for _, existing := range *values {
+		if existing == value {
+			return false
+		}
+	}
+	*values = append(*values, value)
+	return true
+}
+
+func NewCatalogue() (*Catalogue, error) {
+	return &Catalogue{
+		patterns:   make(map[string]*AttackPattern),
+		techniques: make(map[string]*Technique),
+		byCwe:      make(map[string][]string),
+		byCve:      make(map[string][]string),
+		lock:       &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewCatalogue() *Catalogue {
+	catalogue, err := NewCatalogue()
+	if err != nil {
+		panic(err)
+	}
+	return catalogue
+}
@@ -0,0 +1,106 @@
+package attack
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+func loadTestCatalogue(t *testing.T) *Catalogue {
+	catalogue := MustNewCatalogue()
+	loaders := map[string]func(*os.File) error{
+		"testdata/capec.xml":              func(f *os.File) error { return catalogue.LoadCapec(f) },
+		"testdata/enterprise-attack.json": func(f *os.File) error { return catalogue.LoadAttack(f) },
+		"testdata/cve-mappings.csv":       func(f *os.File) error { return catalogue.LoadCveMappings(f) },
+	}
+	for name, load := range loaders {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("failed to open %s: %s", name, err)
+		}
+		if err := load(file); err != nil {
+			t.Fatalf("failed to load %s: %s", name, err)
+		}
+		file.Close()
+	}
+	return catalogue
+}
+
+func techniqueIds(techniques []vulnerability.Technique) []string {
+	ids := []string{}
+	for _, technique := range techniques {
+		ids = append(ids, technique.Id)
+	}
+	return ids
+}
+
+func TestLoad(t *testing.T) {
+	catalogue := loadTestCatalogue(t)
+
+	if _, err := catalogue.Pattern("13"); err != ErrPatternNotFound {
+		t.Errorf("failed to skip deprecated pattern, got %v", err)
+	}
+	if _, err := catalogue.Technique("T1203"); err != ErrTechniqueNotFound {
+		t.Errorf("failed to skip revoked technique, got %v", err)
+	}
+	technique, err := catalogue.Technique("1110")
+	if err != nil {
+		t.Fatalf("failed to get technique: %s", err)
+	}
+	if technique.Name != "Brute Force" || !reflect.DeepEqual(technique.Tactics, []string{"credential-access"}) {
+		t.Errorf("failed to merge capec and attack technique, got %+v", technique)
+	}
+}
+
+func TestTechniques(t *testing.T) {
+	catalogue := loadTestCatalogue(t)
+
+	log4shell := vulnerability.Vulnerability{
+		CveId: "CVE-2021-44228",
+		Cwes:  []vulnerability.Cwe{{Id: "CWE-502"}, {Id: "CWE-20"}},
+	}
+	patterns := catalogue.Patterns(log4shell)
+	if len(patterns) != 2 || patterns[0].Id != "CAPEC-136" || patterns[1].Id != "CAPEC-586" {
+		t.Errorf("failed to find attack patterns, got %+v", patterns)
+	}
+
+	techniques := catalogue.Techniques(log4shell)
+	if !reflect.DeepEqual(techniqueIds(techniques), []string{"T1059", "T1190"}) {
+		t.Fatalf("failed to find techniques, got %+v", techniques)
+	}
+	if !reflect.DeepEqual(techniques[0].Via, []string{"CVE-2021-44228", "CWE-502/CAPEC-586"}) {
+		t.Errorf("failed to record technique provenance, got %v", techniques[0].Via)
+	}
+	if techniques[1].Name != "Exploit Public-Facing Application" || techniques[1].Tactics[0] != "initial-access" {
+		t.Errorf("failed to name technique, got %+v", techniques[1])
+	}
+
+	weak := vulnerability.Vulnerability{CveId: "CVE-2020-0001", Cwes: []vulnerability.Cwe{{Id: "330"}}}
+	if !reflect.DeepEqual(techniqueIds(catalogue.Techniques(weak)), []string{"T1110"}) {
+		t.Errorf("failed to follow capec taxonomy mapping, got %+v", catalogue.Techniques(weak))
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	catalogue := loadTestCatalogue(t)
+	repo := memory.MustNewMemoryVulnerabilityRepository()
+	if err := repo.Add(ctx, vulnerability.Vulnerability{CveId: "CVE-2019-0708"}); err != nil {
+		t.Fatalf("failed to add vulnerability: %s", err)
+	}
+
+	if err := catalogue.Apply(ctx, repo); err != nil {
+		t.Fatalf("failed to apply catalogue: %s", err)
+	}
+	vuln, err := repo.Get(ctx, "CVE-2019-0708")
+	if err != nil {
+		t.Fatalf("failed to get vulnerability: %s", err)
+	}
+	if !reflect.DeepEqual(techniqueIds(vuln.Techniques), []string{"T1190", "T1203", "T1210"}) {
+		t.Errorf("failed to store techniques, got %+v", vuln.Techniques)
+	}
+}
@@ -0,0 +1,64 @@
+package attack
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type capecAttackPattern struct {
+	Id         string `xml:"ID,attr"`
+	Name       string `xml:"Name,attr"`
+	Status     string `xml:"Status,attr"`
+	Weaknesses []struct {
+		CweId string `xml:"CWE_ID,attr"`
+	} `xml:"Related_Weaknesses>Related_Weakness"`
+	TaxonomyMappings []struct {
+		Taxonomy  string `xml:"Taxonomy_Name,attr"`
+		EntryId   string `xml:"Entry_ID"`
+		EntryName string `xml:"Entry_Name"`
+	} `xml:"Taxonomy_Mappings>Taxonomy_Mapping"`
+}
+
+// LoadCapec adds the attack patterns from the CAPEC XML catalogue
+// (https://capec.mitre.org/data/xml/capec_latest.xml). Deprecated patterns
+// are skipped, and ATT&CK taxonomy mappings become the pattern's techniques.
+func (c *Catalogue) LoadCapec(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read capec catalogue: %s", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Attack_Pattern" {
+			continue
+		}
+		var item capecAttackPattern
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return fmt.Errorf("failed to decode capec attack pattern: %s", err)
+		}
+		if strings.EqualFold(item.Status, "Deprecated") {
+			continue
+		}
+
+		pattern := AttackPattern{Id: item.Id, Name: item.Name}
+		for _, weakness := range item.Weaknesses {
+			pattern.Cwes = append(pattern.Cwes, weakness.CweId)
+		}
+		for _, mapping := range item.TaxonomyMappings {
+			if mapping.Taxonomy != "ATTACK" || strings.TrimSpace(mapping.EntryId) == "" {
+				continue
+			}
+			pattern.Techniques = append(pattern.Techniques, mapping.EntryId)
+			c.AddTechnique(Technique{Id: mapping.EntryId, Name: strings.TrimSpace(mapping.EntryName)})
+		}
+		c.AddPattern(pattern)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package attack
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var techniquePattern = regexp.MustCompile(`\bT\d{4}(?:\.\d{3})?\b`)
+
+// LoadCveMappings adds CVE to ATT&CK technique mappings from a CSV file in the
+// layout published by the Center for Threat-Informed Defense, with a CVE ID
+// column followed by impact and exploitation technique columns. Technique ids
+// are picked out of every other column, so cells may hold several.
+func (c *Catalogue) LoadCveMappings(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read cve mapping header: %s", err)
+	}
+	cveColumn := -1
+	for i, name := range header {
+		if strings.Contains(strings.ToUpper(name), "CVE") {
+			cveColumn = i
+			break
+		}
+	}
+	if cveColumn < 0 {
+		return fmt.Errorf("failed to read cve mappings: no cve column in %v", header)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cve mapping: %s", err)
+		}
+		if cveColumn >= len(record) || strings.TrimSpace(record[cveColumn]) == "" {
+			continue
+		}
+
+		techniqueIds := []string{}
+		for i, cell := range record {
+			if i != cveColumn {
+				techniqueIds = append(techniqueIds, techniquePattern.FindAllString(strings.ToUpper(cell), -1)...)
+			}
+		}
+		c.AddCveMapping(record[cveColumn], techniqueIds...)
+	}
+	return nil
+}
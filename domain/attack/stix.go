@@ -0,0 +1,107 @@
+package attack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type stixObject struct {
+	Type               string `json:"type"`
+	Name               string `json:"name"`
+	Revoked            bool   `json:"revoked"`
+	Deprecated         bool   `json:"x_mitre_deprecated"`
+	ExternalReferences []struct {
+		SourceName string `json:"source_name"`
+		ExternalId string `json:"external_id"`
+		Url        string `json:"url"`
+	} `json:"external_references"`
+	KillChainPhases []struct {
+		KillChainName string `json:"kill_chain_name"`
+		PhaseName     string `json:"phase_name"`
+	} `json:"kill_chain_phases"`
+}
+
+// LoadAttack adds the techniques from a MITRE ATT&CK STIX 2 bundle such as
+// enterprise-attack.json. Objects are decoded one at a time, revoked and
+// deprecated techniques are skipped, and CAPEC references on a technique link
+// it to those attack patterns.
+func (c *Catalogue) LoadAttack(r io.Reader) error {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return fmt.Errorf("failed to read attack bundle: %s", err)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read attack bundle: %s", err)
+		}
+		if token != "objects" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return fmt.Errorf("failed to read attack bundle: %s", err)
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return fmt.Errorf("failed to read attack bundle objects: %s", err)
+		}
+		for decoder.More() {
+			var object stixObject
+			if err := decoder.Decode(&object); err != nil {
+				return fmt.Errorf("failed to decode attack object: %s", err)
+			}
+			if object.Type != "attack-pattern" || object.Revoked || object.Deprecated {
+				continue
+			}
+			c.addStixTechnique(object)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return fmt.Errorf("failed to read attack bundle objects: %s", err)
+		}
+	}
+	return nil
+}
+
+func (c *Catalogue) addStixTechnique(object stixObject) {
+	technique := Technique{Name: object.Name}
+	for _, reference := range object.ExternalReferences {
+		switch reference.SourceName {
+		case "mitre-attack":
+			technique.Id = reference.ExternalId
+			technique.Url = reference.Url
+		case "capec":
+			technique.Capecs = append(technique.Capecs, reference.ExternalId)
+		}
+	}
+	if technique.Id == "" {
+		return
+	}
+	for _, phase := range object.KillChainPhases {
+		if phase.KillChainName == "mitre-attack" {
+			technique.Tactics = append(technique.Tactics, phase.PhaseName)
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// The bundle is authoritative for names, so replace any placeholder taken
+	// from a CAPEC taxonomy mapping.
+	if existing, ok := c.techniques[TechniqueId(technique.Id)]; ok {
+		existing.Name = ""
+	}
+	c.addTechnique(technique)
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
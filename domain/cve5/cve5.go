@@ -0,0 +1,216 @@
+package cve5
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var ErrRecordRejected = errors.New("the cve record has been rejected")
+
+// goCollectionUrl marks affected entries that name a Go module.
+const goCollectionUrl = "https://pkg.go.dev"
+
+type cveCvss struct {
+	Version      string  `json:"version"`
+	VectorString string  `json:"vectorString"`
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity"`
+}
+
+type cveContainer struct {
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Affected []struct {
+		Vendor        string   `json:"vendor"`
+		Product       string   `json:"product"`
+		CollectionUrl string   `json:"collectionURL"`
+		PackageName   string   `json:"packageName"`
+		Cpes          []string `json:"cpes"`
+		Versions      []struct {
+			Version     string `json:"version"`
+			Status      string `json:"status"`
+			LessThan    string `json:"lessThan"`
+			VersionType string `json:"versionType"`
+		} `json:"versions"`
+	} `json:"affected"`
+	ProblemTypes []struct {
+		Descriptions []struct {
+			CweId string `json:"cweId"`
+		} `json:"descriptions"`
+	} `json:"problemTypes"`
+	References []struct {
+		Url  string   `json:"url"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	} `json:"references"`
+	Metrics []struct {
+		CvssV40 *cveCvss `json:"cvssV4_0"`
+		CvssV31 *cveCvss `json:"cvssV3_1"`
+		CvssV30 *cveCvss `json:"cvssV3_0"`
+		CvssV20 *cveCvss `json:"cvssV2_0"`
+	} `json:"metrics"`
+	ProviderMetadata struct {
+		ShortName string `json:"shortName"`
+	} `json:"providerMetadata"`
+}
+
+type cveRecord struct {
+	CveMetadata struct {
+		CveId             string `json:"cveId"`
+		AssignerShortName string `json:"assignerShortName"`
+		State             string `json:"state"`
+		DatePublished     string `json:"datePublished"`
+		DateUpdated       string `json:"dateUpdated"`
+	} `json:"cveMetadata"`
+	Containers struct {
+		Cna cveContainer   `json:"cna"`
+		Adp []cveContainer `json:"adp"`
+	} `json:"containers"`
+}
+
+// ReadCveRecord reads a record in the CVE JSON 5 format published in the
+// cvelistV5 repository. Descriptions in every language are kept. Scores and
+// weaknesses come from the CNA container, with ADP containers such as CISA
+// Vulnrichment filling in what the CNA left out.
+func ReadCveRecord(r io.Reader) (vulnerability.Vulnerability, error) {
+	var record cveRecord
+	if err := json.NewDecoder(r).Decode(&record); err != nil {
+		return vulnerability.Vulnerability{}, fmt.Errorf("failed to decode cve record: %s", err)
+	}
+	if record.CveMetadata.State == "REJECTED" {
+		return vulnerability.Vulnerability{}, fmt.Errorf("%w: %s", ErrRecordRejected, record.CveMetadata.CveId)
+	}
+
+	vuln := vulnerability.Vulnerability{
+		CveId:         record.CveMetadata.CveId,
+		Assigner:      record.CveMetadata.AssignerShortName,
+		PublishedDate: parseTime(record.CveMetadata.DatePublished),
+		LastModified:  parseTime(record.CveMetadata.DateUpdated),
+		Cwes:          []vulnerability.Cwe{},
+		References:    []vulnerability.Reference{},
+	}
+
+	cna := record.Containers.Cna
+	for _, description := range cna.Descriptions {
+		vuln.AddDescription(description.Lang, description.Value)
+	}
+	containers := append([]cveContainer{cna}, record.Containers.Adp...)
+	for _, container := range containers {
+		addMetrics(&vuln, container)
+		addCwes(&vuln, container)
+	}
+	addAffected(&vuln, cna)
+
+	for _, reference := range cna.References {
+		parsed, err := url.Parse(reference.Url)
+		if err != nil {
+			continue
+		}
+		tags := reference.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		vuln.References = append(vuln.References, vulnerability.Reference{
+			Url:    *parsed,
+			Name:   reference.Name,
+			Source: cna.ProviderMetadata.ShortName,
+			Tags:   tags,
+		})
+	}
+	return vuln, nil
+}
+
+// addMetrics fills in the scores the vulnerability does not have yet.
+func addMetrics(vuln *vulnerability.Vulnerability, container cveContainer) {
+	for _, metric := range container.Metrics {
+		if metric.CvssV40 != nil && vuln.Cvss4.CvssVector == "" {
+			vuln.Cvss4 = vulnerability.Cvss4{
+				Version:      metric.CvssV40.Version,
+				CvssVector:   metric.CvssV40.VectorString,
+				BaseScore:    metric.CvssV40.BaseScore,
+				BaseSeverity: metric.CvssV40.BaseSeverity,
+			}
+		}
+		cvss3 := metric.CvssV31
+		if cvss3 == nil {
+			cvss3 = metric.CvssV30
+		}
+		if cvss3 != nil && vuln.Cvss3.CvssVector == "" {
+			vuln.Cvss3 = vulnerability.Cvss3{
+				Version:      cvss3.Version,
+				CvssVector:   cvss3.VectorString,
+				BaseScore:    cvss3.BaseScore,
+				BaseSeverity: cvss3.BaseSeverity,
+			}
+		}
+		if metric.CvssV20 != nil && vuln.Cvss2.CvssVector == "" {
+			vuln.Cvss2 = vulnerability.Cvss2{
+				Version:    metric.CvssV20.Version,
+				CvssVector: metric.CvssV20.VectorString,
+				BaseScore:  metric.CvssV20.BaseScore,
+			}
+		}
+	}
+}
+
+func addCwes(vuln *vulnerability.Vulnerability, container cveContainer) {
+	for _, problemType := range container.ProblemTypes {
+		for _, description := range problemType.Descriptions {
+			if description.CweId == "" || hasCwe(vuln.Cwes, description.CweId) {
+				continue
+			}
+			vuln.Cwes = append(vuln.Cwes, vulnerability.Cwe{Id: description.CweId})
+		}
+	}
+}
+
+func hasCwe(cwes []vulnerability.Cwe, cweId string) bool {
+	for _, cwe := range cwes {
+		if strings.EqualFold(cwe.Id, cweId) {
+			return true
+		}
+	}
+	return false
+}
+
+func addAffected(vuln *vulnerability.Vulnerability, container cveContainer) {
+	for _, affected := range container.Affected {
+		product := vulnerability.AffectedProduct{Vendor: affected.Vendor, Product: affected.Product}
+		if len(affected.Cpes) > 0 {
+			product.Cpe = affected.Cpes[0]
+		}
+		if affected.CollectionUrl == goCollectionUrl && affected.PackageName != "" {
+			product.Ecosystem = vulnerability.EcosystemGo
+			product.Product = affected.PackageName
+		}
+		for _, version := range affected.Versions {
+			if version.Status != "affected" || version.VersionType != "semver" || version.LessThan == "" {
+				continue
+			}
+			versionRange := vulnerability.VersionRange{Fixed: version.LessThan}
+			if version.Version != "0" {
+				versionRange.Introduced = version.Version
+			}
+			product.Ranges = append(product.Ranges, versionRange)
+		}
+		vuln.Affected = append(vuln.Affected, product)
+	}
+}
+
+func parseTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000", "2006-01-02T15:04:05"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,87 @@
+package cve5
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const testRecord = `{
+	"dataType": "CVE_RECORD",
+	"dataVersion": "5.1",
+	"cveMetadata": {
+		"cveId": "CVE-2023-39325",
+		"assignerOrgId": "1bb62c36-49e3-4200-9d77-64a1400537cc",
+		"assignerShortName": "Go",
+		"state": "PUBLISHED",
+		"dateReserved": "2023-07-27T17:30:58.563Z",
+		"datePublished": "2023-10-11T21:15:13.133Z",
+		"dateUpdated": "2024-08-02T18:02:06.962Z"
+	},
+	"containers": {
+		"cna": {
+			"providerMetadata": {"orgId": "1bb62c36-49e3-4200-9d77-64a1400537cc", "shortName": "Go"},
+			"title": "HTTP/2 rapid reset can cause excessive work in net/http",
+			"descriptions": [
+				{"lang": "en", "value": "A malicious HTTP/2 client which rapidly creates requests and immediately resets them can cause excessive server resource consumption."},
+				{"lang": "de", "value": "Ein bösartiger HTTP/2-Client, der schnell Anfragen erstellt und sofort zurücksetzt, kann einen übermäßigen Ressourcenverbrauch verursachen."}
+			],
+			"affected": [
+				{
+					"vendor": "Go standard library",
+					"product": "net/http",
+					"collectionURL": "https://pkg.go.dev",
+					"packageName": "net/http",
+					"versions": [
+						{"version": "0", "lessThan": "1.20.10", "status": "affected", "versionType": "semver"},
+						{"version": "1.21.0-0", "lessThan": "1.21.3", "status": "affected", "versionType": "semver"}
+					]
+				}
+			],
+			"problemTypes": [{"descriptions": [{"description": "CWE-400: Uncontrolled Resource Consumption", "lang": "en"}]}],
+			"references": [
+				{"url": "https://go.dev/issue/63417"},
+				{"url": "https://pkg.go.dev/vuln/GO-2023-2102", "tags": ["vendor-advisory"]}
+			]
+		},
+		"adp": [
+			{
+				"providerMetadata": {"orgId": "134c704f-9b21-4f2e-91b3-4a467353bcc0", "shortName": "CISA-ADP"},
+				"problemTypes": [{"descriptions": [{"type": "CWE", "cweId": "CWE-400", "lang": "en", "description": "CWE-400 Uncontrolled Resource Consumption"}]}],
+				"metrics": [{"cvssV3_1": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", "baseScore": 7.5, "baseSeverity": "HIGH"}}]
+			}
+		]
+	}
+}`
+
+func TestReadCveRecord(t *testing.T) {
+	vuln, err := ReadCveRecord(strings.NewReader(testRecord))
+	if err != nil {
+		t.Fatalf("failed to read cve record: %s", err)
+	}
+
+	if vuln.CveId != "CVE-2023-39325" || vuln.Assigner != "Go" {
+		t.Errorf("failed to read metadata, got %s from %s", vuln.CveId, vuln.Assigner)
+	}
+	if !reflect.DeepEqual(vuln.Languages(), []string{"en", "de"}) || vuln.DescriptionIn("de-AT").Lang != "de" {
+		t.Errorf("failed to keep all descriptions, got %+v", vuln.Descriptions)
+	}
+	if vuln.Cvss3.BaseScore != 7.5 || len(vuln.Cwes) != 1 || vuln.Cwes[0].Id != "CWE-400" {
+		t.Errorf("failed to fill in from the adp container, got %+v %+v", vuln.Cvss3, vuln.Cwes)
+	}
+	expected := []vulnerability.VersionRange{{Fixed: "1.20.10"}, {Introduced: "1.21.0-0", Fixed: "1.21.3"}}
+	if len(vuln.Affected) != 1 || vuln.Affected[0].Ecosystem != vulnerability.EcosystemGo || !reflect.DeepEqual(vuln.Affected[0].Ranges, expected) {
+		t.Errorf("failed to read affected go package, got %+v", vuln.Affected)
+	}
+	if len(vuln.References) != 2 || vuln.References[1].Source != "Go" {
+		t.Errorf("failed to read references, got %+v", vuln.References)
+	}
+
+	rejected := `{"cveMetadata": {"cveId": "CVE-2023-0001", "state": "REJECTED"}, "containers": {}}`
+	if _, err := ReadCveRecord(strings.NewReader(rejected)); !errors.Is(err, ErrRecordRejected) {
+		t.Errorf("failed to refuse rejected record, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package cvrf
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var ErrMissingCveId = errors.New("the cvrf vulnerability has no cve id")
+
+// DefaultSource names the vendor when a document's product tree has no
+// vendor branch.
+const DefaultSource = "CVRF"
+
+const (
+	threatExploitStatus = "Exploit Status"
+	threatSeverity      = "Severity"
+
+	statusKnownAffected = "Known Affected"
+)
+
+var remediationTypes = map[string]vulnerability.RemediationType{
+	"Vendor Fix":     vulnerability.RemediationVendorFix,
+	"Workaround":     vulnerability.RemediationWorkaround,
+	"Mitigation":     vulnerability.RemediationMitigation,
+	"None Available": vulnerability.RemediationNoneAvailable,
+	"Will Not Fix":   vulnerability.RemediationWillNotFix,
+}
+
+// document is the format independent form of a CVRF document that both the
+// XML and the MSRC JSON readers decode into.
+type document struct {
+	publisher       string
+	initialRelease  string
+	currentRelease  string
+	products        map[string]product
+	vulnerabilities []entry
+}
+
+type product struct {
+	name   string
+	vendor string
+}
+
+type entry struct {
+	title         string
+	cveId         string
+	descriptions  []localised
+	cwes          []string
+	knownAffected []string
+	threats       []threat
+	scoreSets     []scoreSet
+	remediations  []remediation
+	references    []reference
+	revisions     []string
+}
+
+type localised struct {
+	lang  string
+	value string
+}
+
+type threat struct {
+	kind        string
+	description string
+	productIds  []string
+}
+
+type scoreSet struct {
+	baseScore  float64
+	vector     string
+	productIds []string
+}
+
+type remediation struct {
+	kind            string
+	description     string
+	url             string
+	supercedence    string
+	fixedBuild      string
+	restartRequired string
+	productIds      []string
+}
+
+type reference struct {
+	url         string
+	description string
+}
+
+// source is the vendor the document speaks for, used to attribute severities,
+// remediations and exploitability assessments.
+func (d document) source() string {
+	vendors := []string{}
+	for _, p := range d.products {
+		if p.vendor != "" {
+			vendors = append(vendors, p.vendor)
+		}
+	}
+	if len(vendors) == 0 {
+		return DefaultSource
+	}
+	sort.Strings(vendors)
+	return vendors[0]
+}
+
+func (d document) vulnerabilitiesFromDocument() ([]vulnerability.Vulnerability, error) {
+	source := d.source()
+	vulns := make([]vulnerability.Vulnerability, 0, len(d.vulnerabilities))
+	for _, e := range d.vulnerabilities {
+		if strings.TrimSpace(e.cveId) == "" {
+			return nil, ErrMissingCveId
+		}
+		vulns = append(vulns, d.vulnerability(e, source))
+	}
+	return vulns, nil
+}
+
+func (d document) vulnerability(e entry, source string) vulnerability.Vulnerability {
+	vuln := vulnerability.Vulnerability{
+		CveId:      strings.TrimSpace(e.cveId),
+		Assigner:   d.publisher,
+		Cwes:       []vulnerability.Cwe{},
+		References: []vulnerability.Reference{},
+	}
+	for _, description := range e.descriptions {
+		vuln.AddDescription(description.lang, plainText(description.value))
+	}
+	if vuln.Description == "" {
+		vuln.Description = strings.TrimSpace(e.title)
+	}
+
+	vuln.PublishedDate = parseDate(d.initialRelease)
+	vuln.LastModified = parseDate(d.currentRelease)
+	if len(e.revisions) > 0 {
+		vuln.PublishedDate = parseDate(e.revisions[0])
+		vuln.LastModified = parseDate(e.revisions[len(e.revisions)-1])
+	}
+
+	for _, cwe := range e.cwes {
+		vuln.Cwes = append(vuln.Cwes, vulnerability.Cwe{Id: cwe})
+	}
+
+	// The vulnerability carries the worst score, and each affected product
+	// the worst score of the sets naming it.
+	var best *scoreSet
+	productScores := make(map[string]*scoreSet)
+	for i := range e.scoreSets {
+		if best == nil || e.scoreSets[i].baseScore > best.baseScore {
+			best = &e.scoreSets[i]
+		}
+		for _, productId := range e.scoreSets[i].productIds {
+			if existing, ok := productScores[productId]; !ok || e.scoreSets[i].baseScore > existing.baseScore {
+				productScores[productId] = &e.scoreSets[i]
+			}
+		}
+	}
+	if best != nil {
+		vuln.Cvss3 = best.cvss()
+	}
+
+	severity := vulnerability.SeverityUnknown
+	rating := ""
+	for _, t := range e.threats {
+		switch t.kind {
+		case threatSeverity:
+			parsed, err := vulnerability.ParseSeverity(t.description)
+			if err == nil && parsed > severity {
+				severity = parsed
+				rating = strings.TrimSpace(t.description)
+			}
+		case threatExploitStatus:
+			vuln.Exploitability = append(vuln.Exploitability, parseExploitStatus(source, t.description))
+		}
+	}
+	if rating != "" {
+		vuln.AddVendorSeverity(vulnerability.VendorSeverity{Source: source, Rating: rating})
+	}
+
+	affected := e.knownAffected
+	if len(affected) == 0 {
+		for _, r := range e.remediations {
+			affected = append(affected, r.productIds...)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, productId := range affected {
+		p, ok := d.products[productId]
+		if !ok || seen[productId] {
+			continue
+		}
+		seen[productId] = true
+		vendor := p.vendor
+		if vendor == "" {
+			vendor = source
+		}
+		product := vulnerability.AffectedProduct{Vendor: vendor, Product: p.name}
+		if score, ok := productScores[productId]; ok {
+			cvss := score.cvss()
+			product.Cvss = &cvss
+		}
+		vuln.Affected = append(vuln.Affected, product)
+	}
+
+	for _, r := range e.remediations {
+		vuln.Remediations = append(vuln.Remediations, d.remediation(r, source))
+	}
+
+	for _, r := range e.references {
+		parsed, err := url.Parse(strings.TrimSpace(r.url))
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		vuln.References = append(vuln.References, vulnerability.Reference{
+			Url:    *parsed,
+			Name:   strings.TrimSpace(r.description),
+			Source: source,
+			Tags:   []string{},
+		})
+	}
+	return vuln
+}
+
+func (s scoreSet) cvss() vulnerability.Cvss3 {
+	version := cvssVersion(s.vector)
+	return vulnerability.Cvss3{
+		Version:      version,
+		CvssVector:   s.vector,
+		BaseScore:    s.baseScore,
+		BaseSeverity: vulnerability.SeverityFromCvssScore(version, s.baseScore).String(),
+	}
+}
+
+func (d document) remediation(r remediation, source string) vulnerability.Remediation {
+	remediationType, ok := remediationTypes[r.kind]
+	if !ok {
+		remediationType = vulnerability.RemediationMitigation
+	}
+	result := vulnerability.Remediation{
+		Source:          source,
+		Type:            remediationType,
+		Description:     plainText(r.description),
+		Kb:              kbNumber(r.description),
+		Url:             strings.TrimSpace(r.url),
+		FixedBuild:      strings.TrimSpace(r.fixedBuild),
+		Supersedes:      kbNumber(r.supercedence),
+		RestartRequired: strings.EqualFold(strings.TrimSpace(r.restartRequired), "Yes"),
+	}
+	if result.Supersedes == "" {
+		result.Supersedes = strings.TrimSpace(r.supercedence)
+	}
+	for _, productId := range r.productIds {
+		if p, ok := d.products[productId]; ok {
+			result.Products = append(result.Products, p.name)
+		}
+	}
+	return result
+}
+
+// parseExploitStatus reads the Microsoft Exploitability Index threat, e.g.
+// "Publicly Disclosed:No;Exploited:No;Latest Software Release:Exploitation
+// More Likely;Older Software Release:Exploitation More Likely;DOS:N/A".
+func parseExploitStatus(source string, status string) vulnerability.Exploitability {
+	assessment := vulnerability.Exploitability{Source: source}
+	for _, field := range strings.Split(status, ";") {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "publicly disclosed":
+			assessment.PubliclyDisclosed = strings.EqualFold(value, "Yes")
+		case "exploited":
+			assessment.Exploited = strings.EqualFold(value, "Yes")
+		case "latest software release":
+			assessment.LatestRelease = value
+		case "older software release":
+			assessment.OlderRelease = value
+		}
+	}
+	return assessment
+}
+
+var kbPattern = regexp.MustCompile(`^(?i:KB)?(\d{6,8})$`)
+
+// kbNumber returns the KB article for security update remediations, whose
+// description is the bare article number.
+func kbNumber(description string) string {
+	match := kbPattern.FindStringSubmatch(strings.TrimSpace(description))
+	if match == nil {
+		return ""
+	}
+	return "KB" + match[1]
+}
+
+func cvssVersion(vector string) string {
+	if strings.HasPrefix(vector, "CVSS:") {
+		if slash := strings.Index(vector, "/"); slash > 0 {
+			return vector[len("CVSS:"):slash]
+		}
+	}
+	return "3.0"
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func plainText(text string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(text, "")))
+}
+
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+	return time.Time{}
+}
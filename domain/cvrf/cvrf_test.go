@@ -0,0 +1,98 @@
+package cvrf
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func readTestDocument(t *testing.T, name string, read func(io.Reader) ([]vulnerability.Vulnerability, error)) []vulnerability.Vulnerability {
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("failed to open test document: %s", err)
+	}
+	defer file.Close()
+
+	vulns, err := read(file)
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", name, err)
+	}
+	if len(vulns) != 2 {
+		t.Fatalf("failed to read %s, expected 2 vulnerabilities got %d", name, len(vulns))
+	}
+	return vulns
+}
+
+func TestReadDocuments(t *testing.T) {
+	readers := map[string]func(io.Reader) ([]vulnerability.Vulnerability, error){
+		"testdata/2021-Dec.xml":  ReadCvrf,
+		"testdata/2021-Dec.json": ReadMsrc,
+	}
+	for name, read := range readers {
+		vulns := readTestDocument(t, name, read)
+		if name == "testdata/2021-Dec.xml" {
+			if !reflect.DeepEqual(vulns[0].Languages(), []string{"en", "es"}) || vulns[0].DescriptionIn("es").Lang != "es" {
+				t.Errorf("%s: failed to keep translated descriptions, got %+v", name, vulns[0].Descriptions)
+			}
+		} else if !reflect.DeepEqual(vulns[0].Languages(), []string{"en"}) {
+			t.Errorf("%s: failed to tag descriptions with the document language, got %+v", name, vulns[0].Descriptions)
+		}
+
+		appx := vulns[0]
+		if appx.CveId != "CVE-2021-43890" || appx.Assigner != "secure@microsoft.com" {
+			t.Errorf("%s: failed to read identifiers, got %s from %s", name, appx.CveId, appx.Assigner)
+		}
+		if appx.Description != "Microsoft has investigated reports of a spoofing vulnerability in AppX installer that affects Microsoft Windows." {
+			t.Errorf("%s: failed to read description, got %q", name, appx.Description)
+		}
+		if !appx.LastModified.Equal(time.Date(2021, 12, 15, 8, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: failed to read revision history, got %s", name, appx.LastModified)
+		}
+		if len(appx.Cwes) != 1 || appx.Cwes[0].Id != "CWE-451" {
+			t.Errorf("%s: failed to read cwes, got %+v", name, appx.Cwes)
+		}
+		if len(appx.Affected) != 1 || appx.Affected[0].Vendor != "Microsoft" || appx.Affected[0].Product != "App Installer" {
+			t.Errorf("%s: failed to read affected products, got %+v", name, appx.Affected)
+		}
+		if !appx.ExploitationLikely() || !appx.Exploitability[0].PubliclyDisclosed {
+			t.Errorf("%s: failed to read exploit status, got %+v", name, appx.Exploitability)
+		}
+		if len(appx.Remediations) != 2 || appx.Remediations[1].Type != vulnerability.RemediationWorkaround || appx.Remediations[0].Kb != "" {
+			t.Errorf("%s: failed to read remediations, got %+v", name, appx.Remediations)
+		}
+		if len(appx.References) != 1 || appx.References[0].Url.Host != "msrc.microsoft.com" {
+			t.Errorf("%s: failed to read references, got %+v", name, appx.References)
+		}
+
+		isns := vulns[1]
+		if isns.Description != "iSNS Server Memory Corruption Vulnerability Can Lead to Remote Code Execution" {
+			t.Errorf("%s: failed to fall back to the title, got %q", name, isns.Description)
+		}
+		if isns.Cvss3.BaseScore != 9.8 || isns.Cvss3.Version != "3.1" || isns.Cvss3.BaseSeverity != "CRITICAL" {
+			t.Errorf("%s: failed to read cvss, got %+v", name, isns.Cvss3)
+		}
+		scores := map[string]float64{}
+		for _, product := range isns.Affected {
+			if product.Cvss != nil {
+				scores[product.Product] = product.Cvss.BaseScore
+			}
+		}
+		if scores["Windows Server 2019"] != 9.8 || scores["Windows 10 Version 1809 for 32-bit Systems"] != 8.8 {
+			t.Errorf("%s: failed to keep the score of each product, got %+v", name, isns.Affected)
+		}
+		if len(isns.VendorSeverities) != 1 || isns.VendorSeverities[0] != (vulnerability.VendorSeverity{Source: "Microsoft", Rating: "Critical"}) {
+			t.Errorf("%s: failed to take the highest vendor severity, got %+v", name, isns.VendorSeverities)
+		}
+		if isns.ExploitationLikely() {
+			t.Errorf("%s: exploitation less likely should not be likely", name)
+		}
+		fix := isns.Remediations[0]
+		if fix.Kb != "KB5008218" || fix.Supersedes != "KB5007206" || !fix.RestartRequired || fix.FixedBuild != "10.0.17763.2366" || len(fix.Products) != 2 {
+			t.Errorf("%s: failed to read security update, got %+v", name, fix)
+		}
+	}
+}
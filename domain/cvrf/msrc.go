@@ -0,0 +1,183 @@
+package cvrf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// The MSRC API serialises CVRF enumerations as integers in its JSON form.
+const msrcKnownAffected = 3
+
+// msrcLang is the fallback for documents whose title carries no language.
+const msrcLang = "en"
+
+var (
+	msrcBranchTypes = map[int]string{0: "Vendor", 1: "Product Family", 2: "Product Name", 3: "Product Version"}
+	msrcThreatTypes = map[int]string{0: "Impact", 1: threatExploitStatus, 2: "Target Set", 3: threatSeverity}
+	msrcRemediation = map[int]string{0: "Workaround", 1: "Mitigation", 2: "Vendor Fix", 3: "None Available", 4: "Will Not Fix"}
+)
+
+type msrcValue struct {
+	Value string `json:"Value"`
+}
+
+type msrcDocument struct {
+	DocumentTitle struct {
+		Value string `json:"Value"`
+		Lang  string `json:"lang"`
+	} `json:"DocumentTitle"`
+	DocumentPublisher struct {
+		ContactDetails msrcValue `json:"ContactDetails"`
+	} `json:"DocumentPublisher"`
+	DocumentTracking struct {
+		InitialReleaseDate string `json:"InitialReleaseDate"`
+		CurrentReleaseDate string `json:"CurrentReleaseDate"`
+	} `json:"DocumentTracking"`
+	ProductTree struct {
+		Branch          []msrcBranch `json:"Branch"`
+		FullProductName []msrcBranch `json:"FullProductName"`
+	} `json:"ProductTree"`
+	Vulnerability []msrcVulnerability `json:"Vulnerability"`
+}
+
+// msrcBranch is either a branch holding further items or, when it carries a
+// product id, a full product name.
+type msrcBranch struct {
+	Items     []msrcBranch `json:"Items"`
+	Type      int          `json:"Type"`
+	Name      string       `json:"Name"`
+	ProductId string       `json:"ProductID"`
+	Value     string       `json:"Value"`
+}
+
+type msrcVulnerability struct {
+	Title msrcValue `json:"Title"`
+	Notes []struct {
+		Title string `json:"Title"`
+		Type  int    `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"Notes"`
+	Cve  string `json:"CVE"`
+	Cwes []struct {
+		Id string `json:"ID"`
+	} `json:"CWE"`
+	ProductStatuses []struct {
+		ProductIds []string `json:"ProductID"`
+		Type       int      `json:"Type"`
+	} `json:"ProductStatuses"`
+	Threats []struct {
+		Description msrcValue `json:"Description"`
+		ProductIds  []string  `json:"ProductID"`
+		Type        int       `json:"Type"`
+	} `json:"Threats"`
+	CvssScoreSets []struct {
+		BaseScore  float64  `json:"BaseScore"`
+		Vector     string   `json:"Vector"`
+		ProductIds []string `json:"ProductID"`
+	} `json:"CVSSScoreSets"`
+	Remediations []struct {
+		Description     msrcValue `json:"Description"`
+		Url             string    `json:"URL"`
+		Supercedence    string    `json:"Supercedence"`
+		ProductIds      []string  `json:"ProductID"`
+		Type            int       `json:"Type"`
+		FixedBuild      string    `json:"FixedBuild"`
+		RestartRequired msrcValue `json:"RestartRequired"`
+	} `json:"Remediations"`
+	References []struct {
+		Url         string    `json:"URL"`
+		Description msrcValue `json:"Description"`
+	} `json:"References"`
+	RevisionHistory []struct {
+		Date string `json:"Date"`
+	} `json:"RevisionHistory"`
+}
+
+func (b msrcBranch) collect(vendor string, products map[string]product) {
+	if b.ProductId != "" {
+		existing, ok := products[b.ProductId]
+		if !ok || existing.vendor == "" {
+			products[b.ProductId] = product{name: strings.TrimSpace(b.Value), vendor: vendor}
+		}
+		return
+	}
+	if msrcBranchTypes[b.Type] == "Vendor" {
+		vendor = b.Name
+	}
+	for _, item := range b.Items {
+		item.collect(vendor, products)
+	}
+}
+
+// ReadMsrc reads the JSON form of a CVRF document served by the MSRC API
+// (https://api.msrc.microsoft.com/cvrf/v2.0/cvrf/2021-Dec with an
+// application/json Accept header).
+func ReadMsrc(r io.Reader) ([]vulnerability.Vulnerability, error) {
+	var raw msrcDocument
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode msrc document: %s", err)
+	}
+
+	doc := document{
+		publisher:      strings.TrimSpace(raw.DocumentPublisher.ContactDetails.Value),
+		initialRelease: raw.DocumentTracking.InitialReleaseDate,
+		currentRelease: raw.DocumentTracking.CurrentReleaseDate,
+		products:       make(map[string]product),
+	}
+	for _, branch := range raw.ProductTree.Branch {
+		branch.collect("", doc.products)
+	}
+	for _, p := range raw.ProductTree.FullProductName {
+		p.collect("", doc.products)
+	}
+
+	lang := raw.DocumentTitle.Lang
+	if lang == "" {
+		lang = msrcLang
+	}
+	for _, v := range raw.Vulnerability {
+		e := entry{title: v.Title.Value, cveId: v.Cve}
+		for _, note := range v.Notes {
+			if note.Title == "Description" {
+				e.descriptions = append(e.descriptions, localised{lang: lang, value: note.Value})
+			}
+		}
+		for _, cwe := range v.Cwes {
+			e.cwes = append(e.cwes, cwe.Id)
+		}
+		for _, status := range v.ProductStatuses {
+			if status.Type == msrcKnownAffected {
+				e.knownAffected = append(e.knownAffected, status.ProductIds...)
+			}
+		}
+		for _, t := range v.Threats {
+			e.threats = append(e.threats, threat{kind: msrcThreatTypes[t.Type], description: t.Description.Value, productIds: t.ProductIds})
+		}
+		for _, s := range v.CvssScoreSets {
+			e.scoreSets = append(e.scoreSets, scoreSet{baseScore: s.BaseScore, vector: strings.TrimSpace(s.Vector), productIds: s.ProductIds})
+		}
+		for _, r := range v.Remediations {
+			e.remediations = append(e.remediations, remediation{
+				kind:            msrcRemediation[r.Type],
+				description:     r.Description.Value,
+				url:             r.Url,
+				supercedence:    r.Supercedence,
+				fixedBuild:      r.FixedBuild,
+				restartRequired: r.RestartRequired.Value,
+				productIds:      r.ProductIds,
+			})
+		}
+		for _, r := range v.References {
+			e.references = append(e.references, reference{url: r.Url, description: r.Description.Value})
+		}
+		for _, revision := range v.RevisionHistory {
+			e.revisions = append(e.revisions, revision.Date)
+		}
+		doc.vulnerabilities = append(doc.vulnerabilities, e)
+	}
+	return doc.vulnerabilitiesFromDocument()
+}
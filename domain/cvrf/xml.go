@@ -0,0 +1,164 @@
+package cvrf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// The CVRF namespaces differ between 1.1 and 1.2, so elements are matched on
+// their local names only.
+type xmlDocument struct {
+	XMLName xml.Name `xml:"cvrfdoc"`
+	Title   struct {
+		Lang string `xml:"lang,attr"`
+	} `xml:"DocumentTitle"`
+	Publisher struct {
+		ContactDetails string `xml:"ContactDetails"`
+	} `xml:"DocumentPublisher"`
+	Tracking struct {
+		InitialReleaseDate string `xml:"InitialReleaseDate"`
+		CurrentReleaseDate string `xml:"CurrentReleaseDate"`
+	} `xml:"DocumentTracking"`
+	ProductTree     xmlBranch          `xml:"ProductTree"`
+	Vulnerabilities []xmlVulnerability `xml:"Vulnerability"`
+}
+
+type xmlBranch struct {
+	Type     string       `xml:"Type,attr"`
+	Name     string       `xml:"Name,attr"`
+	Branches []xmlBranch  `xml:"Branch"`
+	Products []xmlProduct `xml:"FullProductName"`
+}
+
+type xmlProduct struct {
+	ProductId string `xml:"ProductID,attr"`
+	Name      string `xml:",chardata"`
+}
+
+type xmlVulnerability struct {
+	Title string `xml:"Title"`
+	Notes []struct {
+		Title string `xml:"Title,attr"`
+		Type  string `xml:"Type,attr"`
+		Lang  string `xml:"lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"Notes>Note"`
+	Cve  string `xml:"CVE"`
+	Cwes []struct {
+		Id string `xml:"ID,attr"`
+	} `xml:"CWE"`
+	Statuses []struct {
+		Type       string   `xml:"Type,attr"`
+		ProductIds []string `xml:"ProductID"`
+	} `xml:"ProductStatuses>Status"`
+	Threats []struct {
+		Type        string   `xml:"Type,attr"`
+		Description string   `xml:"Description"`
+		ProductIds  []string `xml:"ProductID"`
+	} `xml:"Threats>Threat"`
+	ScoreSets []struct {
+		BaseScore  float64  `xml:"BaseScore"`
+		Vector     string   `xml:"Vector"`
+		ProductIds []string `xml:"ProductID"`
+	} `xml:"CVSSScoreSets>ScoreSet"`
+	Remediations []struct {
+		Type            string   `xml:"Type,attr"`
+		Description     string   `xml:"Description"`
+		Url             string   `xml:"URL"`
+		Supercedence    string   `xml:"Supercedence"`
+		FixedBuild      string   `xml:"FixedBuild"`
+		RestartRequired string   `xml:"RestartRequired"`
+		ProductIds      []string `xml:"ProductID"`
+	} `xml:"Remediations>Remediation"`
+	References []struct {
+		Url         string `xml:"URL"`
+		Description string `xml:"Description"`
+	} `xml:"References>Reference"`
+	Revisions []struct {
+		Date string `xml:"Date"`
+	} `xml:"RevisionHistory>Revision"`
+}
+
+func (b xmlBranch) collect(vendor string, products map[string]product) {
+	if b.Type == "Vendor" {
+		vendor = b.Name
+	}
+	for _, p := range b.Products {
+		existing, ok := products[p.ProductId]
+		if !ok || existing.vendor == "" {
+			products[p.ProductId] = product{name: strings.TrimSpace(p.Name), vendor: vendor}
+		}
+	}
+	for _, branch := range b.Branches {
+		branch.collect(vendor, products)
+	}
+}
+
+// ReadCvrf reads a CVRF 1.1 or 1.2 XML document, such as a monthly MSRC
+// security update release, and returns the vulnerabilities it describes.
+func ReadCvrf(r io.Reader) ([]vulnerability.Vulnerability, error) {
+	var raw xmlDocument
+	if err := xml.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode cvrf document: %s", err)
+	}
+
+	doc := document{
+		publisher:      strings.TrimSpace(raw.Publisher.ContactDetails),
+		initialRelease: raw.Tracking.InitialReleaseDate,
+		currentRelease: raw.Tracking.CurrentReleaseDate,
+		products:       make(map[string]product),
+	}
+	raw.ProductTree.collect("", doc.products)
+
+	for _, v := range raw.Vulnerabilities {
+		e := entry{title: v.Title, cveId: v.Cve}
+		for _, note := range v.Notes {
+			if note.Type != "Description" && note.Title != "Description" {
+				continue
+			}
+			// Notes without xml:lang are in the language of the document.
+			lang := note.Lang
+			if lang == "" {
+				lang = raw.Title.Lang
+			}
+			e.descriptions = append(e.descriptions, localised{lang: lang, value: note.Value})
+		}
+		for _, cwe := range v.Cwes {
+			e.cwes = append(e.cwes, cwe.Id)
+		}
+		for _, status := range v.Statuses {
+			if status.Type == statusKnownAffected {
+				e.knownAffected = append(e.knownAffected, status.ProductIds...)
+			}
+		}
+		for _, t := range v.Threats {
+			e.threats = append(e.threats, threat{kind: t.Type, description: t.Description, productIds: t.ProductIds})
+		}
+		for _, s := range v.ScoreSets {
+			e.scoreSets = append(e.scoreSets, scoreSet{baseScore: s.BaseScore, vector: strings.TrimSpace(s.Vector), productIds: s.ProductIds})
+		}
+		for _, r := range v.Remediations {
+			e.remediations = append(e.remediations, remediation{
+				kind:            r.Type,
+				description:     r.Description,
+				url:             r.Url,
+				supercedence:    r.Supercedence,
+				fixedBuild:      r.FixedBuild,
+				restartRequired: r.RestartRequired,
+				productIds:      r.ProductIds,
+			})
+		}
+		for _, r := range v.References {
+			e.references = append(e.references, reference{url: r.Url, description: r.Description})
+		}
+		for _, revision := range v.Revisions {
+			e.revisions = append(e.revisions, revision.Date)
+		}
+		doc.vulnerabilities = append(doc.vulnerabilities, e)
+	}
+	return doc.vulnerabilitiesFromDocument()
+}
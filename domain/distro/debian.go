@@ -0,0 +1,107 @@
+package distro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type debianEntry struct {
+	Description string                   `json:"description"`
+	Scope       string                   `json:"scope"`
+	Releases    map[string]debianRelease `json:"releases"`
+}
+
+type debianRelease struct {
+	Status       string            `json:"status"`
+	Repositories map[string]string `json:"repositories"`
+	FixedVersion string            `json:"fixed_version"`
+	Urgency      string            `json:"urgency"`
+}
+
+// ParseDebianTracker reads the Debian security tracker JSON export
+// (https://security-tracker.debian.org/tracker/data/json), a map of source
+// package to CVE to per-release status. Packages are decoded one at a time so
+// the whole export is never held in memory at once.
+func ParseDebianTracker(r io.Reader) ([]Record, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, fmt.Errorf("failed to read debian tracker: %s", err)
+	}
+
+	records := []Record{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read debian tracker: %s", err)
+		}
+		packageName, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("failed to read debian tracker: unexpected token %v", token)
+		}
+
+		var entries map[string]debianEntry
+		if err := decoder.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to decode debian package %s: %s", packageName, err)
+		}
+
+		cveIds := make([]string, 0, len(entries))
+		for cveId := range entries {
+			cveIds = append(cveIds, cveId)
+		}
+		sort.Strings(cveIds)
+
+		for _, cveId := range cveIds {
+			entry := entries[cveId]
+			record := Record{CveId: cveId, Description: entry.Description, Fixes: []vulnerability.DistributionFix{}}
+			releases := make([]string, 0, len(entry.Releases))
+			for release := range entry.Releases {
+				releases = append(releases, release)
+			}
+			sort.Strings(releases)
+			for _, release := range releases {
+				record.Fixes = append(record.Fixes, debianFix(packageName, release, entry.Releases[release]))
+			}
+			records = append(records, record)
+		}
+	}
+	return merge(records), nil
+}
+
+func debianFix(packageName string, release string, status debianRelease) vulnerability.DistributionFix {
+	fix := vulnerability.DistributionFix{
+		Distribution: DistributionDebian,
+		Release:      release,
+		Package:      packageName,
+		Priority:     status.Urgency,
+	}
+	switch status.Status {
+	case "resolved":
+		// A fixed version of 0 means the release never shipped the flaw.
+		if status.FixedVersion == "0" {
+			fix.Status = vulnerability.FixStatusNotAffected
+		} else {
+			fix.Status = vulnerability.FixStatusFixed
+			fix.FixedVersion = status.FixedVersion
+		}
+	case "open":
+		fix.Status = vulnerability.FixStatusAffected
+	default:
+		fix.Status = vulnerability.FixStatusUnknown
+	}
+	return fix
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package distro
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const (
+	DistributionDebian = "debian"
+	DistributionUbuntu = "ubuntu"
+	DistributionRedHat = "redhat"
+)
+
+// Record is what a distribution tracker says about a single CVE.
+type Record struct {
+	CveId       string                          `json:"cveId"`
+	Description string                          `json:"description,omitempty"`
+	Fixes       []vulnerability.DistributionFix `json:"fixes"`
+	Severity    *vulnerability.VendorSeverity   `json:"severity,omitempty"`
+}
+
+// Attach copies the fixes and vendor severity of the records about vuln onto
+// it, returning whether any record applied.
+func Attach(vuln *vulnerability.Vulnerability, records []Record) bool {
+	attached := false
+	for _, record := range records {
+		if !strings.EqualFold(record.CveId, vuln.CveId) {
+			continue
+		}
+		for _, fix := range record.Fixes {
+			vuln.AddDistributionFix(fix)
+		}
+		if record.Severity != nil {
+			vuln.AddVendorSeverity(*record.Severity)
+		}
+		attached = true
+	}
+	return attached
+}
+
+// Apply attaches the records to the vulnerabilities stored in the repository.
+// Records for CVEs the repository does not hold are skipped.
+func Apply(ctx context.Context, repository vulnerability.VulnerabilityRepository, records []Record) error {
+	byCveId := make(map[string][]Record)
+	for _, record := range records {
+		byCveId[strings.ToUpper(record.CveId)] = append(byCveId[strings.ToUpper(record.CveId)], record)
+	}
+
+	for cveId, cveRecords := range byCveId {
+		vuln, err := repository.Get(ctx, cveId)
+		if errors.Is(err, vulnerability.ErrVulnerabilityNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if Attach(vuln, cveRecords) {
+			if err := repository.Update(ctx, vuln.CveId, vuln); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// merge folds records for the same CVE together, keeping first-seen order.
+func merge(records []Record) []Record {
+	merged := []Record{}
+	positions := make(map[string]int)
+	for _, record := range records {
+		position, ok := positions[record.CveId]
+		if !ok {
+			positions[record.CveId] = len(merged)
+			merged = append(merged, record)
+			continue
+		}
+		merged[position].Fixes = append(merged[position].Fixes, record.Fixes...)
+		if merged[position].Description == "" {
+			merged[position].Description = record.Description
+		}
+	}
+	return merged
+}
@@ -0,0 +1,220 @@
+package distro
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+const testDebianTracker = `{
+	"apache-log4j2": {
+		"CVE-2021-44228": {
+			"description": "Remote code injection in Log4j",
+			"scope": "remote",
+			"releases": {
+				"bullseye": {"status": "resolved", "repositories": {"bullseye": "2.17.0-1~deb11u1"}, "fixed_version": "2.15.0-1~deb11u1", "urgency": "not yet assigned"},
+				"buster": {"status": "resolved", "repositories": {"buster": "2.17.0-1~deb10u1"}, "fixed_version": "0", "urgency": "unimportant"},
+				"sid": {"status": "open", "repositories": {"sid": "2.14.1-1"}, "urgency": "high"}
+			}
+		}
+	}
+}`
+
+const testUbuntuCve = `{
+	"id": "CVE-2021-44228",
+	"description": "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints.",
+	"priority": "high",
+	"packages": [
+		{
+			"name": "apache-log4j2",
+			"statuses": [
+				{"release_codename": "focal", "status": "released", "description": "2.12.2-0ubuntu0.20.04.1"},
+				{"release_codename": "bionic", "status": "DNE", "description": ""},
+				{"release_codename": "xenial", "status": "ignored", "description": "end of standard support"}
+			]
+		}
+	]
+}`
+
+const testUbuntuNotice = `{
+	"id": "USN-5192-1",
+	"cves_ids": ["CVE-2021-44228"],
+	"release_packages": {
+		"focal": [
+			{"name": "apache-log4j2", "version": "2.12.2-0ubuntu0.20.04.1", "is_source": true},
+			{"name": "liblog4j2-java", "version": "2.12.2-0ubuntu0.20.04.1", "is_source": false}
+		]
+	}
+}`
+
+const testRedHatCve = `{
+	"name": "CVE-2021-44228",
+	"threat_severity": "Critical",
+	"details": ["A flaw was found in the Java logging library Apache Log4j 2."],
+	"affected_release": [
+		{"product_name": "Red Hat Enterprise Linux 8", "advisory": "RHSA-2021:5206", "cpe": "cpe:/a:redhat:rhel_eus:8.4", "package": "log4j-0:2.11.2-2.el8_5"}
+	],
+	"package_state": [
+		{"product_name": "Red Hat Enterprise Linux 7", "fix_state": "Not affected", "package_name": "log4j", "cpe": "cpe:/o:redhat:enterprise_linux:7"},
+		{"product_name": "Red Hat Enterprise Linux 6", "fix_state": "Out of support scope", "package_name": "log4j", "cpe": "cpe:/o:redhat:enterprise_linux:6"}
+	]
+}`
+
+func fixFor(fixes []vulnerability.DistributionFix, release string) *vulnerability.DistributionFix {
+	for i := range fixes {
+		if fixes[i].Release == release {
+			return &fixes[i]
+		}
+	}
+	return nil
+}
+
+func TestParseDebianTracker(t *testing.T) {
+	records, err := ParseDebianTracker(strings.NewReader(testDebianTracker))
+	if err != nil {
+		t.Fatalf("failed to parse debian tracker: %s", err)
+	}
+	if len(records) != 1 || len(records[0].Fixes) != 3 {
+		t.Fatalf("failed to parse debian tracker, got %+v", records)
+	}
+	if fix := fixFor(records[0].Fixes, "bullseye"); fix == nil || fix.Status != vulnerability.FixStatusFixed || fix.FixedVersion != "2.15.0-1~deb11u1" {
+		t.Errorf("failed to parse fixed release, got %+v", fix)
+	}
+	if fix := fixFor(records[0].Fixes, "buster"); fix == nil || fix.Status != vulnerability.FixStatusNotAffected {
+		t.Errorf("failed to parse unaffected release, got %+v", fix)
+	}
+	if fix := fixFor(records[0].Fixes, "sid"); fix == nil || fix.Status != vulnerability.FixStatusAffected || fix.Priority != "high" {
+		t.Errorf("failed to parse open release, got %+v", fix)
+	}
+}
+
+func TestParseUbuntu(t *testing.T) {
+	records, err := ParseUbuntuCves(strings.NewReader(testUbuntuCve))
+	if err != nil {
+		t.Fatalf("failed to parse ubuntu cve: %s", err)
+	}
+	if len(records) != 1 || records[0].Severity == nil || records[0].Severity.Rating != "high" {
+		t.Fatalf("failed to parse ubuntu cve, got %+v", records)
+	}
+	if fix := fixFor(records[0].Fixes, "focal"); fix == nil || fix.FixedVersion != "2.12.2-0ubuntu0.20.04.1" {
+		t.Errorf("failed to parse released status, got %+v", fix)
+	}
+	if fix := fixFor(records[0].Fixes, "bionic"); fix == nil || fix.Status != vulnerability.FixStatusNotAffected {
+		t.Errorf("failed to parse DNE status, got %+v", fix)
+	}
+	if fix := fixFor(records[0].Fixes, "xenial"); fix == nil || fix.Status != vulnerability.FixStatusWillNotFix || fix.FixedVersion != "" {
+		t.Errorf("failed to parse ignored status, got %+v", fix)
+	}
+
+	records, err = ParseUbuntuNotice(strings.NewReader(testUbuntuNotice))
+	if err != nil {
+		t.Fatalf("failed to parse ubuntu notice: %s", err)
+	}
+	if len(records) != 1 || len(records[0].Fixes) != 1 || records[0].Fixes[0].Advisory != "USN-5192-1" {
+		t.Errorf("failed to parse ubuntu notice, got %+v", records)
+	}
+}
+
+func TestParseRedHatCve(t *testing.T) {
+	record, err := ParseRedHatCve(strings.NewReader(testRedHatCve))
+	if err != nil {
+		t.Fatalf("failed to parse red hat cve: %s", err)
+	}
+	fix := fixFor(record.Fixes, "Red Hat Enterprise Linux 8")
+	if fix == nil || fix.Package != "log4j" || fix.FixedVersion != "0:2.11.2-2.el8_5" || fix.Advisory != "RHSA-2021:5206" {
+		t.Errorf("failed to parse affected release, got %+v", fix)
+	}
+	if fix := fixFor(record.Fixes, "Red Hat Enterprise Linux 7"); fix == nil || fix.Status != vulnerability.FixStatusNotAffected {
+		t.Errorf("failed to parse not affected state, got %+v", fix)
+	}
+	if fix := fixFor(record.Fixes, "Red Hat Enterprise Linux 6"); fix == nil || fix.Status != vulnerability.FixStatusWillNotFix {
+		t.Errorf("failed to parse out of support state, got %+v", fix)
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.MustNewMemoryVulnerabilityRepository()
+	if err := repo.Add(ctx, vulnerability.Vulnerability{CveId: "CVE-2021-44228"}); err != nil {
+		t.Fatalf("failed to add vulnerability: %s", err)
+	}
+
+	debian, _ := ParseDebianTracker(strings.NewReader(testDebianTracker))
+	redHat, _ := ParseRedHatCve(strings.NewReader(testRedHatCve))
+	records := append(debian, redHat, Record{CveId: "CVE-1999-0001"})
+	if err := Apply(ctx, repo, records); err != nil {
+		t.Fatalf("failed to apply records: %s", err)
+	}
+	// Applying twice must not duplicate fixes.
+	if err := Apply(ctx, repo, records); err != nil {
+		t.Fatalf("failed to apply records: %s", err)
+	}
+
+	vuln, err := repo.Get(ctx, "CVE-2021-44228")
+	if err != nil {
+		t.Fatalf("failed to get vulnerability: %s", err)
+	}
+	if len(vuln.DistributionFixes) != 6 {
+		t.Errorf("failed to attach distribution fixes, got %d", len(vuln.DistributionFixes))
+	}
+	if len(vuln.VendorSeverities) != 1 || vuln.VendorSeverities[0].Source != "Red Hat" {
+		t.Errorf("failed to attach vendor severity, got %+v", vuln.VendorSeverities)
+	}
+}
+
+const testUbuntuOval = `<?xml version="1.0" ?>
+<oval_definitions xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5" xmlns:linux="http://oval.mitre.org/XMLSchema/oval-definitions-5#linux">
+  <definitions>
+    <definition class="inventory" id="oval:com.ubuntu.focal:def:100" version="1">
+      <metadata><title>Check that Ubuntu 20.04 LTS (focal) is installed.</title></metadata>
+    </definition>
+    <definition class="vulnerability" id="oval:com.ubuntu.focal:def:2021442280000000" version="1">
+      <metadata>
+        <title>CVE-2021-44228 on Ubuntu 20.04 LTS (focal) - high.</title>
+        <description>Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints.</description>
+        <reference source="CVE" ref_id="CVE-2021-44228" ref_url="https://ubuntu.com/security/CVE-2021-44228"/>
+        <advisory><severity>High</severity></advisory>
+      </metadata>
+      <criteria operator="AND">
+        <extend_definition definition_ref="oval:com.ubuntu.focal:def:100" applicability_check="true"/>
+        <criteria operator="OR">
+          <criterion test_ref="oval:com.ubuntu.focal:tst:2021442280000000" comment="apache-log4j2 package in focal was vulnerable but has been fixed (note: '2.12.2-0ubuntu0.20.04.1')."/>
+          <criterion test_ref="oval:com.ubuntu.focal:tst:2021442280000010" comment="liblog4j2-java package in focal is affected and needs fixing."/>
+        </criteria>
+      </criteria>
+    </definition>
+  </definitions>
+  <tests>
+    <linux:dpkginfo_test check="at least one" id="oval:com.ubuntu.focal:tst:2021442280000000" version="1">
+      <linux:object object_ref="oval:com.ubuntu.focal:obj:2021442280000000"/>
+      <linux:state state_ref="oval:com.ubuntu.focal:ste:2021442280000000"/>
+    </linux:dpkginfo_test>
+  </tests>
+  <states>
+    <linux:dpkginfo_state id="oval:com.ubuntu.focal:ste:2021442280000000" version="1">
+      <linux:evr datatype="debian_evr_string" operation="less than">0:2.12.2-0ubuntu0.20.04.1</linux:evr>
+    </linux:dpkginfo_state>
+  </states>
+</oval_definitions>`
+
+func TestParseUbuntuOval(t *testing.T) {
+	records, err := ParseUbuntuOval(strings.NewReader(testUbuntuOval))
+	if err != nil {
+		t.Fatalf("failed to parse ubuntu oval: %s", err)
+	}
+	if len(records) != 1 || records[0].CveId != "CVE-2021-44228" || records[0].Severity == nil || records[0].Severity.Rating != "high" {
+		t.Fatalf("failed to parse ubuntu oval, got %+v", records)
+	}
+	if len(records[0].Fixes) != 2 {
+		t.Fatalf("expected a fix per package criterion, got %+v", records[0].Fixes)
+	}
+	if fix := records[0].Fixes[0]; fix.Package != "apache-log4j2" || fix.Release != "focal" || fix.Status != vulnerability.FixStatusFixed || fix.FixedVersion != "2.12.2-0ubuntu0.20.04.1" {
+		t.Errorf("failed to parse fixed criterion, got %+v", fix)
+	}
+	if fix := records[0].Fixes[1]; fix.Package != "liblog4j2-java" || fix.Status != vulnerability.FixStatusAffected || fix.FixedVersion != "" {
+		t.Errorf("failed to parse affected criterion, got %+v", fix)
+	}
+}
@@ -0,0 +1,97 @@
+package distro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type redHatCve struct {
+	Name            string `json:"name"`
+	ThreatSeverity  string `json:"threat_severity"`
+	Details         []string
+	AffectedRelease []struct {
+		ProductName string `json:"product_name"`
+		Advisory    string `json:"advisory"`
+		Cpe         string `json:"cpe"`
+		Package     string `json:"package"`
+	} `json:"affected_release"`
+	PackageState []struct {
+		ProductName string `json:"product_name"`
+		FixState    string `json:"fix_state"`
+		PackageName string `json:"package_name"`
+		Cpe         string `json:"cpe"`
+	} `json:"package_state"`
+}
+
+// ParseRedHatCve reads a CVE from the Red Hat security data API
+// (https://access.redhat.com/hydra/rest/securitydata/cve/CVE-2021-44228.json).
+// Shipped errata become fixed entries and package states cover the rest.
+func ParseRedHatCve(r io.Reader) (Record, error) {
+	var cve redHatCve
+	if err := json.NewDecoder(r).Decode(&cve); err != nil {
+		return Record{}, fmt.Errorf("failed to decode red hat cve json: %s", err)
+	}
+
+	record := Record{CveId: cve.Name, Fixes: []vulnerability.DistributionFix{}}
+	if len(cve.Details) > 0 {
+		record.Description = cve.Details[0]
+	}
+	if cve.ThreatSeverity != "" {
+		record.Severity = &vulnerability.VendorSeverity{Source: "Red Hat", Rating: cve.ThreatSeverity}
+	}
+
+	for _, release := range cve.AffectedRelease {
+		name, version := splitNevra(release.Package)
+		record.Fixes = append(record.Fixes, vulnerability.DistributionFix{
+			Distribution: DistributionRedHat,
+			Release:      release.ProductName,
+			Package:      name,
+			Status:       vulnerability.FixStatusFixed,
+			FixedVersion: version,
+			Advisory:     release.Advisory,
+			Priority:     cve.ThreatSeverity,
+		})
+	}
+	for _, state := range cve.PackageState {
+		record.Fixes = append(record.Fixes, vulnerability.DistributionFix{
+			Distribution: DistributionRedHat,
+			Release:      state.ProductName,
+			Package:      state.PackageName,
+			Status:       redHatStatus(state.FixState),
+			Priority:     cve.ThreatSeverity,
+		})
+	}
+	return record, nil
+}
+
+func redHatStatus(fixState string) vulnerability.FixStatus {
+	switch strings.ToLower(fixState) {
+	case "not affected":
+		return vulnerability.FixStatusNotAffected
+	case "affected", "fix deferred", "under investigation":
+		return vulnerability.FixStatusAffected
+	case "will not fix", "out of support scope":
+		return vulnerability.FixStatusWillNotFix
+	}
+	return vulnerability.FixStatusUnknown
+}
+
+// splitNevra separates an RPM name-epoch:version-release string such as
+// log4j-0:2.11.2-2.el8_5 into the package name and the epoch:version-release.
+// Without an epoch the last two dash separated fields are the version.
+func splitNevra(nevra string) (string, string) {
+	if colon := strings.Index(nevra, ":"); colon >= 0 {
+		if dash := strings.LastIndex(nevra[:colon], "-"); dash >= 0 {
+			return nevra[:dash], nevra[dash+1:]
+		}
+	}
+	parts := strings.Split(nevra, "-")
+	if len(parts) < 3 {
+		return nevra, ""
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), strings.Join(parts[len(parts)-2:], "-")
+}
@@ -0,0 +1,279 @@
+package distro
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type ubuntuCve struct {
+	Id          string `json:"id"`
+	Description string `json:"description"`
+	Priority    string `json:"priority"`
+	Packages    []struct {
+		Name     string `json:"name"`
+		Statuses []struct {
+			ReleaseCodename string `json:"release_codename"`
+			Status          string `json:"status"`
+			Description     string `json:"description"`
+		} `json:"statuses"`
+	} `json:"packages"`
+}
+
+// ParseUbuntuCves reads Ubuntu CVE JSON as served by the Ubuntu security API
+// (https://ubuntu.com/security/cves/CVE-2021-44228.json), either a single CVE
+// or a {"cves": [...]} listing.
+func ParseUbuntuCves(r io.Reader) ([]Record, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ubuntu cve json: %s", err)
+	}
+
+	var listing struct {
+		Cves []ubuntuCve `json:"cves"`
+	}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, fmt.Errorf("failed to decode ubuntu cve json: %s", err)
+	}
+	if listing.Cves == nil {
+		var single ubuntuCve
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&single); err != nil {
+			return nil, fmt.Errorf("failed to decode ubuntu cve json: %s", err)
+		}
+		listing.Cves = []ubuntuCve{single}
+	}
+
+	records := []Record{}
+	for _, cve := range listing.Cves {
+		record := Record{CveId: cve.Id, Description: cve.Description, Fixes: []vulnerability.DistributionFix{}}
+		if cve.Priority != "" {
+			record.Severity = &vulnerability.VendorSeverity{Source: "Ubuntu", Rating: cve.Priority}
+		}
+		for _, pkg := range cve.Packages {
+			for _, status := range pkg.Statuses {
+				fix := vulnerability.DistributionFix{
+					Distribution: DistributionUbuntu,
+					Release:      status.ReleaseCodename,
+					Package:      pkg.Name,
+					Status:       ubuntuStatus(status.Status),
+					Priority:     cve.Priority,
+				}
+				if fix.Status == vulnerability.FixStatusFixed {
+					fix.FixedVersion = status.Description
+				}
+				record.Fixes = append(record.Fixes, fix)
+			}
+		}
+		records = append(records, record)
+	}
+	return merge(records), nil
+}
+
+func ubuntuStatus(status string) vulnerability.FixStatus {
+	switch status {
+	case "released":
+		return vulnerability.FixStatusFixed
+	case "not-affected", "DNE":
+		return vulnerability.FixStatusNotAffected
+	case "needed", "pending", "deferred", "active":
+		return vulnerability.FixStatusAffected
+	case "ignored":
+		return vulnerability.FixStatusWillNotFix
+	}
+	return vulnerability.FixStatusUnknown
+}
+
+type ubuntuNotice struct {
+	Id              string   `json:"id"`
+	CveIds          []string `json:"cves_ids"`
+	ReleasePackages map[string][]struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		IsSource bool   `json:"is_source"`
+	} `json:"release_packages"`
+}
+
+// ParseUbuntuNotice reads an Ubuntu Security Notice in the JSON form served
+// at https://ubuntu.com/security/notices/USN-5192-1.json. Every CVE the
+// notice covers is marked fixed in the source packages it lists.
+func ParseUbuntuNotice(r io.Reader) ([]Record, error) {
+	var notice ubuntuNotice
+	if err := json.NewDecoder(r).Decode(&notice); err != nil {
+		return nil, fmt.Errorf("failed to decode ubuntu security notice: %s", err)
+	}
+
+	releases := make([]string, 0, len(notice.ReleasePackages))
+	for release := range notice.ReleasePackages {
+		releases = append(releases, release)
+	}
+	sort.Strings(releases)
+
+	records := []Record{}
+	for _, cveId := range notice.CveIds {
+		record := Record{CveId: cveId, Fixes: []vulnerability.DistributionFix{}}
+		for _, release := range releases {
+			for _, pkg := range notice.ReleasePackages[release] {
+				if !pkg.IsSource {
+					continue
+				}
+				record.Fixes = append(record.Fixes, vulnerability.DistributionFix{
+					Distribution: DistributionUbuntu,
+					Release:      release,
+					Package:      pkg.Name,
+					Status:       vulnerability.FixStatusFixed,
+					FixedVersion: pkg.Version,
+					Advisory:     notice.Id,
+				})
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+type ubuntuOval struct {
+	Definitions []ubuntuOvalDefinition `xml:"definitions>definition"`
+	Tests       []struct {
+		Id    string `xml:"id,attr"`
+		State struct {
+			StateRef string `xml:"state_ref,attr"`
+		} `xml:"state"`
+	} `xml:"tests>dpkginfo_test"`
+	States []struct {
+		Id  string `xml:"id,attr"`
+		Evr struct {
+			Operation string `xml:"operation,attr"`
+			Value     string `xml:",chardata"`
+		} `xml:"evr"`
+	} `xml:"states>dpkginfo_state"`
+}
+
+type ubuntuOvalDefinition struct {
+	Class    string `xml:"class,attr"`
+	Metadata struct {
+		Description string `xml:"description"`
+		References  []struct {
+			Source string `xml:"source,attr"`
+			RefId  string `xml:"ref_id,attr"`
+		} `xml:"reference"`
+		Severity string `xml:"advisory>severity"`
+	} `xml:"metadata"`
+	Criteria ubuntuOvalCriteria `xml:"criteria"`
+}
+
+type ubuntuOvalCriteria struct {
+	Criteria   []ubuntuOvalCriteria `xml:"criteria"`
+	Criterions []struct {
+		TestRef string `xml:"test_ref,attr"`
+		Comment string `xml:"comment,attr"`
+	} `xml:"criterion"`
+}
+
+// ubuntuOvalCriterion matches the comments Ubuntu writes on each package
+// criterion, such as "apache-log4j2 package in focal was vulnerable but has
+// been fixed (note: '2.12.2-0ubuntu0.20.04.1').".
+var ubuntuOvalCriterion = regexp.MustCompile(`^(\S+) package in (\S+) (.*?)(?: \(note: '([^']*)'\))?\.?$`)
+
+// ParseUbuntuOval reads the per-release Ubuntu CVE OVAL definitions published
+// at https://security-metadata.canonical.com/oval/ (com.ubuntu.focal.cve.oval.xml).
+// Package and release come from the criterion comments, and fixed versions
+// from the dpkg state each criterion tests.
+func ParseUbuntuOval(r io.Reader) ([]Record, error) {
+	var oval ubuntuOval
+	if err := xml.NewDecoder(r).Decode(&oval); err != nil {
+		return nil, fmt.Errorf("failed to decode ubuntu oval: %s", err)
+	}
+
+	states := make(map[string]string)
+	for _, state := range oval.States {
+		if state.Evr.Operation == "less than" {
+			states[state.Id] = strings.TrimPrefix(strings.TrimSpace(state.Evr.Value), "0:")
+		}
+	}
+	fixedVersions := make(map[string]string)
+	for _, test := range oval.Tests {
+		if version, ok := states[test.State.StateRef]; ok {
+			fixedVersions[test.Id] = version
+		}
+	}
+
+	records := []Record{}
+	for _, definition := range oval.Definitions {
+		if definition.Class != "vulnerability" {
+			continue
+		}
+		cveIds := []string{}
+		for _, reference := range definition.Metadata.References {
+			if reference.Source == "CVE" {
+				cveIds = append(cveIds, reference.RefId)
+			}
+		}
+		priority := strings.ToLower(strings.TrimSpace(definition.Metadata.Severity))
+
+		fixes := []vulnerability.DistributionFix{}
+		definition.Criteria.each(func(testRef string, comment string) {
+			match := ubuntuOvalCriterion.FindStringSubmatch(strings.TrimSpace(comment))
+			if match == nil {
+				return
+			}
+			fix := vulnerability.DistributionFix{
+				Distribution: DistributionUbuntu,
+				Release:      match[2],
+				Package:      match[1],
+				Status:       ubuntuOvalStatus(match[3]),
+				Priority:     priority,
+			}
+			if fix.Status == vulnerability.FixStatusFixed {
+				fix.FixedVersion = fixedVersions[testRef]
+				if fix.FixedVersion == "" {
+					fix.FixedVersion = match[4]
+				}
+			}
+			fixes = append(fixes, fix)
+		})
+
+		for _, cveId := range cveIds {
+			record := Record{
+				CveId:       cveId,
+				Description: strings.TrimSpace(definition.Metadata.Description),
+				Fixes:       append([]vulnerability.DistributionFix{}, fixes...),
+			}
+			if priority != "" {
+				record.Severity = &vulnerability.VendorSeverity{Source: "Ubuntu", Rating: priority}
+			}
+			records = append(records, record)
+		}
+	}
+	return merge(records), nil
+}
+
+func (c ubuntuOvalCriteria) each(visit func(testRef string, comment string)) {
+	for _, criterion := range c.Criterions {
+		visit(criterion.TestRef, criterion.Comment)
+	}
+	for _, nested := range c.Criteria {
+		nested.each(visit)
+	}
+}
+
+func ubuntuOvalStatus(status string) vulnerability.FixStatus {
+	switch {
+	case strings.Contains(status, "has been fixed"):
+		return vulnerability.FixStatusFixed
+	case strings.Contains(status, "not affected"):
+		return vulnerability.FixStatusNotAffected
+	case strings.Contains(status, "will not be fixed"):
+		return vulnerability.FixStatusWillNotFix
+	case strings.Contains(status, "is affected"):
+		return vulnerability.FixStatusAffected
+	}
+	return vulnerability.FixStatusUnknown
+}
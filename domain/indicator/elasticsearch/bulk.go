@@ -0,0 +1,323 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/elastic/go-elasticsearch/esapi"
+)
+
+var (
+	ErrBulkIndexerClosed = errors.New("the bulk indexer is closed")
+	ErrInvalidBulkConfig = errors.New("the bulk config is invalid")
+)
+
+// BulkConfig tunes bulk indexing. Zero values take the defaults of
+// DefaultBulkConfig.
+type BulkConfig struct {
+	// BatchSize is the number of indicators sent in one _bulk request.
+	BatchSize int
+	// FlushInterval sends a partial batch once it has waited this long.
+	FlushInterval time.Duration
+	// Workers is the number of _bulk requests in flight at once.
+	Workers int
+	// Refresh is passed through as the refresh parameter: "true", "false" or
+	// "wait_for". Empty leaves refreshing to the index settings.
+	Refresh string
+}
+
+var DefaultBulkConfig = BulkConfig{
+	BatchSize:     500,
+	FlushInterval: 5 * time.Second,
+	Workers:       2,
+}
+
+func (bc BulkConfig) withDefaults() (BulkConfig, error) {
+	if bc.BatchSize < 0 || bc.Workers < 0 || bc.FlushInterval < 0 {
+		return bc, fmt.Errorf("%w: sizes and intervals cannot be negative", ErrInvalidBulkConfig)
+	}
+	switch bc.Refresh {
+	case "", "true", "false", "wait_for":
+	default:
+		return bc, fmt.Errorf("%w: unknown refresh policy %q", ErrInvalidBulkConfig, bc.Refresh)
+	}
+	if bc.BatchSize == 0 {
+		bc.BatchSize = DefaultBulkConfig.BatchSize
+	}
+	if bc.FlushInterval == 0 {
+		bc.FlushInterval = DefaultBulkConfig.FlushInterval
+	}
+	if bc.Workers == 0 {
+		bc.Workers = DefaultBulkConfig.Workers
+	}
+	return bc, nil
+}
+
+// BulkFailure is an indicator the cluster did not store. Err is an
+// ElasticsearchError wrapping indicator.ErrIndicatorAlreadyExists when the id
+// was taken, or the error that stopped its whole batch.
+type BulkFailure struct {
+	Id     string
+	Status int
+	Err    error
+}
+
+func (bf BulkFailure) Error() string {
+	return fmt.Sprintf("failed to index document %s: %s", bf.Id, bf.Err)
+}
+
+// AddMany creates the indicators through the _bulk endpoint. Like Add it
+// never overwrites, so indicators that already exist come back as failures.
+// The error is only set when a whole batch could not be sent, or ctx was
+// cancelled, and the indicators affected are reported as failures too.
+// With monthly indices an id is only checked against its own month.
+func (eir ElasticsearchIndicatorRepository) AddMany(ctx context.Context, indicators []indicator.Indicator, config BulkConfig) ([]BulkFailure, error) {
+	indexer, err := NewBulkIndexer(ctx, eir, config)
+	if err != nil {
+		return nil, err
+	}
+	for index, i := range indicators {
+		if err := indexer.Add(i); err != nil {
+			failures, closeErr := indexer.Close()
+			if closeErr == nil {
+				closeErr = err
+			}
+			return append(failures, failAll(indicators[index:], err)...), closeErr
+		}
+	}
+	return indexer.Close()
+}
+
+// BulkIndexer batches indicators added over time, for crawlers that do not
+// have the whole backfill in hand.
+type BulkIndexer struct {
+	repository ElasticsearchIndicatorRepository
+	config     BulkConfig
+	ctx        context.Context
+
+	// lock guards pending and closed, and is held while handing a batch to
+	// the workers so no batch is sent after Close.
+	lock    sync.Mutex
+	pending []indicator.Indicator
+	closed  bool
+
+	failuresLock sync.Mutex
+	failures     []BulkFailure
+	err          error
+
+	batches chan []indicator.Indicator
+	done    chan struct{}
+	running sync.WaitGroup
+}
+
+func NewBulkIndexer(ctx context.Context, repository ElasticsearchIndicatorRepository, config BulkConfig) (*BulkIndexer, error) {
+	config, err := config.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	bi := &BulkIndexer{
+		repository: repository,
+		config:     config,
+		ctx:        ctx,
+		failures:   []BulkFailure{},
+		batches:    make(chan []indicator.Indicator),
+		done:       make(chan struct{}),
+	}
+
+	workers := sync.WaitGroup{}
+	for worker := 0; worker < config.Workers; worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for batch := range bi.batches {
+				failures, err := repository.bulk(ctx, batch, config.Refresh)
+				bi.record(failures, err)
+			}
+		}()
+	}
+	bi.running.Add(1)
+	go func() {
+		defer bi.running.Done()
+		bi.flushPeriodically()
+		close(bi.batches)
+		workers.Wait()
+	}()
+	return bi, nil
+}
+
+func MustNewBulkIndexer(ctx context.Context, repository ElasticsearchIndicatorRepository, config BulkConfig) *BulkIndexer {
+	indexer, err := NewBulkIndexer(ctx, repository, config)
+	if err != nil {
+		panic(err)
+	}
+	return indexer
+}
+
+// Add queues the indicator, blocking while every worker is busy and a full
+// batch is waiting.
+func (bi *BulkIndexer) Add(i indicator.Indicator) error {
+	bi.lock.Lock()
+	defer bi.lock.Unlock()
+	if bi.closed {
+		return ErrBulkIndexerClosed
+	}
+	if err := bi.ctx.Err(); err != nil {
+		return err
+	}
+	bi.pending = append(bi.pending, i)
+	if len(bi.pending) >= bi.config.BatchSize {
+		bi.flush()
+	}
+	return nil
+}
+
+// Close sends what is still queued, waits for every request and returns the
+// failures of the indexer's whole life.
+func (bi *BulkIndexer) Close() ([]BulkFailure, error) {
+	bi.lock.Lock()
+	if !bi.closed {
+		bi.closed = true
+		bi.flush()
+		close(bi.done)
+	}
+	bi.lock.Unlock()
+	bi.running.Wait()
+
+	bi.failuresLock.Lock()
+	defer bi.failuresLock.Unlock()
+	return bi.failures, bi.err
+}
+
+func (bi *BulkIndexer) flushPeriodically() {
+	ticker := time.NewTicker(bi.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-bi.done:
+			return
+		case <-ticker.C:
+			bi.lock.Lock()
+			if !bi.closed {
+				bi.flush()
+			}
+			bi.lock.Unlock()
+		}
+	}
+}
+
+// flush must be called with the lock held.
+func (bi *BulkIndexer) flush() {
+	if len(bi.pending) == 0 {
+		return
+	}
+	batch := bi.pending
+	bi.pending = nil
+	select {
+	case bi.batches <- batch:
+	case <-bi.ctx.Done():
+		bi.record(failAll(batch, bi.ctx.Err()), bi.ctx.Err())
+	}
+}
+
+func (bi *BulkIndexer) record(failures []BulkFailure, err error) {
+	bi.failuresLock.Lock()
+	defer bi.failuresLock.Unlock()
+	bi.failures = append(bi.failures, failures...)
+	if err != nil && bi.err == nil {
+		bi.err = err
+	}
+}
+
+type bulkAction struct {
+	Create struct {
+		Index string `json:"_index"`
+		Id    string `json:"_id"`
+	} `json:"create"`
+}
+
+type bulkResponse struct {
+	Took   int  `json:"took"`
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Id     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (eir ElasticsearchIndicatorRepository) bulk(ctx context.Context, batch []indicator.Indicator, refresh string) ([]BulkFailure, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, i := range batch {
+		var action bulkAction
+		action.Create.Index = eir.writeIndex(i)
+		action.Create.Id = i.Id
+		if err := encoder.Encode(action); err != nil {
+			return failAll(batch, err), err
+		}
+		if err := encoder.Encode(i); err != nil {
+			return failAll(batch, err), err
+		}
+	}
+
+	request := esapi.BulkRequest{
+		Index:   eir.IndexName,
+		Body:    &body,
+		Refresh: refresh,
+	}
+	res, err := request.Do(ctx, eir.Client)
+	if err != nil {
+		err := transportError(fmt.Sprintf("bulk index %d documents", len(batch)), err)
+		return failAll(batch, err), err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		err := responseError(res, fmt.Sprintf("bulk index %d documents", len(batch)))
+		return failAll(batch, err), err
+	}
+
+	var r bulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		err = fmt.Errorf("error parsing the response: %s", err)
+		return failAll(batch, err), err
+	}
+
+	failures := []BulkFailure{}
+	for _, item := range r.Items {
+		for _, result := range item {
+			if result.Status < http.StatusMultipleChoices {
+				continue
+			}
+			failures = append(failures, BulkFailure{
+				Id:     result.Id,
+				Status: result.Status,
+				Err:    bulkItemError(result.Id, result.Status, result.Error.Type, result.Error.Reason),
+			})
+		}
+	}
+	return failures, nil
+}
+
+func failAll(batch []indicator.Indicator, err error) []BulkFailure {
+	status := 0
+	var ee *ElasticsearchError
+	if errors.As(err, &ee) {
+		status = ee.Status
+	}
+	failures := make([]BulkFailure, 0, len(batch))
+	for _, i := range batch {
+		failures = append(failures, BulkFailure{Id: i.Id, Status: status, Err: err})
+	}
+	return failures
+}
@@ -0,0 +1,123 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/elastic/go-elasticsearch"
+)
+
+// fakeCluster answers _bulk requests, rejecting ids it has already seen the
+// way a create action does.
+type fakeCluster struct {
+	lock     sync.Mutex
+	ids      map[string]bool
+	requests int
+	refresh  []string
+}
+
+func (fc *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/indicators/_bulk" {
+		http.NotFound(w, r)
+		return
+	}
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	fc.requests++
+	fc.refresh = append(fc.refresh, r.URL.Query().Get("refresh"))
+
+	items := []map[string]interface{}{}
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var action bulkAction
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			http.Error(w, "malformed bulk body", http.StatusBadRequest)
+			return
+		}
+		result := map[string]interface{}{"_id": action.Create.Id, "status": http.StatusCreated}
+		if fc.ids[action.Create.Id] {
+			result["status"] = http.StatusConflict
+			result["error"] = map[string]string{"type": "version_conflict_engine_exception", "reason": "document already exists"}
+		}
+		fc.ids[action.Create.Id] = true
+		items = append(items, map[string]interface{}{"create": result})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"took": 1, "errors": false, "items": items})
+}
+
+func newFakeRepository(t *testing.T, cluster http.Handler) ElasticsearchIndicatorRepository {
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	return ElasticsearchIndicatorRepository{Client: client, IndexName: "indicators"}
+}
+
+func TestAddMany(t *testing.T) {
+	cluster := &fakeCluster{ids: map[string]bool{"t3_taken": true}}
+	repo := newFakeRepository(t, cluster)
+
+	indicators := []indicator.Indicator{}
+	for n := 0; n < 4; n++ {
+		indicators = append(indicators, indicator.Indicator{Id: fmt.Sprintf("t3_%d", n)})
+	}
+	indicators = append(indicators, indicator.Indicator{Id: "t3_taken"})
+
+	failures, err := repo.AddMany(context.Background(), indicators, BulkConfig{BatchSize: 2, Workers: 2, Refresh: "wait_for"})
+	if err != nil {
+		t.Fatalf("failed to bulk index: %s", err)
+	}
+	if len(failures) != 1 || failures[0].Id != "t3_taken" || !errors.Is(failures[0].Err, indicator.ErrIndicatorAlreadyExists) {
+		t.Errorf("expected the taken id to be reported, got %+v", failures)
+	}
+	if cluster.requests != 3 || len(cluster.ids) != 5 || cluster.refresh[0] != "wait_for" {
+		t.Errorf("expected three batches with the refresh policy, got %d requests %v", cluster.requests, cluster.refresh)
+	}
+
+	if _, err := repo.AddMany(context.Background(), indicators, BulkConfig{Refresh: "sometimes"}); !errors.Is(err, ErrInvalidBulkConfig) {
+		t.Errorf("expected ErrInvalidBulkConfig, got %v", err)
+	}
+}
+
+func TestBulkIndexerFlushInterval(t *testing.T) {
+	cluster := &fakeCluster{ids: map[string]bool{}}
+	repo := newFakeRepository(t, cluster)
+
+	indexer := MustNewBulkIndexer(context.Background(), repo, BulkConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	if err := indexer.Add(indicator.Indicator{Id: "t3_rdmhqk"}); err != nil {
+		t.Fatalf("failed to queue indicator: %s", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		cluster.lock.Lock()
+		sent := cluster.requests
+		cluster.lock.Unlock()
+		if sent == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the partial batch to be flushed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if failures, err := indexer.Close(); err != nil || len(failures) != 0 {
+		t.Errorf("failed to close indexer, got %+v %v", failures, err)
+	}
+	if err := indexer.Add(indicator.Indicator{Id: "t3_late"}); !errors.Is(err, ErrBulkIndexerClosed) {
+		t.Errorf("expected ErrBulkIndexerClosed, got %v", err)
+	}
+}
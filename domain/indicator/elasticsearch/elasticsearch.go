@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/carbonrook/cvewatch-domain/domain/indicator"
 	"github.com/elastic/go-elasticsearch"
@@ -13,31 +15,135 @@ import (
 )
 
 type ElasticsearchIndicatorRepository struct {
-	Client           *elasticsearch.Client
-	IndexName        string
+	Client    *elasticsearch.Client
+	IndexName string
+	// Monthly writes each indicator to the index for the month it was
+	// created in, see MonthlyIndexName, and reads through IndexName as an
+	// alias over all of them.
+	Monthly          bool
 	IndicatorFactory indicator.IndicatorFactory
 }
 
-func (eir ElasticsearchIndicatorRepository) Add(ctx context.Context, indicator indicator.Indicator) error {
-	body, err := json.Marshal(indicator)
+// searchPageSize is how many hits searchAll asks for at a time. Pages are
+// walked with search_after, so index.max_result_window does not cap them.
+const searchPageSize = 1000
+
+// Add never overwrites. Monthly indices only enforce unique ids within
+// themselves, so the other months are searched first, which leaves a window
+// where two writers can add the same id to different months.
+func (eir ElasticsearchIndicatorRepository) Add(ctx context.Context, i indicator.Indicator) error {
+	if eir.Monthly {
+		_, err := eir.getDocument(ctx, i.Id)
+		if err == nil {
+			return indicator.ErrIndicatorAlreadyExists
+		}
+		if !errors.Is(err, indicator.ErrIndicatorNotFound) {
+			return err
+		}
+	}
+	return eir.index(ctx, eir.writeIndex(i), i, func(request *esapi.IndexRequest) {
+		request.OpType = "create"
+	})
+}
+
+// Update replaces the indicator only if nobody else has written it since it
+// was read, using the document's sequence number and primary term. The
+// indicator stays in the index it was added to, even if its CreatedDate
+// moves to another month.
+func (eir ElasticsearchIndicatorRepository) Update(ctx context.Context, id string, i *indicator.Indicator) error {
+	existing, err := eir.getDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+	updated := *i
+	updated.Id = id
+	return eir.indexIfUnchanged(ctx, existing.Index, updated, existing.SequenceNumber, existing.PrimaryTerm)
+}
+
+func (eir ElasticsearchIndicatorRepository) Upsert(ctx context.Context, i indicator.Indicator) (*indicator.Indicator, error) {
+	hits, err := eir.searchHits(ctx, eir.filteredQuery(sourceIdFilter(i.Source, i.SourceId), 1, "desc", nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) > 0 {
+		i.Id = hits[0].Id
+		if err := eir.indexIfUnchanged(ctx, hits[0].Index, i, hits[0].SequenceNumber, hits[0].PrimaryTerm); err != nil {
+			return nil, err
+		}
+		return &i, nil
+	}
+
+	// Another writer may have added the same post since the search, in which
+	// case create fails on the shared UpsertId and the caller retries against
+	// the stored copy. Monthly indices only see the collision within a month.
+	i.Id = indicator.UpsertId(i)
+	err = eir.index(ctx, eir.writeIndex(i), i, func(request *esapi.IndexRequest) {
+		request.OpType = "create"
+	})
+	if err != nil {
+		return nil, asConflict(err)
+	}
+	return &i, nil
+}
+
+func (eir ElasticsearchIndicatorRepository) Delete(ctx context.Context, id string) error {
+	index := eir.IndexName
+	if eir.Monthly {
+		existing, err := eir.getDocument(ctx, id)
+		if err != nil {
+			return err
+		}
+		index = existing.Index
+	}
+	request := esapi.DeleteRequest{
+		Index:      index,
+		DocumentID: id,
+		Refresh:    "true",
+	}
+
+	res, err := request.Do(ctx, eir.Client)
+	if err != nil {
+		return transportError("delete document "+id, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "delete document "+id)
+	}
+	return nil
+}
+
+func (eir ElasticsearchIndicatorRepository) indexIfUnchanged(ctx context.Context, index string, i indicator.Indicator, seqNo int, primaryTerm int) error {
+	err := eir.index(ctx, index, i, func(request *esapi.IndexRequest) {
+		request.IfSeqNo = &seqNo
+		request.IfPrimaryTerm = &primaryTerm
+	})
+	return asConflict(err)
+}
+
+func (eir ElasticsearchIndicatorRepository) index(ctx context.Context, index string, i indicator.Indicator, options ...func(*esapi.IndexRequest)) error {
+	body, err := json.Marshal(i)
 	if err != nil {
 		return err
 	}
 	request := esapi.IndexRequest{
-		Index:      eir.IndexName,
-		DocumentID: indicator.Id,
-		Body:       strings.NewReader(string(body)),
+		Index:      index,
+		DocumentID: i.Id,
+		Body:       bytes.NewReader(body),
 		Refresh:    "true",
 	}
+	for _, option := range options {
+		option(&request)
+	}
 
 	res, err := request.Do(ctx, eir.Client)
 	if err != nil {
-		return fmt.Errorf("failed to get response from cluster: %s", err)
+		return transportError("index document "+i.Id, err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("failed to index document %s: %s", indicator.Id, err)
+		return responseError(res, "index document "+i.Id)
 	}
 	return nil
 }
@@ -57,17 +163,36 @@ type elasticSearchResponse struct {
 			Relation string `json:"relation"`
 		} `json:"total"`
 		MaxScore float64 `json:"max_score"`
-		Hits     []struct {
-			Index  string              `json:"_index"`
-			Type   string              `json:"_type"`
-			Id     string              `json:"_id"`
-			Score  float64             `json:"_score"`
-			Source indicator.Indicator `json:"_source"`
-		}
+		Hits     []elasticSearchHit
 	} `json:"hits"`
 }
 
+type elasticSearchHit struct {
+	Index          string              `json:"_index"`
+	Type           string              `json:"_type"`
+	Id             string              `json:"_id"`
+	Score          float64             `json:"_score"`
+	SequenceNumber int                 `json:"_seq_no"`
+	PrimaryTerm    int                 `json:"_primary_term"`
+	Source         indicator.Indicator `json:"_source"`
+	Sort           []json.RawMessage   `json:"sort"`
+}
+
 func (eir ElasticsearchIndicatorRepository) searchWithQuery(ctx context.Context, query bytes.Buffer) ([]indicator.Indicator, error) {
+	hits, err := eir.searchHits(ctx, query)
+	if err != nil {
+		return []indicator.Indicator{}, err
+	}
+
+	indicatorCollection := eir.IndicatorFactory.MustNewIndicatorCollection()
+	for _, hit := range hits {
+		indicatorCollection.Append(hit.Source)
+	}
+
+	return indicatorCollection.Indicators, nil
+}
+
+func (eir ElasticsearchIndicatorRepository) searchHits(ctx context.Context, query bytes.Buffer) ([]elasticSearchHit, error) {
 	res, err := eir.Client.Search(
 		eir.Client.Search.WithContext(ctx),
 		eir.Client.Search.WithIndex(eir.IndexName),
@@ -76,25 +201,19 @@ func (eir ElasticsearchIndicatorRepository) searchWithQuery(ctx context.Context,
 		eir.Client.Search.WithPretty(),
 	)
 	if err != nil {
-		return []indicator.Indicator{}, err
+		return nil, transportError("search indicators", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return []indicator.Indicator{}, fmt.Errorf("failed to retrieve document")
+		return nil, responseError(res, "search indicators")
 	}
 
 	var r elasticSearchResponse
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return []indicator.Indicator{}, fmt.Errorf("error parsing the response: %s", err)
-	}
-
-	indicatorCollection := eir.IndicatorFactory.MustNewIndicatorCollection()
-	for _, hit := range r.Hits.Hits {
-		indicatorCollection.Append(hit.Source)
+		return nil, fmt.Errorf("error parsing the response: %s", err)
 	}
-
-	return indicatorCollection.Indicators, nil
+	return r.Hits.Hits, nil
 }
 
 type elasticGetResponse struct {
@@ -108,6 +227,22 @@ type elasticGetResponse struct {
 }
 
 func (eir ElasticsearchIndicatorRepository) GetById(ctx context.Context, id string) (*indicator.Indicator, error) {
+	r, err := eir.getDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	retrievedIndicator := &r.Source
+
+	return retrievedIndicator, nil
+}
+
+// getDocument searches for the id when indices are monthly, since a get
+// through an alias over several indices is refused.
+func (eir ElasticsearchIndicatorRepository) getDocument(ctx context.Context, id string) (*elasticGetResponse, error) {
+	if eir.Monthly {
+		return eir.searchDocument(ctx, id)
+	}
 
 	res, err := eir.Client.Get(
 		eir.IndexName,
@@ -117,115 +252,419 @@ func (eir ElasticsearchIndicatorRepository) GetById(ctx context.Context, id stri
 		eir.Client.Get.WithSource(),
 	)
 	if err != nil {
-		return &indicator.Indicator{}, err
+		return nil, transportError("get document "+id, err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return &indicator.Indicator{}, fmt.Errorf("failed to retrieve document")
+		return nil, responseError(res, "get document "+id)
 	}
 
 	var r elasticGetResponse
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		return &indicator.Indicator{}, fmt.Errorf("error parsing the response: %s", err)
+		return nil, fmt.Errorf("error parsing the response: %s", err)
 	}
 
 	if !r.Found {
-		return &indicator.Indicator{}, indicator.ErrIndicatorNotFound
+		return nil, indicator.ErrIndicatorNotFound
 	}
+	return &r, nil
+}
 
-	retrievedIndicator := &r.Source
-
-	return retrievedIndicator, nil
+func (eir ElasticsearchIndicatorRepository) searchDocument(ctx context.Context, id string) (*elasticGetResponse, error) {
+	hits, err := eir.searchHits(ctx, eir.filteredQuery(map[string]interface{}{
+		"ids": map[string]interface{}{"values": []string{id}},
+	}, 1, "desc", nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, indicator.ErrIndicatorNotFound
+	}
+	return &elasticGetResponse{
+		Index:          hits[0].Index,
+		Id:             hits[0].Id,
+		SequenceNumber: hits[0].SequenceNumber,
+		PrimaryTerm:    hits[0].PrimaryTerm,
+		Found:          true,
+		Source:         hits[0].Source,
+	}, nil
 }
 
 func (eir ElasticsearchIndicatorRepository) GetByLink(ctx context.Context, link string) (*indicator.Indicator, error) {
-	var buf bytes.Buffer
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"constant_score": map[string]interface{}{
-				"filter": map[string]interface{}{
-					"term": map[string]interface{}{
-						"link": link,
-					},
-				},
+	return eir.searchLatest(ctx, map[string]interface{}{
+		"term": map[string]interface{}{"link": link},
+	})
+}
+
+func (eir ElasticsearchIndicatorRepository) GetByTopic(ctx context.Context, topicName string) (*indicator.IndicatorCollection, error) {
+	return eir.searchAll(ctx, nestedMentions(map[string]interface{}{
+		"term": mentionTerm("topicName", topicName),
+	}))
+}
+
+func (eir ElasticsearchIndicatorRepository) GetByMention(ctx context.Context, mention indicator.Mention) (*indicator.IndicatorCollection, error) {
+	return eir.searchAll(ctx, nestedMentions(map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"term": mentionTerm("topicName", mention.TopicName)},
+				{"term": mentionTerm("mention", mention.Mention)},
 			},
 		},
+	}))
+}
+
+// mentionTerm lowercases the value to match the keyword normaliser on
+// mentions, making lookups case-insensitive like Mention.Equal.
+func mentionTerm(field string, value string) map[string]interface{} {
+	return map[string]interface{}{"mentions." + field: strings.ToLower(value)}
+}
+
+// nestedMentions runs the query against each mention on its own, as the
+// mapping stores them nested, so a topic name only matches alongside its own
+// mention.
+func nestedMentions(query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path":  "mentions",
+			"query": query,
+		},
 	}
+}
 
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		return nil, err
+func (eir ElasticsearchIndicatorRepository) GetBySource(ctx context.Context, source string) (*indicator.IndicatorCollection, error) {
+	return eir.searchAll(ctx, map[string]interface{}{
+		"term": map[string]interface{}{"source": source},
+	})
+}
+
+func (eir ElasticsearchIndicatorRepository) GetBySourceId(ctx context.Context, source string, sourceId string) (*indicator.Indicator, error) {
+	return eir.searchLatest(ctx, sourceIdFilter(source, sourceId))
+}
+
+func sourceIdFilter(source string, sourceId string) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{"term": map[string]interface{}{"source": source}},
+				{"term": map[string]interface{}{"sourceId": sourceId}},
+			},
+		},
+	}
+}
+
+func (eir ElasticsearchIndicatorRepository) GetLatest(ctx context.Context) (*indicator.Indicator, error) {
+	return eir.searchLatest(ctx, map[string]interface{}{"match_all": map[string]interface{}{}})
+}
+
+func (eir ElasticsearchIndicatorRepository) GetBetween(ctx context.Context, start time.Time, end time.Time) (*indicator.IndicatorCollection, error) {
+	return eir.searchAll(ctx, map[string]interface{}{
+		"range": map[string]interface{}{
+			"createdDate": map[string]interface{}{
+				"gte": start.Format(time.RFC3339Nano),
+				"lt":  end.Format(time.RFC3339Nano),
+			},
+		},
+	})
+}
+
+// searchAll returns every indicator matching the filter, oldest first,
+// paging through the hits with search_after.
+func (eir ElasticsearchIndicatorRepository) searchAll(ctx context.Context, filter map[string]interface{}) (*indicator.IndicatorCollection, error) {
+	matches := []indicator.Indicator{}
+	var after []json.RawMessage
+	for {
+		hits, err := eir.searchHits(ctx, eir.filteredQuery(filter, searchPageSize, "asc", after))
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range hits {
+			matches = append(matches, hit.Source)
+		}
+		if len(hits) < searchPageSize {
+			return &indicator.IndicatorCollection{Indicators: matches}, nil
+		}
+		after = hits[len(hits)-1].Sort
 	}
+}
 
-	matches, err := eir.searchWithQuery(ctx, buf)
+// searchLatest returns the most recently created indicator matching the
+// filter.
+func (eir ElasticsearchIndicatorRepository) searchLatest(ctx context.Context, filter map[string]interface{}) (*indicator.Indicator, error) {
+	matches, err := eir.searchWithQuery(ctx, eir.filteredQuery(filter, 1, "desc", nil))
 	if err != nil {
 		return nil, err
 	}
-
-	if len(matches) > 0 {
-		return &matches[len(matches)-1], nil
+	if len(matches) == 0 {
+		return nil, indicator.ErrIndicatorNotFound
 	}
-
-	return nil, nil
+	return &matches[0], nil
 }
 
-func (eir ElasticsearchIndicatorRepository) GetByMention(ctx context.Context, mention indicator.Mention) ([]indicator.Indicator, error) {
+// filteredQuery sorts on createdDate, then id so the order is total, and
+// asks for sequence numbers so hits can be written back with optimistic
+// concurrency. after is the sort of the last hit of the previous page.
+func (eir ElasticsearchIndicatorRepository) filteredQuery(filter map[string]interface{}, size int, order string, after []json.RawMessage) bytes.Buffer {
 	var buf bytes.Buffer
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": []map[string]interface{}{
-					{"term": map[string]interface{}{"mentions.topicName": mention.TopicName}},
-					{"term": map[string]interface{}{"mentions.mention": mention.Mention}},
-				},
+			"constant_score": map[string]interface{}{
+				"filter": filter,
 			},
 		},
+		"size":                size,
+		"seq_no_primary_term": true,
+		"sort": []map[string]interface{}{
+			{"createdDate": map[string]interface{}{"order": order}},
+			{"id": map[string]interface{}{"order": order}},
+		},
 	}
+	if len(after) > 0 {
+		query["search_after"] = after
+	}
+	// A map of strings and slices always encodes.
+	_ = json.NewEncoder(&buf).Encode(query)
+	return buf
+}
 
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+// Find translates the query into a bool query and pages with search_after,
+// the cursor carrying the sort values of the last hit.
+func (eir ElasticsearchIndicatorRepository) Find(ctx context.Context, query indicator.Query) (*indicator.Page, error) {
+	query, err := query.Normalise()
+	if err != nil {
+		return nil, err
+	}
+	body, err := findQuery(query)
+	if err != nil {
 		return nil, err
 	}
 
-	matches, err := eir.searchWithQuery(ctx, buf)
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	hits, err := eir.searchHits(ctx, buf)
 	if err != nil {
 		return nil, err
 	}
 
-	return matches, nil
+	page := &indicator.Page{Indicators: []indicator.Indicator{}}
+	for index, hit := range hits {
+		if index == query.Limit {
+			last := hits[index-1].Sort
+			values := make([]interface{}, len(last))
+			for i, value := range last {
+				values[i] = value
+			}
+			if page.NextCursor, err = indicator.EncodeCursor(values...); err != nil {
+				return nil, err
+			}
+			break
+		}
+		page.Indicators = append(page.Indicators, hit.Source)
+	}
+	return page, nil
 }
 
-type elasticsearchErrorResponse struct {
-	Error struct {
-		RootCause []struct {
-			Type   string `json:"type"`
-			Reason string `json:"reason"`
-		} `json:"root_cause"`
-	} `json:"error"`
-	Status int `json:"status"`
+// findQuery asks for one hit more than the page so the last page has no
+// cursor.
+func findQuery(query indicator.Query) (map[string]interface{}, error) {
+	filters := []map[string]interface{}{}
+	if len(query.Sources) > 0 {
+		filters = append(filters, map[string]interface{}{"terms": map[string]interface{}{"source": query.Sources}})
+	}
+	for _, tag := range query.Tags {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"tags": tag}})
+	}
+	if query.TopicName != "" {
+		filters = append(filters, nestedMentions(map[string]interface{}{
+			"term": mentionTerm("topicName", query.TopicName),
+		}))
+	}
+	if len(query.Mentions) > 0 {
+		should := []map[string]interface{}{}
+		for _, mention := range query.Mentions {
+			should = append(should, map[string]interface{}{
+				"bool": map[string]interface{}{
+					"must": []map[string]interface{}{
+						{"term": mentionTerm("topicName", mention.TopicName)},
+						{"term": mentionTerm("mention", mention.Mention)},
+					},
+				},
+			})
+		}
+		filters = append(filters, nestedMentions(map[string]interface{}{
+			"bool": map[string]interface{}{"should": should, "minimum_should_match": 1},
+		}))
+	}
+	if query.MinScore != nil || query.MaxScore != nil {
+		scoreRange := map[string]interface{}{}
+		if query.MinScore != nil {
+			scoreRange["gte"] = *query.MinScore
+		}
+		if query.MaxScore != nil {
+			scoreRange["lte"] = *query.MaxScore
+		}
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"score": scoreRange}})
+	}
+	if dateRange, ok := dateRangeFilter(query.CreatedAfter, query.CreatedBefore); ok {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"createdDate": dateRange}})
+	}
+	if dateRange, ok := dateRangeFilter(query.AccessedAfter, query.AccessedBefore); ok {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"accessedDate": dateRange}})
+	}
+
+	boolQuery := map[string]interface{}{"filter": filters}
+	if query.Text != "" {
+		boolQuery["must"] = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":    query.Text,
+				"fields":   []string{"title", "body"},
+				"operator": "and",
+			},
+		}
+	}
+
+	order := "asc"
+	if query.Descending {
+		order = "desc"
+	}
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"bool": boolQuery},
+		"size":  query.Limit + 1,
+		"sort": []map[string]interface{}{
+			{string(query.SortBy): map[string]interface{}{"order": order}},
+			{"id": map[string]interface{}{"order": order}},
+		},
+	}
+	if query.Cursor != "" {
+		values, err := indicator.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 2 {
+			return nil, indicator.ErrInvalidCursor
+		}
+		body["search_after"] = values
+	}
+	return body, nil
 }
 
-func NewElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string) (indicator.IndicatorRepository, error) {
-	client, err := elasticsearch.NewClient(config)
+// scrollKeepAlive only has to cover the time a consumer takes over one batch.
+const scrollKeepAlive = time.Minute
+
+type elasticScrollResponse struct {
+	elasticSearchResponse
+	ScrollId string `json:"_scroll_id"`
+}
+
+// Stream walks the matching indicators with the scroll API, which keeps a
+// consistent view of the index for the whole walk. The cluster version this
+// client targets has no point in time API to pair with search_after.
+func (eir ElasticsearchIndicatorRepository) Stream(ctx context.Context, query indicator.Query) <-chan indicator.StreamResult {
+	results := make(chan indicator.StreamResult)
+	query.Cursor = ""
+	go func() {
+		defer close(results)
+		batch, err := eir.startScroll(ctx, query)
+		scrollId := ""
+		defer func() {
+			if scrollId != "" {
+				eir.clearScroll(scrollId)
+			}
+		}()
+		for {
+			if batch != nil && batch.ScrollId != "" {
+				scrollId = batch.ScrollId
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					indicator.SendStreamResult(ctx, results, indicator.StreamResult{Err: err})
+				}
+				return
+			}
+			for _, hit := range batch.Hits.Hits {
+				if !indicator.SendStreamResult(ctx, results, indicator.StreamResult{Indicator: hit.Source}) {
+					return
+				}
+			}
+			if len(batch.Hits.Hits) == 0 {
+				return
+			}
+			batch, err = decodeScroll(eir.Client.Scroll(
+				eir.Client.Scroll.WithContext(ctx),
+				eir.Client.Scroll.WithScrollID(scrollId),
+				eir.Client.Scroll.WithScroll(scrollKeepAlive),
+			))
+		}
+	}()
+	return results
+}
+
+func (eir ElasticsearchIndicatorRepository) startScroll(ctx context.Context, query indicator.Query) (*elasticScrollResponse, error) {
+	query, err := query.Normalise()
 	if err != nil {
-		return ElasticsearchIndicatorRepository{}, err
+		return nil, err
+	}
+	body, err := findQuery(query)
+	if err != nil {
+		return nil, err
 	}
+	body["size"] = query.Limit
 
-	res, err := client.API.Indices.Get([]string{indexName})
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	return decodeScroll(eir.Client.Search(
+		eir.Client.Search.WithContext(ctx),
+		eir.Client.Search.WithIndex(eir.IndexName),
+		eir.Client.Search.WithBody(&buf),
+		eir.Client.Search.WithScroll(scrollKeepAlive),
+	))
+}
+
+func decodeScroll(res *esapi.Response, err error) (*elasticScrollResponse, error) {
 	if err != nil {
-		return ElasticsearchIndicatorRepository{}, fmt.Errorf("failed to connect to cluster: %s", err)
+		return nil, transportError("scroll indicators", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return ElasticsearchIndicatorRepository{}, fmt.Errorf("received error response from cluster: %s", res.String())
+		return nil, responseError(res, "scroll indicators")
+	}
+	var r elasticScrollResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response: %s", err)
 	}
+	return &r, nil
+}
 
-	fmt.Println("successfully connected to cluster")
-	return ElasticsearchIndicatorRepository{
-		Client:           client,
-		IndexName:        indexName,
-		IndicatorFactory: indicator.IndicatorFactory{},
-	}, nil
+// clearScroll releases the search context without waiting for it to expire.
+// It runs after the stream ends, so it does not use the stream's context.
+func (eir ElasticsearchIndicatorRepository) clearScroll(scrollId string) {
+	res, err := eir.Client.ClearScroll(eir.Client.ClearScroll.WithScrollID(scrollId))
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+func dateRangeFilter(after time.Time, before time.Time) (map[string]interface{}, bool) {
+	dateRange := map[string]interface{}{}
+	if !after.IsZero() {
+		dateRange["gte"] = after.Format(time.RFC3339Nano)
+	}
+	if !before.IsZero() {
+		dateRange["lt"] = before.Format(time.RFC3339Nano)
+	}
+	return dateRange, len(dateRange) > 0
+}
+
+// NewElasticsearchIndicatorRepository only checks that the index exists. It
+// never creates or changes it, which EnsureIndex and Migrate are for.
+func NewElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string) (indicator.IndicatorRepository, error) {
+	return newElasticsearchIndicatorRepository(config, indexName, false)
 }
 
 func MustNewElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string) indicator.IndicatorRepository {
@@ -235,3 +674,40 @@ func MustNewElasticsearchIndicatorRepository(config elasticsearch.Config, indexN
 	}
 	return repo
 }
+
+// NewMonthlyElasticsearchIndicatorRepository stores indicators in monthly
+// indices behind the indexName alias. See ApplyRetention for removing old
+// months.
+func NewMonthlyElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string) (indicator.IndicatorRepository, error) {
+	return newElasticsearchIndicatorRepository(config, indexName, true)
+}
+
+func MustNewMonthlyElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string) indicator.IndicatorRepository {
+	repo, err := NewMonthlyElasticsearchIndicatorRepository(config, indexName)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
+
+func newElasticsearchIndicatorRepository(config elasticsearch.Config, indexName string, monthly bool) (indicator.IndicatorRepository, error) {
+	client, err := elasticsearch.NewClient(config)
+	if err != nil {
+		return ElasticsearchIndicatorRepository{}, err
+	}
+
+	repo := ElasticsearchIndicatorRepository{
+		Client:           client,
+		IndexName:        indexName,
+		Monthly:          monthly,
+		IndicatorFactory: indicator.IndicatorFactory{},
+	}
+	exists, err := repo.indexExists(context.Background())
+	if err != nil {
+		return ElasticsearchIndicatorRepository{}, fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+	if !exists {
+		return ElasticsearchIndicatorRepository{}, fmt.Errorf("%w: %s, create it with EnsureIndex", ErrIndexNotFound, indexName)
+	}
+	return repo, nil
+}
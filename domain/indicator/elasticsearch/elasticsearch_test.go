@@ -0,0 +1,545 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+// searchCluster answers _search by evaluating the query against its
+// documents the way Elasticsearch would: fields inside an object array are
+// flattened unless a nested query scopes them to one object, and mention
+// fields are lowercased as the normaliser does. Hits are sorted on
+// createdDate then id and paged with size and search_after.
+type searchCluster struct {
+	t        *testing.T
+	docs     []indicator.Indicator
+	searches int
+}
+
+func (sc *searchCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/indicators/_search" {
+		http.NotFound(w, r)
+		return
+	}
+	var body struct {
+		Query       map[string]interface{}         `json:"query"`
+		Size        *int                           `json:"size"`
+		Sort        []map[string]map[string]string `json:"sort"`
+		SearchAfter []interface{}                  `json:"search_after"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sc.searches++
+
+	descending := len(body.Sort) > 0 && body.Sort[0]["createdDate"]["order"] == "desc"
+	docs := append([]indicator.Indicator{}, sc.docs...)
+	sort.SliceStable(docs, func(a, b int) bool {
+		return sortsBefore(docs[a].CreatedDate.UnixNano()/1e6, docs[a].Id, docs[b].CreatedDate.UnixNano()/1e6, docs[b].Id, descending)
+	})
+
+	hits := []map[string]interface{}{}
+	for _, doc := range docs {
+		millis := doc.CreatedDate.UnixNano() / 1e6
+		if len(body.SearchAfter) == 2 {
+			afterMillis, _ := body.SearchAfter[0].(float64)
+			afterId, _ := body.SearchAfter[1].(string)
+			if !sortsBefore(int64(afterMillis), afterId, millis, doc.Id, descending) {
+				continue
+			}
+		}
+		if body.Size != nil && len(hits) == *body.Size {
+			break
+		}
+		encoded, _ := json.Marshal(doc)
+		var source map[string]interface{}
+		json.Unmarshal(encoded, &source)
+		if sc.matches(body.Query, source) {
+			hits = append(hits, map[string]interface{}{"_id": doc.Id, "_source": source, "sort": []interface{}{millis, doc.Id}})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+}
+
+func sortsBefore(aMillis int64, aId string, bMillis int64, bId string, descending bool) bool {
+	if aMillis != bMillis {
+		return (aMillis < bMillis) != descending
+	}
+	return (aId < bId) != descending
+}
+
+func (sc *searchCluster) matches(query map[string]interface{}, doc map[string]interface{}) bool {
+	for kind, raw := range query {
+		clause, _ := raw.(map[string]interface{})
+		switch kind {
+		case "match_all":
+		case "constant_score":
+			if !sc.matches(clause["filter"].(map[string]interface{}), doc) {
+				return false
+			}
+		case "bool":
+			for _, occur := range []string{"must", "filter"} {
+				for _, sub := range clauses(clause[occur]) {
+					if !sc.matches(sub, doc) {
+						return false
+					}
+				}
+			}
+			if should := clauses(clause["should"]); len(should) > 0 {
+				matched := false
+				for _, sub := range should {
+					matched = matched || sc.matches(sub, doc)
+				}
+				if !matched {
+					return false
+				}
+			}
+		case "nested":
+			path := clause["path"].(string)
+			objects, _ := doc[path].([]interface{})
+			matched := false
+			for _, object := range objects {
+				scoped := map[string]interface{}{}
+				for field, value := range object.(map[string]interface{}) {
+					scoped[path+"."+field] = value
+				}
+				matched = matched || sc.matches(clause["query"].(map[string]interface{}), scoped)
+			}
+			if !matched {
+				return false
+			}
+		case "term":
+			for field, value := range clause {
+				if !containsValue(fieldValues(doc, field), value) {
+					return false
+				}
+			}
+		case "range":
+			for field, raw := range clause {
+				value, _ := time.Parse(time.RFC3339Nano, doc[field].(string))
+				for operator, bound := range raw.(map[string]interface{}) {
+					limit, _ := time.Parse(time.RFC3339Nano, bound.(string))
+					if (operator == "gte" && value.Before(limit)) || (operator == "lt" && !value.Before(limit)) {
+						return false
+					}
+				}
+			}
+		default:
+			sc.t.Errorf("search cluster cannot evaluate %s queries", kind)
+			return false
+		}
+	}
+	return true
+}
+
+func clauses(raw interface{}) []map[string]interface{} {
+	switch typed := raw.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{typed}
+	case []interface{}:
+		list := []map[string]interface{}{}
+		for _, item := range typed {
+			list = append(list, item.(map[string]interface{}))
+		}
+		return list
+	}
+	return nil
+}
+
+// fieldValues looks a dotted field up, flattening object arrays on the way.
+func fieldValues(doc map[string]interface{}, field string) []interface{} {
+	if value, ok := doc[field]; ok {
+		return normalise(field, []interface{}{value})
+	}
+	parts := strings.SplitN(field, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	objects, _ := doc[parts[0]].([]interface{})
+	values := []interface{}{}
+	for _, object := range objects {
+		if value, ok := object.(map[string]interface{})[parts[1]]; ok {
+			values = append(values, value)
+		}
+	}
+	return normalise(field, values)
+}
+
+func normalise(field string, values []interface{}) []interface{} {
+	if !strings.HasPrefix(field, "mentions.") {
+		return values
+	}
+	for i, value := range values {
+		values[i] = strings.ToLower(value.(string))
+	}
+	return values
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetByMentionDoesNotCrossMatch(t *testing.T) {
+	crossed := indicator.Indicator{Id: "crossed", Mentions: []indicator.Mention{
+		indicator.MustNewMention("cve", []byte("CVE-2021-45046")),
+		indicator.MustNewMention("product", []byte("CVE-2021-44228")),
+	}}
+	exact := indicator.Indicator{Id: "exact", Mentions: []indicator.Mention{
+		indicator.MustNewMention("CVE", []byte("cve-2021-44228")),
+	}}
+	cluster := &searchCluster{t: t, docs: []indicator.Indicator{crossed, exact}}
+	repo := newFakeRepository(t, cluster)
+	ctx := context.Background()
+	log4shell := indicator.MustNewMention("cve", []byte("CVE-2021-44228"))
+
+	// The query used before mentions were nested pairs the fields crosswise.
+	flat := map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{
+		map[string]interface{}{"term": map[string]interface{}{"mentions.topicName": "cve"}},
+		map[string]interface{}{"term": map[string]interface{}{"mentions.mention": "cve-2021-44228"}},
+	}}}
+	encoded, _ := json.Marshal(crossed)
+	var source map[string]interface{}
+	json.Unmarshal(encoded, &source)
+	if !cluster.matches(flat, source) {
+		t.Fatalf("expected the search cluster to reproduce the cross match")
+	}
+
+	found, err := repo.GetByMention(ctx, log4shell)
+	if err != nil {
+		t.Fatalf("failed to get by mention: %s", err)
+	}
+	if found.Length() != 1 || found.Indicators[0].Id != "exact" {
+		t.Errorf("expected only the indicator mentioning the pair, got %+v", found.Indicators)
+	}
+
+	page, err := repo.Find(ctx, indicator.Query{Mentions: []indicator.Mention{log4shell}})
+	if err != nil {
+		t.Fatalf("failed to find by mention: %s", err)
+	}
+	if len(page.Indicators) != 1 || page.Indicators[0].Id != "exact" {
+		t.Errorf("expected find to pair mentions too, got %+v", page.Indicators)
+	}
+
+	topic, err := repo.GetByTopic(ctx, "Product")
+	if err != nil || topic.Length() != 1 || topic.Indicators[0].Id != "crossed" {
+		t.Errorf("expected case-insensitive topic lookup, got %+v %v", topic, err)
+	}
+}
+
+func TestLookups(t *testing.T) {
+	start := time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)
+	log4shell := indicator.MustNewMention("cve", []byte("CVE-2021-44228"))
+	docs := []indicator.Indicator{}
+	for n := 0; n < 2*searchPageSize+1; n++ {
+		i := indicator.Indicator{Id: fmt.Sprintf("t3_%04d", n), Source: "reddit", SourceId: fmt.Sprintf("%04d", n), CreatedDate: start.Add(time.Duration(n) * time.Minute)}
+		if n%2 == 0 {
+			i.Mentions = []indicator.Mention{log4shell}
+		}
+		docs = append(docs, i)
+	}
+	docs = append(docs, indicator.Indicator{Id: "tweet", Source: "twitter", SourceId: "1470000", CreatedDate: start.Add(-time.Hour)})
+	cluster := &searchCluster{t: t, docs: docs}
+	repo := newFakeRepository(t, cluster)
+	ctx := context.Background()
+
+	all, err := repo.GetBySource(ctx, "reddit")
+	if err != nil || all.Length() != 2*searchPageSize+1 || cluster.searches != 3 {
+		t.Fatalf("expected every indicator over three pages, got %d in %d searches: %v", all.Length(), cluster.searches, err)
+	}
+	for n, i := range all.Indicators {
+		if i.Id != fmt.Sprintf("t3_%04d", n) {
+			t.Fatalf("expected indicators oldest first without repeats, got %s at %d", i.Id, n)
+		}
+	}
+
+	if topic, err := repo.GetByTopic(ctx, "CVE"); err != nil || topic.Length() != searchPageSize+1 {
+		t.Errorf("failed to get indicators by topic, got %d: %v", topic.Length(), err)
+	}
+	if mentioning, err := repo.GetByMention(ctx, log4shell); err != nil || mentioning.Length() != searchPageSize+1 {
+		t.Errorf("failed to get indicators by mention, got %d: %v", mentioning.Length(), err)
+	}
+	if found, err := repo.GetBySourceId(ctx, "twitter", "1470000"); err != nil || found.Id != "tweet" {
+		t.Errorf("failed to get indicator by source id, got %v %v", found, err)
+	}
+	if _, err := repo.GetBySourceId(ctx, "twitter", "missing"); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound for unknown source id, got %v", err)
+	}
+	if latest, err := repo.GetLatest(ctx); err != nil || latest.Id != fmt.Sprintf("t3_%04d", 2*searchPageSize) {
+		t.Errorf("failed to get latest indicator, got %v %v", latest, err)
+	}
+	between, err := repo.GetBetween(ctx, start.Add(-time.Hour), start.Add(2*time.Minute))
+	if err != nil || between.Length() != 3 || between.Indicators[0].Id != "tweet" || between.Indicators[2].Id != "t3_0001" {
+		t.Errorf("expected start to be inclusive and end exclusive, got %+v %v", between, err)
+	}
+}
+
+type storedDocument struct {
+	indicator indicator.Indicator
+	seqNo     int
+}
+
+// documentCluster stores documents with sequence numbers, answering gets,
+// conditional and create-only index requests and searches the way
+// Elasticsearch does. beforeWrite, when set, runs once ahead of the next
+// index request to stand in for another writer.
+type documentCluster struct {
+	t           *testing.T
+	docs        map[string]storedDocument
+	seqNo       int
+	beforeWrite func(dc *documentCluster)
+}
+
+func (dc *documentCluster) put(i indicator.Indicator) {
+	dc.seqNo++
+	dc.docs[i.Id] = storedDocument{indicator: i, seqNo: dc.seqNo}
+}
+
+func (dc *documentCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/indicators/_search" {
+		var body struct {
+			Query map[string]interface{} `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		search := &searchCluster{t: dc.t}
+		hits := []map[string]interface{}{}
+		for id, doc := range dc.docs {
+			encoded, _ := json.Marshal(doc.indicator)
+			var source map[string]interface{}
+			json.Unmarshal(encoded, &source)
+			if search.matches(body.Query, source) {
+				hits = append(hits, map[string]interface{}{"_index": "indicators", "_id": id, "_seq_no": doc.seqNo, "_primary_term": 1, "_source": source})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/indicators/_doc/") {
+		http.NotFound(w, r)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/indicators/_doc/")
+
+	if r.Method == http.MethodGet {
+		doc, ok := dc.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"_index": "indicators", "_id": id, "found": false})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"_index": "indicators", "_id": id, "_seq_no": doc.seqNo, "_primary_term": 1, "found": true, "_source": doc.indicator})
+		return
+	}
+
+	if beforeWrite := dc.beforeWrite; beforeWrite != nil {
+		dc.beforeWrite = nil
+		beforeWrite(dc)
+	}
+	existing, exists := dc.docs[id]
+	params := r.URL.Query()
+	conflict := ""
+	switch {
+	case params.Get("op_type") == "create" && exists:
+		conflict = fmt.Sprintf("[%s]: version conflict, document already exists", id)
+	case params.Get("if_seq_no") != "" && (!exists || params.Get("if_seq_no") != fmt.Sprint(existing.seqNo)):
+		conflict = fmt.Sprintf("[%s]: version conflict, required seqNo [%s], current document has seqNo [%d]", id, params.Get("if_seq_no"), existing.seqNo)
+	}
+	if conflict != "" {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"type": "version_conflict_engine_exception", "reason": conflict}, "status": http.StatusConflict})
+		return
+	}
+	var i indicator.Indicator
+	json.NewDecoder(r.Body).Decode(&i)
+	dc.put(i)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"_index": "indicators", "_id": id, "_seq_no": dc.seqNo, "result": "created"})
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ctx := context.Background()
+	cluster := &documentCluster{t: t, docs: map[string]storedDocument{}}
+	cluster.put(indicator.Indicator{Id: "t3_rdmhqk", Source: "reddit", SourceId: "rdmhqk", Score: 10})
+	repo := newFakeRepository(t, cluster)
+
+	// Another writer lands between Update's read and its write.
+	cluster.beforeWrite = func(dc *documentCluster) {
+		dc.put(indicator.Indicator{Id: "t3_rdmhqk", Source: "reddit", SourceId: "rdmhqk", Score: 99})
+	}
+	stale := indicator.Indicator{Source: "reddit", SourceId: "rdmhqk", Score: 20}
+	if err := repo.Update(ctx, "t3_rdmhqk", &stale); !errors.Is(err, indicator.ErrIndicatorConflict) {
+		t.Errorf("expected ErrIndicatorConflict for a stale if_seq_no, got %v", err)
+	}
+	if cluster.docs["t3_rdmhqk"].indicator.Score != 99 {
+		t.Errorf("expected the other writer's update to survive, got %+v", cluster.docs["t3_rdmhqk"].indicator)
+	}
+	if err := repo.Update(ctx, "t3_rdmhqk", &stale); err != nil || cluster.docs["t3_rdmhqk"].indicator.Score != 20 {
+		t.Errorf("failed to update after re-reading, got %v", err)
+	}
+
+	// Upsert writes onto the document with the same source and source id.
+	recrawled := indicator.Indicator{Id: "t3_recrawled", Source: "reddit", SourceId: "rdmhqk", Score: 250}
+	stored, err := repo.Upsert(ctx, recrawled)
+	if err != nil || stored.Id != "t3_rdmhqk" {
+		t.Fatalf("failed to upsert onto the existing document, got %v %v", stored, err)
+	}
+	if _, added := cluster.docs["t3_recrawled"]; added || len(cluster.docs) != 1 || cluster.docs["t3_rdmhqk"].indicator.Score != 250 {
+		t.Errorf("expected upsert to replace the existing document, got %+v", cluster.docs)
+	}
+
+	// Another crawler upserts the same post under its own fresh id between
+	// Upsert's search and its create. Both create under the post's UpsertId,
+	// so the later create fails and a retry writes onto the stored copy.
+	post := indicator.Indicator{Id: "c0ffee00-0000-4000-8000-000000000001", Source: "reddit", SourceId: "new", Score: 1}
+	other := indicator.Indicator{Id: "c0ffee00-0000-4000-8000-000000000002", Source: "reddit", SourceId: "new", Score: 5}
+	cluster.beforeWrite = func(dc *documentCluster) {
+		if _, err := repo.Upsert(ctx, other); err != nil {
+			t.Errorf("failed to upsert the other crawl: %s", err)
+		}
+	}
+	if _, err := repo.Upsert(ctx, post); !errors.Is(err, indicator.ErrIndicatorConflict) {
+		t.Errorf("expected ErrIndicatorConflict when losing the create race, got %v", err)
+	}
+	id := indicator.UpsertId(post)
+	if len(cluster.docs) != 2 || cluster.docs[id].indicator.Score != 5 {
+		t.Errorf("expected the post to be stored once by the winning create, got %+v", cluster.docs)
+	}
+	if stored, err := repo.Upsert(ctx, post); err != nil || stored.Id != id || len(cluster.docs) != 2 || cluster.docs[id].indicator.Score != 1 {
+		t.Errorf("failed to upsert onto the stored copy on retry, got %v %v", stored, err)
+	}
+}
+
+// scrollCluster serves docs in batches of the search's size through one
+// scroll context, failing the scroll request numbered failOn when it is set.
+type scrollCluster struct {
+	lock    sync.Mutex
+	docs    []indicator.Indicator
+	size    int
+	offset  int
+	scrolls int
+	failOn  int
+	cleared []string
+}
+
+func (sc *scrollCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/indicators/_search" && r.URL.Query().Get("scroll") != "":
+		var body struct {
+			Size int `json:"size"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sc.size, sc.offset = body.Size, 0
+	case r.Method == http.MethodGet && r.URL.Path == "/_search/scroll/scroll-1":
+		sc.scrolls++
+		if sc.scrolls == sc.failOn {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"type": "search_context_missing_exception", "reason": "No search context found for id [1]"}, "status": 404}`))
+			return
+		}
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/_search/scroll/"):
+		sc.cleared = append(sc.cleared, strings.TrimPrefix(r.URL.Path, "/_search/scroll/"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"succeeded": true, "num_freed": 1})
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	hits := []map[string]interface{}{}
+	for ; sc.offset < len(sc.docs) && len(hits) < sc.size; sc.offset++ {
+		hits = append(hits, map[string]interface{}{"_id": sc.docs[sc.offset].Id, "_source": sc.docs[sc.offset]})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"_scroll_id": "scroll-1", "hits": map[string]interface{}{"hits": hits}})
+}
+
+func (sc *scrollCluster) clearedIds() []string {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	return append([]string{}, sc.cleared...)
+}
+
+func TestStream(t *testing.T) {
+	docs := []indicator.Indicator{}
+	for n := 0; n < 5; n++ {
+		docs = append(docs, indicator.Indicator{Id: fmt.Sprintf("t3_%d", n)})
+	}
+	query := indicator.Query{Limit: 2}
+
+	cluster := &scrollCluster{docs: docs}
+	ids := []string{}
+	for result := range newFakeRepository(t, cluster).Stream(context.Background(), query) {
+		if result.Err != nil {
+			t.Fatalf("failed to stream indicators: %s", result.Err)
+		}
+		ids = append(ids, result.Indicator.Id)
+	}
+	if strings.Join(ids, ",") != "t3_0,t3_1,t3_2,t3_3,t3_4" || cluster.scrolls != 3 {
+		t.Errorf("expected every indicator once over three scroll requests, got %v in %d", ids, cluster.scrolls)
+	}
+	if cleared := cluster.clearedIds(); len(cleared) != 1 || cleared[0] != "scroll-1" {
+		t.Errorf("expected the scroll to be cleared once the stream ends, got %v", cleared)
+	}
+
+	// An error part way through is the last result.
+	cluster = &scrollCluster{docs: docs, failOn: 2}
+	results := []indicator.StreamResult{}
+	for result := range newFakeRepository(t, cluster).Stream(context.Background(), query) {
+		results = append(results, result)
+	}
+	var ee *ElasticsearchError
+	if len(results) != 5 || results[3].Err != nil || !errors.As(results[4].Err, &ee) || ee.Type != "search_context_missing_exception" {
+		t.Errorf("expected four indicators then the scroll error, got %+v", results)
+	}
+	if cleared := cluster.clearedIds(); len(cleared) != 1 {
+		t.Errorf("expected the scroll to be cleared after an error, got %v", cleared)
+	}
+
+	// Cancelling stops the walk without an error result and still clears.
+	cluster = &scrollCluster{docs: docs}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newFakeRepository(t, cluster).Stream(ctx, query)
+	if first := <-stream; first.Err != nil || first.Indicator.Id != "t3_0" {
+		t.Fatalf("failed to stream the first indicator, got %+v", first)
+	}
+	cancel()
+	for result := range stream {
+		if result.Err != nil {
+			t.Errorf("expected no error result after cancelling, got %v", result.Err)
+		}
+	}
+	if cleared := cluster.clearedIds(); len(cleared) != 1 {
+		t.Errorf("expected the scroll to be cleared after cancelling, got %v", cleared)
+	}
+}
+
+func TestFindQueryText(t *testing.T) {
+	body, err := findQuery(indicator.Query{Text: "apache log4j", Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to build find query: %s", err)
+	}
+	must := body["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].(map[string]interface{})
+	match := must["multi_match"].(map[string]interface{})
+	if match["operator"] != "and" || match["query"] != "apache log4j" {
+		t.Errorf("expected every word of the text to be required, got %+v", match)
+	}
+}
@@ -0,0 +1,147 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/elastic/go-elasticsearch/esapi"
+)
+
+var ErrIndexNotFound = errors.New("the index was not found")
+
+// ElasticsearchError is a failed call to the cluster. Err is the domain
+// sentinel the failure maps onto, such as indicator.ErrIndicatorNotFound, or
+// the transport error when no response came back.
+type ElasticsearchError struct {
+	Op     string
+	Status int
+	Type   string
+	Reason string
+	Err    error
+}
+
+func (ee *ElasticsearchError) Error() string {
+	if ee.Status == 0 {
+		return fmt.Sprintf("failed to %s: %s", ee.Op, ee.Err)
+	}
+	if ee.Type == "" {
+		return fmt.Sprintf("failed to %s: %d %s", ee.Op, ee.Status, http.StatusText(ee.Status))
+	}
+	return fmt.Sprintf("failed to %s: %d %s: %s", ee.Op, ee.Status, ee.Type, ee.Reason)
+}
+
+func (ee *ElasticsearchError) Unwrap() error {
+	return ee.Err
+}
+
+// retryableTypes are failures of a busy or recovering cluster rather than
+// of the request.
+var retryableTypes = map[string]bool{
+	"es_rejected_execution_exception":         true,
+	"circuit_breaking_exception":              true,
+	"unavailable_shards_exception":            true,
+	"no_shard_available_action_exception":     true,
+	"primary_missing_action_exception":        true,
+	"process_cluster_event_timeout_exception": true,
+	"master_not_discovered_exception":         true,
+	"node_not_connected_exception":            true,
+	"node_disconnected_exception":             true,
+	"connect_transport_exception":             true,
+	"receive_timeout_transport_exception":     true,
+}
+
+// Retryable reports whether the same call may succeed if made again:
+// throttling, an unavailable cluster or a lost connection. Cancelled calls
+// are not retryable.
+func (ee *ElasticsearchError) Retryable() bool {
+	if ee.Status == 0 {
+		return !errors.Is(ee.Err, context.Canceled) && !errors.Is(ee.Err, context.DeadlineExceeded)
+	}
+	switch ee.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return retryableTypes[ee.Type]
+}
+
+type elasticsearchErrorResponse struct {
+	Error  json.RawMessage `json:"error"`
+	Status int             `json:"status"`
+}
+
+type elasticsearchErrorCause struct {
+	RootCause []struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"root_cause"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// responseError reads the error body of a response that IsError. The body
+// may be missing, as on HEAD requests and document 404s.
+func responseError(res *esapi.Response, op string) *ElasticsearchError {
+	ee := &ElasticsearchError{Op: op, Status: res.StatusCode}
+	if res.Body != nil {
+		body, _ := io.ReadAll(res.Body)
+		var r elasticsearchErrorResponse
+		if json.Unmarshal(body, &r) == nil && len(r.Error) > 0 {
+			var cause elasticsearchErrorCause
+			if json.Unmarshal(r.Error, &cause) == nil {
+				ee.Type, ee.Reason = cause.Type, cause.Reason
+				if ee.Type == "" && len(cause.RootCause) > 0 {
+					ee.Type, ee.Reason = cause.RootCause[0].Type, cause.RootCause[0].Reason
+				}
+			} else {
+				json.Unmarshal(r.Error, &ee.Reason)
+			}
+		}
+	}
+	ee.Err = sentinelFor(ee.Status, ee.Type)
+	return ee
+}
+
+// bulkItemError describes one failed action of a _bulk response.
+func bulkItemError(id string, status int, errorType string, reason string) *ElasticsearchError {
+	return &ElasticsearchError{
+		Op:     "index document " + id,
+		Status: status,
+		Type:   errorType,
+		Reason: reason,
+		Err:    sentinelFor(status, errorType),
+	}
+}
+
+func sentinelFor(status int, errorType string) error {
+	switch {
+	case errorType == "index_not_found_exception":
+		return ErrIndexNotFound
+	case status == http.StatusNotFound:
+		return indicator.ErrIndicatorNotFound
+	case status == http.StatusConflict || errorType == "version_conflict_engine_exception":
+		return indicator.ErrIndicatorAlreadyExists
+	}
+	return nil
+}
+
+// transportError is a call that got no response at all.
+func transportError(op string, err error) *ElasticsearchError {
+	return &ElasticsearchError{Op: op, Err: err}
+}
+
+// asConflict reports a version conflict on a conditional write as
+// indicator.ErrIndicatorConflict, since the document did exist but changed.
+func asConflict(err error) error {
+	var ee *ElasticsearchError
+	if errors.As(err, &ee) && errors.Is(ee.Err, indicator.ErrIndicatorAlreadyExists) {
+		conflict := *ee
+		conflict.Err = indicator.ErrIndicatorConflict
+		return &conflict
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+// errorCluster answers every request with the same status and body.
+type errorCluster struct {
+	status int
+	body   string
+}
+
+func (ec errorCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(ec.status)
+	w.Write([]byte(ec.body))
+}
+
+func TestElasticsearchErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		cluster   errorCluster
+		call      func(repo ElasticsearchIndicatorRepository) error
+		sentinel  error
+		errorType string
+		retryable bool
+	}{
+		{
+			name:    "create of an existing document",
+			cluster: errorCluster{http.StatusConflict, `{"error": {"root_cause": [{"type": "version_conflict_engine_exception", "reason": "[t3_rdmhqk]: version conflict, document already exists"}], "type": "version_conflict_engine_exception", "reason": "[t3_rdmhqk]: version conflict, document already exists"}, "status": 409}`},
+			call: func(repo ElasticsearchIndicatorRepository) error {
+				return repo.Add(ctx, indicator.Indicator{Id: "t3_rdmhqk"})
+			},
+			sentinel:  indicator.ErrIndicatorAlreadyExists,
+			errorType: "version_conflict_engine_exception",
+		},
+		{
+			name:    "conditional write after another writer",
+			cluster: errorCluster{http.StatusConflict, `{"error": {"type": "version_conflict_engine_exception", "reason": "required seqNo [4], primary term [1]. current document has seqNo [5] and primary term [1]"}, "status": 409}`},
+			call: func(repo ElasticsearchIndicatorRepository) error {
+				return repo.indexIfUnchanged(ctx, repo.IndexName, indicator.Indicator{Id: "t3_rdmhqk"}, 4, 1)
+			},
+			sentinel:  indicator.ErrIndicatorConflict,
+			errorType: "version_conflict_engine_exception",
+		},
+		{
+			name:    "missing document",
+			cluster: errorCluster{http.StatusNotFound, `{"_index": "indicators-v2", "_type": "_doc", "_id": "t3_missing", "found": false}`},
+			call: func(repo ElasticsearchIndicatorRepository) error {
+				_, err := repo.GetById(ctx, "t3_missing")
+				return err
+			},
+			sentinel: indicator.ErrIndicatorNotFound,
+		},
+		{
+			name:    "missing index",
+			cluster: errorCluster{http.StatusNotFound, `{"error": {"type": "index_not_found_exception", "reason": "no such index [indicators]"}, "status": 404}`},
+			call: func(repo ElasticsearchIndicatorRepository) error {
+				_, err := repo.GetById(ctx, "t3_rdmhqk")
+				return err
+			},
+			sentinel:  ErrIndexNotFound,
+			errorType: "index_not_found_exception",
+		},
+		{
+			name:    "rejected search",
+			cluster: errorCluster{http.StatusTooManyRequests, `{"error": {"type": "es_rejected_execution_exception", "reason": "rejected execution of coordinating operation"}, "status": 429}`},
+			call: func(repo ElasticsearchIndicatorRepository) error {
+				_, err := repo.GetBySource(ctx, "reddit")
+				return err
+			},
+			errorType: "es_rejected_execution_exception",
+			retryable: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.call(newFakeRepository(t, test.cluster))
+		var ee *ElasticsearchError
+		if !errors.As(err, &ee) {
+			t.Errorf("%s: expected an ElasticsearchError, got %v", test.name, err)
+			continue
+		}
+		if ee.Status != test.cluster.status || ee.Type != test.errorType {
+			t.Errorf("%s: failed to decode error body, got %d %s", test.name, ee.Status, ee.Type)
+		}
+		if test.sentinel != nil && !errors.Is(err, test.sentinel) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.sentinel, err)
+		}
+		if ee.Retryable() != test.retryable {
+			t.Errorf("%s: expected retryable to be %t", test.name, test.retryable)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	repo := newFakeRepository(t, errorCluster{http.StatusOK, `{}`})
+	_, err := repo.GetById(cancelled, "t3_rdmhqk")
+	var ee *ElasticsearchError
+	if !errors.As(err, &ee) || ee.Retryable() || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled call not to be retryable, got %v", err)
+	}
+}
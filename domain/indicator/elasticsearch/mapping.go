@@ -0,0 +1,321 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// MappingVersion is bumped whenever Mapping changes in a way existing indices
+// cannot take, and Migrate moves the alias onto a new index built with it.
+const MappingVersion = 2
+
+// mentionNormaliser lowercases mentions as they are indexed, so term queries
+// on them compare the way Mention.Equal does.
+const mentionNormaliser = "lowercase"
+
+// dateFormat accepts what encoding/json writes for time.Time.
+const dateFormat = "strict_date_optional_time||epoch_millis"
+
+// Mapping describes the JSON form of indicator.Indicator. Exact-match fields
+// are keywords, and mentions are nested so a topic name is only ever paired
+// with its own mention.
+func Mapping() map[string]interface{} {
+	keyword := map[string]interface{}{"type": "keyword"}
+	lowercaseKeyword := map[string]interface{}{"type": "keyword", "normalizer": mentionNormaliser}
+	text := map[string]interface{}{"type": "text"}
+	date := map[string]interface{}{"type": "date", "format": dateFormat}
+	return map[string]interface{}{
+		"dynamic": false,
+		"_meta":   map[string]interface{}{"mappingVersion": MappingVersion},
+		"properties": map[string]interface{}{
+			"id":           keyword,
+			"title":        text,
+			"body":         text,
+			"score":        map[string]interface{}{"type": "long"},
+			"createdDate":  date,
+			"accessedDate": date,
+			"link":         keyword,
+			"source":       keyword,
+			"sourceId":     keyword,
+			"references":   keyword,
+			"tags":         keyword,
+			"mentions": map[string]interface{}{
+				"type": "nested",
+				"properties": map[string]interface{}{
+					"topicName": lowercaseKeyword,
+					"mention":   lowercaseKeyword,
+				},
+			},
+		},
+	}
+}
+
+// Settings defines the analysis components Mapping refers to.
+func Settings() map[string]interface{} {
+	return map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"normalizer": map[string]interface{}{
+				mentionNormaliser: map[string]interface{}{
+					"type":   "custom",
+					"filter": []string{"lowercase"},
+				},
+			},
+		},
+	}
+}
+
+// Template applies Mapping to every versioned index behind the alias, so
+// indices created by hand or by Migrate get the same mapping. Monthly
+// indices are also put behind the alias as they are created, which the
+// cluster does on their first write.
+func (eir ElasticsearchIndicatorRepository) Template() map[string]interface{} {
+	template := map[string]interface{}{
+		"index_patterns": []string{eir.IndexName + "-v*"},
+		"version":        MappingVersion,
+		"settings":       Settings(),
+		"mappings":       Mapping(),
+	}
+	if eir.Monthly {
+		template["index_patterns"] = []string{eir.IndexName + "-*"}
+		template["aliases"] = map[string]interface{}{eir.IndexName: map[string]interface{}{}}
+	}
+	return template
+}
+
+// VersionedIndexName is the concrete index holding the given mapping version.
+// IndexName is the alias in front of it.
+func (eir ElasticsearchIndicatorRepository) VersionedIndexName(version int) string {
+	return fmt.Sprintf("%s-v%d", eir.IndexName, version)
+}
+
+// EnsureIndex installs the index template and, unless IndexName already
+// names an index or alias, creates the current versioned index behind it, or
+// this month's index when indices are monthly.
+func (eir ElasticsearchIndicatorRepository) EnsureIndex(ctx context.Context) error {
+	if err := eir.putTemplate(ctx); err != nil {
+		return err
+	}
+	if eir.Monthly {
+		return eir.ensureMonthlyIndex(ctx)
+	}
+	exists, err := eir.indexExists(ctx)
+	if err != nil || exists {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"aliases": map[string]interface{}{eir.IndexName: map[string]interface{}{}},
+	}
+	err = eir.createIndex(ctx, eir.VersionedIndexName(MappingVersion), body)
+	if err != nil {
+		// Another instance may have created it first.
+		if exists, existsErr := eir.indexExists(ctx); existsErr == nil && exists {
+			return nil
+		}
+	}
+	return err
+}
+
+// Migrate copies every indicator into the index for MappingVersion and points
+// the alias at it in one atomic step. An index that predates the alias and
+// is itself called IndexName is deleted in that same step, since the alias
+// cannot take its name otherwise. Indices the alias used to point at are
+// kept for the caller to remove. Writes made while the copy runs are not
+// carried over, so ingestion should be paused first.
+//
+// Monthly indices are not copied. The new mapping applies from the next
+// month's index, and older months age out under ApplyRetention.
+func (eir ElasticsearchIndicatorRepository) Migrate(ctx context.Context) error {
+	if err := eir.putTemplate(ctx); err != nil {
+		return err
+	}
+	if eir.Monthly {
+		return eir.ensureMonthlyIndex(ctx)
+	}
+	target := eir.VersionedIndexName(MappingVersion)
+	current, err := eir.aliasedIndices(ctx)
+	if err != nil {
+		return err
+	}
+	if len(current) == 1 && current[0] == target {
+		return nil
+	}
+	exists, err := eir.indexExists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return eir.EnsureIndex(ctx)
+	}
+
+	if err := eir.createIndex(ctx, target, map[string]interface{}{}); err != nil {
+		return err
+	}
+	if err := eir.reindex(ctx, eir.IndexName, target); err != nil {
+		return err
+	}
+
+	actions := []map[string]interface{}{
+		{"add": map[string]interface{}{"index": target, "alias": eir.IndexName}},
+	}
+	if len(current) == 0 {
+		actions = append([]map[string]interface{}{{"remove_index": map[string]interface{}{"index": eir.IndexName}}}, actions...)
+	}
+	for _, index := range current {
+		actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": index, "alias": eir.IndexName}})
+	}
+	return eir.updateAliases(ctx, actions)
+}
+
+func (eir ElasticsearchIndicatorRepository) putTemplate(ctx context.Context) error {
+	body, err := json.Marshal(eir.Template())
+	if err != nil {
+		return err
+	}
+	res, err := eir.Client.Indices.PutTemplate(
+		bytes.NewReader(body),
+		eir.IndexName,
+		eir.Client.Indices.PutTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return transportError("put index template "+eir.IndexName, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "put index template "+eir.IndexName)
+	}
+	return nil
+}
+
+func (eir ElasticsearchIndicatorRepository) indexExists(ctx context.Context) (bool, error) {
+	res, err := eir.Client.Indices.Exists(
+		[]string{eir.IndexName},
+		eir.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return false, transportError("check index "+eir.IndexName, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return false, nil
+	case res.IsError():
+		return false, responseError(res, "check index "+eir.IndexName)
+	}
+	return true, nil
+}
+
+// aliasedIndices returns the indices behind the alias, or none when
+// IndexName is missing or a plain index.
+func (eir ElasticsearchIndicatorRepository) aliasedIndices(ctx context.Context) ([]string, error) {
+	res, err := eir.Client.Indices.GetAlias(
+		eir.Client.Indices.GetAlias.WithContext(ctx),
+		eir.Client.Indices.GetAlias.WithName(eir.IndexName),
+	)
+	if err != nil {
+		return nil, transportError("get alias "+eir.IndexName, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return []string{}, nil
+	}
+	if res.IsError() {
+		return nil, responseError(res, "get alias "+eir.IndexName)
+	}
+	var r map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response: %s", err)
+	}
+	indices := []string{}
+	for index := range r {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+func (eir ElasticsearchIndicatorRepository) createIndex(ctx context.Context, index string, body map[string]interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	res, err := eir.Client.Indices.Create(
+		index,
+		eir.Client.Indices.Create.WithContext(ctx),
+		eir.Client.Indices.Create.WithBody(bytes.NewReader(encoded)),
+	)
+	if err != nil {
+		return transportError("create index "+index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "create index "+index)
+	}
+	return nil
+}
+
+type reindexResponse struct {
+	Total    int               `json:"total"`
+	Created  int               `json:"created"`
+	Failures []json.RawMessage `json:"failures"`
+}
+
+func (eir ElasticsearchIndicatorRepository) reindex(ctx context.Context, source string, target string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"source": map[string]interface{}{"index": source},
+		"dest":   map[string]interface{}{"index": target, "op_type": "create"},
+	})
+	if err != nil {
+		return err
+	}
+	res, err := eir.Client.Reindex(
+		bytes.NewReader(body),
+		eir.Client.Reindex.WithContext(ctx),
+		eir.Client.Reindex.WithWaitForCompletion(true),
+		eir.Client.Reindex.WithRefresh(true),
+	)
+	if err != nil {
+		return transportError("reindex "+source+" into "+target, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "reindex "+source+" into "+target)
+	}
+	var r reindexResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("error parsing the response: %s", err)
+	}
+	if len(r.Failures) > 0 {
+		return fmt.Errorf("failed to reindex %d of %d documents from %s into %s", len(r.Failures), r.Total, source, target)
+	}
+	return nil
+}
+
+func (eir ElasticsearchIndicatorRepository) updateAliases(ctx context.Context, actions []map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+	res, err := eir.Client.Indices.UpdateAliases(
+		bytes.NewReader(body),
+		eir.Client.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return transportError("update aliases of "+eir.IndexName, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "update aliases of "+eir.IndexName)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch"
+)
+
+var currentIndex = fmt.Sprintf("indicators-v%d", MappingVersion)
+
+// adminCluster fakes the index administration endpoints. legacy makes
+// "indicators" a plain index that predates the alias.
+type adminCluster struct {
+	lock     sync.Mutex
+	legacy   bool
+	aliases  map[string]string
+	requests []string
+	bodies   map[string]string
+}
+
+func (ac *adminCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+	route := r.Method + " " + r.URL.Path
+	ac.requests = append(ac.requests, route)
+	body, _ := io.ReadAll(r.Body)
+	ac.bodies[route] = string(body)
+
+	w.Header().Set("Content-Type", "application/json")
+	switch route {
+	case "PUT /" + currentIndex:
+		if strings.Contains(string(body), `"indicators"`) {
+			ac.aliases[currentIndex] = "indicators"
+		}
+		w.Write([]byte(`{"acknowledged": true}`))
+	case "HEAD /indicators":
+		if !ac.legacy && len(ac.aliases) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case "GET /_alias/indicators":
+		if len(ac.aliases) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "alias [indicators] missing", "status": 404}`))
+			return
+		}
+		response := map[string]interface{}{}
+		for index := range ac.aliases {
+			response[index] = map[string]interface{}{"aliases": map[string]interface{}{"indicators": map[string]interface{}{}}}
+		}
+		json.NewEncoder(w).Encode(response)
+	case "POST /_reindex":
+		w.Write([]byte(`{"total": 2, "created": 2, "failures": []}`))
+	case "POST /_aliases":
+		ac.legacy = false
+		ac.aliases[currentIndex] = "indicators"
+		w.Write([]byte(`{"acknowledged": true}`))
+	case "PUT /_template/indicators":
+		w.Write([]byte(`{"acknowledged": true}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestEnsureIndex(t *testing.T) {
+	cluster := &adminCluster{aliases: map[string]string{}, bodies: map[string]string{}}
+	repo := newFakeRepository(t, cluster)
+
+	if err := repo.EnsureIndex(context.Background()); err != nil {
+		t.Fatalf("failed to ensure index: %s", err)
+	}
+	if cluster.aliases[currentIndex] != "indicators" {
+		t.Errorf("expected the current index to be created behind the alias, got %v", cluster.requests)
+	}
+	if template := cluster.bodies["PUT /_template/indicators"]; !strings.Contains(template, `"indicators-v*"`) || !strings.Contains(template, `"nested"`) {
+		t.Errorf("expected the template to cover versioned indices with nested mentions, got %s", template)
+	}
+
+	cluster.requests = nil
+	if err := repo.EnsureIndex(context.Background()); err != nil {
+		t.Fatalf("failed to ensure existing index: %s", err)
+	}
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate current index: %s", err)
+	}
+	for _, request := range cluster.requests {
+		if request == "PUT /"+currentIndex || request == "POST /_reindex" {
+			t.Errorf("expected nothing to be created for a current index, got %s", request)
+		}
+	}
+}
+
+func TestNewRepositoryIsReadOnly(t *testing.T) {
+	cluster := &adminCluster{aliases: map[string]string{}, bodies: map[string]string{}}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+	config := elasticsearch.Config{Addresses: []string{server.URL}}
+
+	if _, err := NewElasticsearchIndicatorRepository(config, "indicators"); !errors.Is(err, ErrIndexNotFound) {
+		t.Errorf("expected ErrIndexNotFound for a missing index, got %v", err)
+	}
+	for _, request := range cluster.requests {
+		if request != "HEAD /indicators" {
+			t.Errorf("expected the constructor only to check the index, got %s", request)
+		}
+	}
+
+	cluster.aliases[currentIndex] = "indicators"
+	if _, err := NewElasticsearchIndicatorRepository(config, "indicators"); err != nil {
+		t.Errorf("failed to create repository over an existing index: %s", err)
+	}
+}
+
+func TestMigrateLegacyIndex(t *testing.T) {
+	cluster := &adminCluster{legacy: true, aliases: map[string]string{}, bodies: map[string]string{}}
+	repo := newFakeRepository(t, cluster)
+
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %s", err)
+	}
+	if reindex := cluster.bodies["POST /_reindex"]; !strings.Contains(reindex, `"index":"indicators"`) || !strings.Contains(reindex, `"index":"`+currentIndex+`"`) {
+		t.Errorf("expected legacy index to be copied into the current index, got %s", reindex)
+	}
+	var swap struct {
+		Actions []map[string]map[string]string `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(cluster.bodies["POST /_aliases"]), &swap); err != nil {
+		t.Fatalf("failed to decode alias update: %s", err)
+	}
+	if len(swap.Actions) != 2 || swap.Actions[0]["remove_index"]["index"] != "indicators" || swap.Actions[1]["add"]["index"] != currentIndex {
+		t.Errorf("expected the legacy index to be swapped for the alias in one update, got %+v", swap.Actions)
+	}
+}
+
+func TestMigratePreviousVersion(t *testing.T) {
+	previous := fmt.Sprintf("indicators-v%d", MappingVersion-1)
+	cluster := &adminCluster{aliases: map[string]string{previous: "indicators"}, bodies: map[string]string{}}
+	repo := newFakeRepository(t, cluster)
+
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %s", err)
+	}
+	var swap struct {
+		Actions []map[string]map[string]string `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(cluster.bodies["POST /_aliases"]), &swap); err != nil {
+		t.Fatalf("failed to decode alias update: %s", err)
+	}
+	if len(swap.Actions) != 2 || swap.Actions[0]["add"]["index"] != currentIndex || swap.Actions[1]["remove"]["index"] != previous {
+		t.Errorf("expected the alias to move from %s to %s, got %+v", previous, currentIndex, swap.Actions)
+	}
+}
+
+func TestMonthlyTemplate(t *testing.T) {
+	repo := ElasticsearchIndicatorRepository{IndexName: "indicators", Monthly: true}
+	template := repo.Template()
+	if patterns := template["index_patterns"].([]string); len(patterns) != 1 || patterns[0] != "indicators-*" {
+		t.Errorf("expected the template to cover monthly indices, got %v", patterns)
+	}
+	if _, ok := template["aliases"].(map[string]interface{})["indicators"]; !ok {
+		t.Errorf("expected new monthly indices to join the alias, got %v", template["aliases"])
+	}
+	if name := repo.MonthlyIndexName(time.Date(2022, 3, 31, 23, 0, 0, 0, time.FixedZone("EST", -5*3600))); name != "indicators-2022.04" {
+		t.Errorf("expected monthly index names in UTC, got %s", name)
+	}
+}
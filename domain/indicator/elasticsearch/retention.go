@@ -0,0 +1,164 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+var (
+	ErrIndexNotAlias          = errors.New("the index name is an index rather than an alias")
+	ErrInvalidRetentionPolicy = errors.New("the retention policy is invalid")
+)
+
+// monthFormat names monthly indices so they sort by age.
+const monthFormat = "2006.01"
+
+// MonthlyIndexName is the index holding indicators created in the month of
+// t, in UTC.
+func (eir ElasticsearchIndicatorRepository) MonthlyIndexName(t time.Time) string {
+	return fmt.Sprintf("%s-%s", eir.IndexName, t.UTC().Format(monthFormat))
+}
+
+// writeIndex is where a new indicator goes. Indicators without a
+// CreatedDate land in the month of the zero time, and so are the first to
+// be removed by retention.
+func (eir ElasticsearchIndicatorRepository) writeIndex(i indicator.Indicator) string {
+	if !eir.Monthly {
+		return eir.IndexName
+	}
+	return eir.MonthlyIndexName(i.CreatedDate)
+}
+
+func (eir ElasticsearchIndicatorRepository) ensureMonthlyIndex(ctx context.Context) error {
+	indices, err := eir.aliasedIndices(ctx)
+	if err != nil || len(indices) > 0 {
+		return err
+	}
+	exists, err := eir.indexExists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s cannot front monthly indices", ErrIndexNotAlias, eir.IndexName)
+	}
+
+	// The template puts the index behind the alias.
+	err = eir.createIndex(ctx, eir.MonthlyIndexName(time.Now()), map[string]interface{}{})
+	if err != nil {
+		// Another instance may have created it first.
+		if indices, aliasErr := eir.aliasedIndices(ctx); aliasErr == nil && len(indices) > 0 {
+			return nil
+		}
+	}
+	return err
+}
+
+type RetentionAction string
+
+const (
+	// RetentionDelete drops the index and its indicators for good.
+	RetentionDelete RetentionAction = "delete"
+	// RetentionArchive takes the index out from behind the alias and closes
+	// it, keeping it on disk to be snapshotted or reopened.
+	RetentionArchive RetentionAction = "archive"
+)
+
+// RetentionPolicy expires a monthly index once every indicator it can hold,
+// going by CreatedDate, is older than MaxAge.
+type RetentionPolicy struct {
+	MaxAge time.Duration
+	Action RetentionAction
+	Now    func() time.Time
+}
+
+// ApplyRetention deletes or archives the monthly indices behind the alias
+// that have passed the policy's MaxAge, and returns the indices it expired.
+// It is meant to run on a schedule, and expires nothing twice. Indicators
+// written later with a CreatedDate in an expired month fail on an archived
+// index and recreate a deleted one, to be expired again on the next run.
+func (eir ElasticsearchIndicatorRepository) ApplyRetention(ctx context.Context, policy RetentionPolicy) ([]string, error) {
+	if !eir.Monthly {
+		return nil, fmt.Errorf("%w: only monthly indices can be expired", ErrInvalidRetentionPolicy)
+	}
+	if policy.MaxAge <= 0 {
+		return nil, fmt.Errorf("%w: max age must be positive", ErrInvalidRetentionPolicy)
+	}
+	if policy.Action != RetentionDelete && policy.Action != RetentionArchive {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidRetentionPolicy, policy.Action)
+	}
+	now := time.Now
+	if policy.Now != nil {
+		now = policy.Now
+	}
+	cutoff := now().Add(-policy.MaxAge)
+
+	indices, err := eir.aliasedIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expired := []string{}
+	for _, index := range indices {
+		month, err := time.Parse(monthFormat, strings.TrimPrefix(index, eir.IndexName+"-"))
+		if err != nil {
+			// Not a monthly index, such as one added to the alias by hand.
+			continue
+		}
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if policy.Action == RetentionArchive {
+			err = eir.archiveIndex(ctx, index)
+		} else {
+			err = eir.deleteIndex(ctx, index)
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired = append(expired, index)
+	}
+	return expired, nil
+}
+
+func (eir ElasticsearchIndicatorRepository) archiveIndex(ctx context.Context, index string) error {
+	err := eir.updateAliases(ctx, []map[string]interface{}{
+		{"remove": map[string]interface{}{"index": index, "alias": eir.IndexName}},
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := eir.Client.Indices.Close(
+		[]string{index},
+		eir.Client.Indices.Close.WithContext(ctx),
+	)
+	if err != nil {
+		return transportError("close index "+index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "close index "+index)
+	}
+	return nil
+}
+
+func (eir ElasticsearchIndicatorRepository) deleteIndex(ctx context.Context, index string) error {
+	res, err := eir.Client.Indices.Delete(
+		[]string{index},
+		eir.Client.Indices.Delete.WithContext(ctx),
+	)
+	if err != nil {
+		return transportError("delete index "+index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return responseError(res, "delete index "+index)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+// monthlyCluster keeps documents per index and answers searches through the
+// indicators alias, which covers every index it holds that is not closed.
+type monthlyCluster struct {
+	lock     sync.Mutex
+	docs     map[string]map[string]indicator.Indicator
+	closed   map[string]bool
+	requests []string
+}
+
+func (mc *monthlyCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	route := r.Method + " " + r.URL.Path
+	mc.requests = append(mc.requests, route)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.URL.Path == "/indicators/_search":
+		var body struct {
+			Query struct {
+				ConstantScore struct {
+					Filter struct {
+						Ids struct {
+							Values []string `json:"values"`
+						} `json:"ids"`
+					} `json:"filter"`
+				} `json:"constant_score"`
+			} `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		hits := []map[string]interface{}{}
+		for _, index := range mc.aliased() {
+			for _, id := range body.Query.ConstantScore.Filter.Ids.Values {
+				if doc, ok := mc.docs[index][id]; ok {
+					hits = append(hits, map[string]interface{}{"_index": index, "_id": id, "_seq_no": 1, "_primary_term": 1, "_source": doc})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"hits": map[string]interface{}{"hits": hits}})
+	case route == "GET /_alias/indicators":
+		response := map[string]interface{}{}
+		for _, index := range mc.aliased() {
+			response[index] = map[string]interface{}{"aliases": map[string]interface{}{"indicators": map[string]interface{}{}}}
+		}
+		json.NewEncoder(w).Encode(response)
+	case route == "POST /_aliases":
+		w.Write([]byte(`{"acknowledged": true}`))
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "_close":
+		mc.closed[parts[0]] = true
+		w.Write([]byte(`{"acknowledged": true}`))
+	case r.Method == http.MethodDelete && len(parts) == 1:
+		delete(mc.docs, parts[0])
+		w.Write([]byte(`{"acknowledged": true}`))
+	case r.Method == http.MethodPut && len(parts) == 3:
+		var doc indicator.Indicator
+		json.NewDecoder(r.Body).Decode(&doc)
+		if mc.docs[parts[0]] == nil {
+			mc.docs[parts[0]] = map[string]indicator.Indicator{}
+		}
+		if _, exists := mc.docs[parts[0]][parts[2]]; exists && r.URL.Query().Get("op_type") == "create" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": {"type": "version_conflict_engine_exception", "reason": "document already exists"}, "status": 409}`))
+			return
+		}
+		mc.docs[parts[0]][parts[2]] = doc
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"result": "created"}`))
+	case r.Method == http.MethodDelete && len(parts) >= 2:
+		id := parts[len(parts)-1]
+		if _, exists := mc.docs[parts[0]][id]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"result": "not_found"}`))
+			return
+		}
+		delete(mc.docs[parts[0]], id)
+		w.Write([]byte(`{"result": "deleted"}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (mc *monthlyCluster) aliased() []string {
+	indices := []string{}
+	for index := range mc.docs {
+		if !mc.closed[index] {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices
+}
+
+func newMonthlyRepository(t *testing.T, cluster *monthlyCluster) ElasticsearchIndicatorRepository {
+	repo := newFakeRepository(t, cluster)
+	repo.Monthly = true
+	return repo
+}
+
+func TestMonthlyIndices(t *testing.T) {
+	cluster := &monthlyCluster{docs: map[string]map[string]indicator.Indicator{}, closed: map[string]bool{}}
+	repo := newMonthlyRepository(t, cluster)
+	ctx := context.Background()
+
+	december := indicator.Indicator{Id: "t3_rdmhqk", CreatedDate: time.Date(2021, 12, 10, 23, 0, 0, 0, time.FixedZone("AEDT", 11*3600))}
+	january := indicator.Indicator{Id: "t3_s1a2b3", CreatedDate: time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)}
+	for _, i := range []indicator.Indicator{december, january} {
+		if err := repo.Add(ctx, i); err != nil {
+			t.Fatalf("failed to add indicator: %s", err)
+		}
+	}
+	if _, ok := cluster.docs["indicators-2021.12"]["t3_rdmhqk"]; !ok {
+		t.Errorf("expected the indicator in the month it was created in UTC, got %v", cluster.requests)
+	}
+
+	found, err := repo.GetById(ctx, "t3_s1a2b3")
+	if err != nil || found.Id != "t3_s1a2b3" {
+		t.Errorf("failed to get indicator across monthly indices: %v", err)
+	}
+	if _, err := repo.GetById(ctx, "t3_missing"); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	// The same id in another month is still a duplicate.
+	duplicate := january
+	duplicate.Id = december.Id
+	if err := repo.Add(ctx, duplicate); !errors.Is(err, indicator.ErrIndicatorAlreadyExists) {
+		t.Errorf("expected an id taken in another month to already exist, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, "t3_rdmhqk"); err != nil {
+		t.Fatalf("failed to delete indicator: %s", err)
+	}
+	if len(cluster.docs["indicators-2021.12"]) != 0 || len(cluster.docs["indicators-2022.01"]) != 1 {
+		t.Errorf("expected the delete to reach the indicator's own index, got %v", cluster.docs)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)
+	for _, action := range []RetentionAction{RetentionDelete, RetentionArchive} {
+		cluster := &monthlyCluster{docs: map[string]map[string]indicator.Indicator{}, closed: map[string]bool{}}
+		for _, index := range []string{"indicators-2021.10", "indicators-2021.11", "indicators-2022.01", "indicators-imported"} {
+			cluster.docs[index] = map[string]indicator.Indicator{}
+		}
+		repo := newMonthlyRepository(t, cluster)
+		policy := RetentionPolicy{MaxAge: 60 * 24 * time.Hour, Action: action, Now: func() time.Time { return now }}
+
+		expired, err := repo.ApplyRetention(context.Background(), policy)
+		if err != nil {
+			t.Fatalf("failed to apply %s retention: %s", action, err)
+		}
+		if len(expired) != 1 || expired[0] != "indicators-2021.10" {
+			t.Errorf("expected only the month wholly older than the max age to expire, got %v", expired)
+		}
+		_, kept := cluster.docs["indicators-2021.10"]
+		if action == RetentionDelete && kept {
+			t.Errorf("expected the index to be deleted, got %v", cluster.requests)
+		}
+		if action == RetentionArchive && (!kept || !cluster.closed["indicators-2021.10"]) {
+			t.Errorf("expected the index to be kept but closed, got %v", cluster.requests)
+		}
+
+		if expired, err := repo.ApplyRetention(context.Background(), policy); err != nil || len(expired) != 0 {
+			t.Errorf("expected a second %s run to expire nothing, got %v %v", action, expired, err)
+		}
+	}
+
+	if _, err := newFakeRepository(t, &monthlyCluster{}).ApplyRetention(context.Background(), RetentionPolicy{MaxAge: time.Hour, Action: RetentionDelete}); !errors.Is(err, ErrInvalidRetentionPolicy) {
+		t.Errorf("expected retention to need monthly indices, got %v", err)
+	}
+}
@@ -24,6 +24,17 @@ func (f IndicatorFactory) NewIndicator() (Indicator, error) {
 	}, nil
 }
 
+// UpsertId is the Id an indicator is added under by Upsert. It is the same
+// for every crawl of a post, so two writers adding the same post at once
+// collide rather than store it twice. Indicators without a SourceId keep
+// their own Id.
+func UpsertId(i Indicator) string {
+	if i.SourceId == "" {
+		return i.Id
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(i.Source+"\x00"+i.SourceId)).String()
+}
+
 func (f IndicatorFactory) MustNewIndicator() Indicator {
 	indicator, err := f.NewIndicator()
 	if err != nil {
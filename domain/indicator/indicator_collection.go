@@ -1,6 +1,9 @@
 package indicator
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type IndicatorCollection struct {
 	Indicators []Indicator
@@ -48,6 +51,37 @@ func (icollection *IndicatorCollection) Last() (Indicator, error) {
 	return icollection.Indicators[len(icollection.Indicators)-1], nil
 }
 
+func (icollection *IndicatorCollection) Mentioning(mention Mention) IndicatorCollection {
+	matches := IndicatorCollection{Indicators: []Indicator{}}
+	for _, indicator := range icollection.Indicators {
+		for _, existingMention := range indicator.Mentions {
+			if existingMention.Equal(mention) {
+				matches.Append(indicator)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func (icollection *IndicatorCollection) Filter(match func(Indicator) bool) IndicatorCollection {
+	matches := IndicatorCollection{Indicators: []Indicator{}}
+	for _, indicator := range icollection.Indicators {
+		if match(indicator) {
+			matches.Append(indicator)
+		}
+	}
+	return matches
+}
+
+// SortByCreatedDate orders the collection oldest first, keeping the insertion
+// order of indicators created at the same time.
+func (icollection *IndicatorCollection) SortByCreatedDate() {
+	sort.SliceStable(icollection.Indicators, func(i, j int) bool {
+		return icollection.Indicators[i].CreatedDate.Before(icollection.Indicators[j].CreatedDate)
+	})
+}
+
 func (f IndicatorFactory) NewIndicatorCollection() (IndicatorCollection, error) {
 	return IndicatorCollection{
 		Indicators: []Indicator{},
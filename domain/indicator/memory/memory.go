@@ -2,46 +2,250 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/carbonrook/cvewatch-domain/domain/indicator"
 )
 
 type MemoryRepository struct {
 	Factory    indicator.IndicatorFactory
-	Collection indicator.IndicatorCollection
+	Collection *indicator.IndicatorCollection
 	lock       *sync.RWMutex
 }
 
-func (mr MemoryRepository) Add(ctx context.Context, indicator indicator.Indicator) error {
+func (mr MemoryRepository) Add(ctx context.Context, i indicator.Indicator) error {
 	mr.lock.Lock()
 	defer mr.lock.Unlock()
-	mr.Collection.Append(indicator)
+	if mr.indexOf(func(existing indicator.Indicator) bool { return existing.Id == i.Id }) >= 0 {
+		return indicator.ErrIndicatorAlreadyExists
+	}
+	mr.Collection.Append(i)
+	return nil
+}
+
+func (mr MemoryRepository) Update(ctx context.Context, id string, i *indicator.Indicator) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	index := mr.indexOf(func(existing indicator.Indicator) bool { return existing.Id == id })
+	if index < 0 {
+		return indicator.ErrIndicatorNotFound
+	}
+	updated := *i
+	updated.Id = id
+	mr.Collection.Indicators[index] = updated
+	return nil
+}
+
+func (mr MemoryRepository) Upsert(ctx context.Context, i indicator.Indicator) (*indicator.Indicator, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	index := mr.indexOf(func(existing indicator.Indicator) bool {
+		return existing.Source == i.Source && existing.SourceId == i.SourceId
+	})
+	if index >= 0 {
+		i.Id = mr.Collection.Indicators[index].Id
+		mr.Collection.Indicators[index] = i
+		return &i, nil
+	}
+	i.Id = indicator.UpsertId(i)
+	if mr.indexOf(func(existing indicator.Indicator) bool { return existing.Id == i.Id }) >= 0 {
+		return nil, indicator.ErrIndicatorConflict
+	}
+	mr.Collection.Append(i)
+	return &i, nil
+}
+
+func (mr MemoryRepository) Delete(ctx context.Context, id string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	index := mr.indexOf(func(existing indicator.Indicator) bool { return existing.Id == id })
+	if index < 0 {
+		return indicator.ErrIndicatorNotFound
+	}
+	mr.Collection.Indicators = append(mr.Collection.Indicators[:index], mr.Collection.Indicators[index+1:]...)
 	return nil
 }
 
 func (mr MemoryRepository) GetById(ctx context.Context, id string) (*indicator.Indicator, error) {
+	return mr.first(func(i indicator.Indicator) bool { return i.Id == id })
+}
+
+func (mr MemoryRepository) GetByLink(ctx context.Context, link string) (*indicator.Indicator, error) {
+	return mr.first(func(i indicator.Indicator) bool { return i.Link == link })
+}
+
+func (mr MemoryRepository) GetByTopic(ctx context.Context, topicName string) (*indicator.IndicatorCollection, error) {
+	return mr.filter(func(i indicator.Indicator) bool {
+		for _, mention := range i.Mentions {
+			if strings.EqualFold(mention.TopicName, topicName) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (mr MemoryRepository) GetByMention(ctx context.Context, mention indicator.Mention) (*indicator.IndicatorCollection, error) {
 	mr.lock.RLock()
 	defer mr.lock.RUnlock()
-	for _, indicatorMatch := range mr.Collection.Indicators {
-		if indicatorMatch.Id == id {
-			return &indicatorMatch, nil
+	matches := mr.Collection.Mentioning(mention)
+	matches.SortByCreatedDate()
+	return &matches, nil
+}
+
+func (mr MemoryRepository) GetBySource(ctx context.Context, source string) (*indicator.IndicatorCollection, error) {
+	return mr.filter(func(i indicator.Indicator) bool { return i.Source == source })
+}
+
+func (mr MemoryRepository) GetBySourceId(ctx context.Context, source string, sourceId string) (*indicator.Indicator, error) {
+	return mr.first(func(i indicator.Indicator) bool { return i.Source == source && i.SourceId == sourceId })
+}
+
+func (mr MemoryRepository) GetLatest(ctx context.Context) (*indicator.Indicator, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	var latest *indicator.Indicator
+	for i, existing := range mr.Collection.Indicators {
+		if latest == nil || existing.CreatedDate.After(latest.CreatedDate) {
+			latest = &mr.Collection.Indicators[i]
 		}
 	}
-	return nil, indicator.ErrIndicatorNotFound
+	if latest == nil {
+		return nil, indicator.ErrIndicatorNotFound
+	}
+	found := *latest
+	return &found, nil
 }
 
-func (mr MemoryRepository) GetByLink(ctx context.Context, link string) (*indicator.Indicator, error) {
+func (mr MemoryRepository) GetBetween(ctx context.Context, start time.Time, end time.Time) (*indicator.IndicatorCollection, error) {
+	return mr.filter(func(i indicator.Indicator) bool {
+		return !i.CreatedDate.Before(start) && i.CreatedDate.Before(end)
+	})
+}
+
+func (mr MemoryRepository) first(match func(indicator.Indicator) bool) (*indicator.Indicator, error) {
 	mr.lock.RLock()
 	defer mr.lock.RUnlock()
 	for _, indicatorMatch := range mr.Collection.Indicators {
-		if indicatorMatch.Link == link {
+		if match(indicatorMatch) {
 			return &indicatorMatch, nil
 		}
 	}
 	return nil, indicator.ErrIndicatorNotFound
 }
 
+func (mr MemoryRepository) Find(ctx context.Context, query indicator.Query) (*indicator.Page, error) {
+	query, err := query.Normalise()
+	if err != nil {
+		return nil, err
+	}
+	var after *indicator.Indicator
+	if query.Cursor != "" {
+		if after, err = cursorIndicator(query); err != nil {
+			return nil, err
+		}
+	}
+
+	mr.lock.RLock()
+	matches := mr.Collection.Filter(func(i indicator.Indicator) bool {
+		return query.Matches(i) && (after == nil || query.Before(*after, i))
+	})
+	mr.lock.RUnlock()
+
+	sort.SliceStable(matches.Indicators, func(a, b int) bool {
+		return query.Before(matches.Indicators[a], matches.Indicators[b])
+	})
+	page := &indicator.Page{Indicators: matches.Indicators}
+	if len(page.Indicators) > query.Limit {
+		page.Indicators = page.Indicators[:query.Limit]
+		if page.NextCursor, err = memoryCursor(query, page.Indicators[query.Limit-1]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (mr MemoryRepository) Stream(ctx context.Context, query indicator.Query) <-chan indicator.StreamResult {
+	return indicator.StreamFind(ctx, query, mr.Find)
+}
+
+// memoryCursor records the sort value and id of the last indicator on a page.
+func memoryCursor(query indicator.Query, last indicator.Indicator) (string, error) {
+	switch query.SortBy {
+	case indicator.SortByScore:
+		return indicator.EncodeCursor(last.Score, last.Id)
+	case indicator.SortByAccessedDate:
+		return indicator.EncodeCursor(last.AccessedDate.Format(time.RFC3339Nano), last.Id)
+	default:
+		return indicator.EncodeCursor(last.CreatedDate.Format(time.RFC3339Nano), last.Id)
+	}
+}
+
+// cursorIndicator rebuilds enough of the last indicator on the previous page
+// to compare the remaining ones against it.
+func cursorIndicator(query indicator.Query) (*indicator.Indicator, error) {
+	values, err := indicator.DecodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 2 {
+		return nil, indicator.ErrInvalidCursor
+	}
+	id, ok := values[1].(string)
+	if !ok {
+		return nil, indicator.ErrInvalidCursor
+	}
+	last := &indicator.Indicator{Id: id}
+
+	if query.SortBy == indicator.SortByScore {
+		number, ok := values[0].(json.Number)
+		if !ok {
+			return nil, indicator.ErrInvalidCursor
+		}
+		if last.Score, err = number.Int64(); err != nil {
+			return nil, indicator.ErrInvalidCursor
+		}
+		return last, nil
+	}
+
+	value, ok := values[0].(string)
+	if !ok {
+		return nil, indicator.ErrInvalidCursor
+	}
+	date, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, indicator.ErrInvalidCursor
+	}
+	if query.SortBy == indicator.SortByAccessedDate {
+		last.AccessedDate = date
+	} else {
+		last.CreatedDate = date
+	}
+	return last, nil
+}
+
+// indexOf must be called with the lock held.
+func (mr MemoryRepository) indexOf(match func(indicator.Indicator) bool) int {
+	for index, existing := range mr.Collection.Indicators {
+		if match(existing) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (mr MemoryRepository) filter(match func(indicator.Indicator) bool) (*indicator.IndicatorCollection, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	matches := mr.Collection.Filter(match)
+	matches.SortByCreatedDate()
+	return &matches, nil
+}
+
 func NewMemoryIndicatorRepository() (indicator.IndicatorRepository, error) {
 	factory, err := indicator.NewIndicatorFactory("")
 	if err != nil {
@@ -50,7 +254,7 @@ func NewMemoryIndicatorRepository() (indicator.IndicatorRepository, error) {
 	collection := factory.MustNewIndicatorCollection()
 	return MemoryRepository{
 		Factory:    factory,
-		Collection: collection,
+		Collection: &collection,
 		lock:       &sync.RWMutex{},
 	}, nil
 }
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+func TestMemoryRepositoryWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := MustNewMemoryIndicatorRepository()
+	factory := indicator.MustNewIndicatorFactory("reddit")
+
+	post := factory.MustNewIndicator()
+	post.SourceId = "t3_rdmhqk"
+	post.Score = 10
+	if err := repo.Add(ctx, post); err != nil {
+		t.Fatalf("failed to add indicator: %s", err)
+	}
+	if err := repo.Add(ctx, post); !errors.Is(err, indicator.ErrIndicatorAlreadyExists) {
+		t.Errorf("expected ErrIndicatorAlreadyExists, got %v", err)
+	}
+
+	recrawled := factory.MustNewIndicator()
+	recrawled.SourceId = post.SourceId
+	recrawled.Score = 250
+	stored, err := repo.Upsert(ctx, recrawled)
+	if err != nil || stored.Id != post.Id {
+		t.Fatalf("failed to upsert onto the existing indicator, got %v %v", stored, err)
+	}
+	if found, err := repo.GetById(ctx, post.Id); err != nil || found.Score != 250 {
+		t.Errorf("failed to update score through upsert, got %v %v", found, err)
+	}
+	if all, _ := repo.GetBySource(ctx, "reddit"); all.Length() != 1 {
+		t.Errorf("expected upsert to replace rather than add, got %d indicators", all.Length())
+	}
+
+	// Crawls of a new post with different ids store it once under UpsertId.
+	for n := 0; n < 2; n++ {
+		crawl := factory.MustNewIndicator()
+		crawl.SourceId = "t3_rdmzzz"
+		if stored, err := repo.Upsert(ctx, crawl); err != nil || stored.Id != indicator.UpsertId(crawl) {
+			t.Errorf("expected a new post to be added under its upsert id, got %v %v", stored, err)
+		}
+	}
+	if all, _ := repo.GetBySource(ctx, "reddit"); all.Length() != 2 {
+		t.Errorf("expected each post to be stored once, got %d indicators", all.Length())
+	}
+	taken := factory.MustNewIndicator()
+	taken.Id = post.Id
+	taken.SourceId = ""
+	if _, err := repo.Upsert(ctx, taken); !errors.Is(err, indicator.ErrIndicatorConflict) {
+		t.Errorf("expected ErrIndicatorConflict upserting onto a taken id, got %v", err)
+	}
+
+	stored.Score = 300
+	if err := repo.Update(ctx, stored.Id, stored); err != nil {
+		t.Errorf("failed to update indicator: %s", err)
+	}
+	if err := repo.Update(ctx, "missing", stored); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound updating missing indicator, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, post.Id); err != nil {
+		t.Errorf("failed to delete indicator: %s", err)
+	}
+	if err := repo.Delete(ctx, post.Id); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestMemoryRepositoryFind(t *testing.T) {
+	ctx := context.Background()
+	repo := MustNewMemoryIndicatorRepository()
+	factory := indicator.MustNewIndicatorFactory("reddit")
+
+	// Two pairs share a score so paging has to break ties on Id.
+	for _, score := range []int64{5, 20, 20, 50, 50, 80} {
+		post := factory.MustNewIndicator()
+		post.Score = score
+		if err := repo.Add(ctx, post); err != nil {
+			t.Fatalf("failed to add indicator: %s", err)
+		}
+	}
+
+	minScore := int64(10)
+	query := indicator.Query{MinScore: &minScore, SortBy: indicator.SortByScore, Descending: true, Limit: 2}
+	seen := map[string]bool{}
+	scores := []int64{}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("expected paging to end")
+		}
+		page, err := repo.Find(ctx, query)
+		if err != nil {
+			t.Fatalf("failed to find indicators: %s", err)
+		}
+		for _, found := range page.Indicators {
+			if seen[found.Id] {
+				t.Errorf("indicator %s returned twice", found.Id)
+			}
+			seen[found.Id] = true
+			scores = append(scores, found.Score)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(scores) != 5 || scores[0] != 80 || scores[4] != 20 {
+		t.Errorf("expected the five matching indicators highest first, got %v", scores)
+	}
+
+	query.Cursor = "bad"
+	if _, err := repo.Find(ctx, query); !errors.Is(err, indicator.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestMemoryRepositoryLookups(t *testing.T) {
+	ctx := context.Background()
+	repo := MustNewMemoryIndicatorRepository()
+	start := time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)
+	log4shell := indicator.MustNewMention("cve", []byte("CVE-2021-44228"))
+
+	for n, source := range []string{"reddit", "twitter", "reddit"} {
+		i := indicator.MustNewIndicatorFactory(source).MustNewIndicator()
+		i.SourceId = source + "-" + string(rune('a'+n))
+		i.CreatedDate = start.Add(time.Duration(2-n) * time.Hour)
+		if n != 1 {
+			i.AddMention(log4shell)
+		}
+		if err := repo.Add(ctx, i); err != nil {
+			t.Fatalf("failed to add indicator: %s", err)
+		}
+	}
+
+	if topic, err := repo.GetByTopic(ctx, "CVE"); err != nil || topic.Length() != 2 || topic.Indicators[0].SourceId != "reddit-c" {
+		t.Errorf("expected indicators by topic oldest first, got %+v %v", topic, err)
+	}
+	if mentioning, err := repo.GetByMention(ctx, log4shell); err != nil || mentioning.Length() != 2 || mentioning.Indicators[1].SourceId != "reddit-a" {
+		t.Errorf("expected indicators by mention oldest first, got %+v %v", mentioning, err)
+	}
+	if bySource, err := repo.GetBySource(ctx, "twitter"); err != nil || bySource.Length() != 1 {
+		t.Errorf("failed to get indicators by source, got %+v %v", bySource, err)
+	}
+	if found, err := repo.GetBySourceId(ctx, "reddit", "reddit-c"); err != nil || !found.CreatedDate.Equal(start) {
+		t.Errorf("failed to get indicator by source id, got %v %v", found, err)
+	}
+	if _, err := repo.GetBySourceId(ctx, "twitter", "reddit-c"); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound for another source's id, got %v", err)
+	}
+	if latest, err := repo.GetLatest(ctx); err != nil || latest.SourceId != "reddit-a" {
+		t.Errorf("failed to get latest indicator, got %v %v", latest, err)
+	}
+	between, err := repo.GetBetween(ctx, start, start.Add(2*time.Hour))
+	if err != nil || between.Length() != 2 || between.Indicators[0].SourceId != "reddit-c" {
+		t.Errorf("expected start to be inclusive and end exclusive, got %+v %v", between, err)
+	}
+	if _, err := MustNewMemoryIndicatorRepository().GetLatest(ctx); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound from an empty repository, got %v", err)
+	}
+}
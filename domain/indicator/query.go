@@ -0,0 +1,234 @@
+package indicator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrInvalidQuery  = errors.New("the indicator query is invalid")
+	ErrInvalidCursor = errors.New("the page cursor is invalid")
+)
+
+type SortField string
+
+const (
+	SortByCreatedDate  SortField = "createdDate"
+	SortByAccessedDate SortField = "accessedDate"
+	SortByScore        SortField = "score"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// Query selects indicators for IndicatorRepository.Find. Every filter that is
+// set must match. Date windows include the After bound and exclude the Before
+// bound, and zero times leave that side open.
+type Query struct {
+	Sources []string
+	// Tags must all be present on the indicator.
+	Tags []string
+	// TopicName matches indicators with any mention under that topic.
+	TopicName string
+	// Mentions matches indicators with any one of the mentions.
+	Mentions       []Mention
+	MinScore       *int64
+	MaxScore       *int64
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	AccessedAfter  time.Time
+	AccessedBefore time.Time
+	// Text matches indicators whose title, or else whose body, contains
+	// every word of it. Words are runs of letters and digits compared without
+	// case, so "log4" does not match "log4j".
+	Text string
+
+	SortBy     SortField
+	Descending bool
+	// Limit is the page size, DefaultPageSize when zero.
+	Limit int
+	// Cursor is the NextCursor of the previous page. Cursors are only valid
+	// for the repository and sort order that produced them.
+	Cursor string
+}
+
+type Page struct {
+	Indicators []Indicator `json:"indicators"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Normalise fills in the default sort and page size and rejects queries that
+// cannot match anything.
+func (q Query) Normalise() (Query, error) {
+	if q.SortBy == "" {
+		q.SortBy = SortByCreatedDate
+	}
+	switch q.SortBy {
+	case SortByCreatedDate, SortByAccessedDate, SortByScore:
+	default:
+		return q, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, q.SortBy)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return q, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if q.MinScore != nil && q.MaxScore != nil && *q.MinScore > *q.MaxScore {
+		return q, fmt.Errorf("%w: minimum score above maximum score", ErrInvalidQuery)
+	}
+	return q, nil
+}
+
+func (q Query) Matches(i Indicator) bool {
+	if len(q.Sources) > 0 && !containsString(q.Sources, i.Source) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !containsString(i.Tags, tag) {
+			return false
+		}
+	}
+	if q.TopicName != "" && !mentionsTopic(i, q.TopicName) {
+		return false
+	}
+	if len(q.Mentions) > 0 && !mentionsAny(i, q.Mentions) {
+		return false
+	}
+	if q.MinScore != nil && i.Score < *q.MinScore {
+		return false
+	}
+	if q.MaxScore != nil && i.Score > *q.MaxScore {
+		return false
+	}
+	if !within(i.CreatedDate, q.CreatedAfter, q.CreatedBefore) || !within(i.AccessedDate, q.AccessedAfter, q.AccessedBefore) {
+		return false
+	}
+	if q.Text != "" {
+		words := textWords(q.Text)
+		if !containsWords(i.Title, words) && !containsWords(i.Body, words) {
+			return false
+		}
+	}
+	return true
+}
+
+// textWords splits text roughly as the standard analyser does.
+func textWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsWords(text string, words []string) bool {
+	present := make(map[string]bool)
+	for _, word := range textWords(text) {
+		present[word] = true
+	}
+	for _, word := range words {
+		if !present[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// Before reports whether a comes before b in the query's sort order. Ties are
+// broken on Id so the order is total and cursors never skip an indicator.
+func (q Query) Before(a Indicator, b Indicator) bool {
+	var less, greater bool
+	switch q.SortBy {
+	case SortByAccessedDate:
+		less, greater = a.AccessedDate.Before(b.AccessedDate), a.AccessedDate.After(b.AccessedDate)
+	case SortByScore:
+		less, greater = a.Score < b.Score, a.Score > b.Score
+	default:
+		less, greater = a.CreatedDate.Before(b.CreatedDate), a.CreatedDate.After(b.CreatedDate)
+	}
+	if !less && !greater {
+		less, greater = a.Id < b.Id, a.Id > b.Id
+	}
+	if q.Descending {
+		return greater
+	}
+	return less
+}
+
+// EncodeCursor packs the sort values of the last indicator on a page into an
+// opaque cursor.
+func EncodeCursor(values ...interface{}) (string, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// DecodeCursor returns the values given to EncodeCursor, with numbers as
+// json.Number so they survive unchanged.
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	var values []interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	if len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+// containsString compares exactly, as the source and tags fields are plain
+// keywords in Elasticsearch.
+func containsString(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mentionsTopic(i Indicator, topicName string) bool {
+	for _, mention := range i.Mentions {
+		if strings.EqualFold(mention.TopicName, topicName) {
+			return true
+		}
+	}
+	return false
+}
+
+func mentionsAny(i Indicator, mentions []Mention) bool {
+	for _, existing := range i.Mentions {
+		for _, mention := range mentions {
+			if existing.Equal(mention) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func within(t time.Time, after time.Time, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+	if !before.IsZero() && !t.Before(before) {
+		return false
+	}
+	return true
+}
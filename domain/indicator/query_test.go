@@ -0,0 +1,86 @@
+package indicator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryMatches(t *testing.T) {
+	created := time.Date(2021, 12, 13, 20, 16, 57, 0, time.UTC)
+	post := Indicator{
+		Id:          "a",
+		Title:       "Log4Shell scanner released",
+		Source:      "reddit",
+		Score:       42,
+		CreatedDate: created,
+		Tags:        []string{"scanner", "java"},
+		Mentions:    []Mention{MustNewMention("cve", []byte("CVE-2021-44228"))},
+	}
+	low, high := int64(40), int64(50)
+
+	matching := []Query{
+		{},
+		{Sources: []string{"twitter", "reddit"}},
+		{Tags: []string{"java", "scanner"}},
+		{TopicName: "CVE", Mentions: []Mention{MustNewMention("cve", []byte("cve-2021-44228"))}},
+		{MinScore: &low, MaxScore: &high},
+		{CreatedAfter: created, CreatedBefore: created.Add(time.Second)},
+		{Text: "log4shell"},
+		{Text: "Released, Log4Shell!"},
+	}
+	for _, query := range matching {
+		if !query.Matches(post) {
+			t.Errorf("expected %+v to match", query)
+		}
+	}
+
+	missing := []Query{
+		{Sources: []string{"twitter"}},
+		{Tags: []string{"scanner", "meme"}},
+		// Sources and tags match exactly, like the keyword fields they are
+		// stored in.
+		{Sources: []string{"Reddit"}},
+		{Tags: []string{"Java"}},
+		{Mentions: []Mention{MustNewMention("cve", []byte("CVE-2021-45046"))}},
+		{MaxScore: &low},
+		{CreatedBefore: created},
+		{Text: "spring4shell"},
+		// Every word must appear, and as a whole word.
+		{Text: "log4shell exploit"},
+		{Text: "log4"},
+	}
+	for _, query := range missing {
+		if query.Matches(post) {
+			t.Errorf("expected %+v not to match", query)
+		}
+	}
+}
+
+func TestQueryNormalise(t *testing.T) {
+	query, err := Query{}.Normalise()
+	if err != nil || query.SortBy != SortByCreatedDate || query.Limit != DefaultPageSize {
+		t.Errorf("failed to fill in defaults, got %+v %v", query, err)
+	}
+	low, high := int64(40), int64(50)
+	for _, invalid := range []Query{{SortBy: "title"}, {Limit: MaxPageSize + 1}, {MinScore: &high, MaxScore: &low}} {
+		if _, err := invalid.Normalise(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("expected ErrInvalidQuery for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	cursor, err := EncodeCursor(int64(1639426617000), "a")
+	if err != nil {
+		t.Fatalf("failed to encode cursor: %s", err)
+	}
+	values, err := DecodeCursor(cursor)
+	if err != nil || len(values) != 2 || values[0] != json.Number("1639426617000") || values[1] != "a" {
+		t.Errorf("failed to round trip cursor, got %v %v", values, err)
+	}
+	if _, err := DecodeCursor("not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
@@ -3,21 +3,50 @@ package indicator
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var (
 	ErrIndicatorNotFound      = errors.New("the indicator was not found")
 	ErrIndicatorAlreadyExists = errors.New("the indicator already exists")
+	ErrIndicatorConflict      = errors.New("the indicator was changed by another writer")
 )
 
+// IndicatorRepository stores indicators. Lookups of a single indicator return
+// ErrIndicatorNotFound when nothing matches, lookups of a collection return an
+// empty collection instead. Collections are ordered by CreatedDate, oldest
+// first.
+//
+// Writes that race with another writer fail with ErrIndicatorConflict and can
+// be retried after reading the indicator again.
 type IndicatorRepository interface {
 	Add(ctx context.Context, indicator Indicator) error
+	Update(ctx context.Context, id string, indicator *Indicator) error
+	// Upsert replaces the indicator with the same Source and SourceId, keeping
+	// its Id, or adds it under UpsertId when there is none. The stored
+	// indicator is returned. When that Id is already taken, by another
+	// writer adding the same post or by another indicator, Upsert fails with
+	// ErrIndicatorConflict rather than ErrIndicatorAlreadyExists.
+	Upsert(ctx context.Context, indicator Indicator) (*Indicator, error)
+	Delete(ctx context.Context, id string) error
 	GetById(ctx context.Context, id string) (*Indicator, error)
 	GetByLink(ctx context.Context, link string) (*Indicator, error)
-	//GetByTriggerName(ctx context.Context, triggerName string) (*IndicatorCollection, error)
-	//GetByMatch(ctx context.Context, match string) (*IndicatorCollection, error)
-	//GetBySource(ctx context.Context, source string) (*IndicatorCollection, error)
-	//GetBySourceId(ctx context.Context, sourceId string) (Indicator, error)
-	//GetLatest(ctx context.Context) (Indicator, error)
-	//GetBetween(ctx context.Context, start time.Time, end time.Time) (*IndicatorCollection, error)
+	GetByTopic(ctx context.Context, topicName string) (*IndicatorCollection, error)
+	GetByMention(ctx context.Context, mention Mention) (*IndicatorCollection, error)
+	GetBySource(ctx context.Context, source string) (*IndicatorCollection, error)
+	// GetBySourceId takes the source as well since ids are only unique
+	// within the site they came from.
+	GetBySourceId(ctx context.Context, source string, sourceId string) (*Indicator, error)
+	GetLatest(ctx context.Context) (*Indicator, error)
+	// GetBetween returns the indicators created at or after start and
+	// before end.
+	GetBetween(ctx context.Context, start time.Time, end time.Time) (*IndicatorCollection, error)
+	// Find returns one page of the indicators matching the query. Pass the
+	// page's NextCursor back in the query to get the next one.
+	Find(ctx context.Context, query Query) (*Page, error)
+	// Stream sends every indicator matching the query, Limit at a time, and
+	// closes the channel at the end. An error is sent as the last result.
+	// Cancelling ctx closes the channel early without one, so consumers
+	// should check ctx.Err() once it closes.
+	Stream(ctx context.Context, query Query) <-chan StreamResult
 }
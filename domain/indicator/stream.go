@@ -0,0 +1,49 @@
+package indicator
+
+import "context"
+
+// StreamResult carries either the next indicator of a stream or the error
+// that ended it.
+type StreamResult struct {
+	Indicator Indicator
+	Err       error
+}
+
+// StreamFind walks every page of find, for repositories that have no cheaper
+// way to iterate. The query's Limit sets the page size and its Cursor is
+// ignored.
+func StreamFind(ctx context.Context, query Query, find func(context.Context, Query) (*Page, error)) <-chan StreamResult {
+	results := make(chan StreamResult)
+	go func() {
+		defer close(results)
+		query.Cursor = ""
+		for {
+			page, err := find(ctx, query)
+			if err != nil {
+				SendStreamResult(ctx, results, StreamResult{Err: err})
+				return
+			}
+			for _, found := range page.Indicators {
+				if !SendStreamResult(ctx, results, StreamResult{Indicator: found}) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			query.Cursor = page.NextCursor
+		}
+	}()
+	return results
+}
+
+// SendStreamResult delivers the result unless ctx is cancelled first, in which
+// case the stream should stop.
+func SendStreamResult(ctx context.Context, results chan<- StreamResult, result StreamResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package indicator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStreamFind(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("cluster went away")
+	pages := []*Page{
+		{Indicators: []Indicator{{Id: "a"}, {Id: "b"}}, NextCursor: "1"},
+		{Indicators: []Indicator{{Id: "c"}}, NextCursor: "2"},
+	}
+	find := func(ctx context.Context, query Query) (*Page, error) {
+		switch query.Cursor {
+		case "":
+			return pages[0], nil
+		case "1":
+			return pages[1], nil
+		}
+		return nil, failure
+	}
+
+	ids := ""
+	var err error
+	for result := range StreamFind(ctx, Query{Cursor: "ignored"}, find) {
+		if result.Err != nil {
+			err = result.Err
+			continue
+		}
+		ids += result.Indicator.Id
+	}
+	if ids != "abc" || !errors.Is(err, failure) {
+		t.Errorf("expected every indicator then the error, got %q %v", ids, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	results := StreamFind(cancelled, Query{}, find)
+	<-results
+	cancel()
+	// The stream has to close on its own for this loop to end.
+	for range results {
+	}
+}
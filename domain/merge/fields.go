@@ -0,0 +1,313 @@
+package merge
+
+import (
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// field describes how one part of a vulnerability is merged. For union
+// fields merge appends the source's entries that are not already present.
+type field struct {
+	name     string
+	strategy Strategy
+	present  func(v vulnerability.Vulnerability) bool
+	merge    func(merged *vulnerability.Vulnerability, v vulnerability.Vulnerability)
+	time     func(v vulnerability.Vulnerability) time.Time
+}
+
+var fields = []field{
+	{
+		name:     "assigner",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return v.Assigner != "" },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.Assigner = v.Assigner },
+	},
+	{
+		name:     "description",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return strings.TrimSpace(v.Description) != "" },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.Description = v.Description },
+	},
+	{
+		// descriptions keeps every translation, the highest ranked source
+		// winning for each language tag.
+		name:     "descriptions",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Descriptions) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, description := range v.Descriptions {
+				if !containsDescription(m.Descriptions, description) {
+					m.Descriptions = append(m.Descriptions, description)
+				}
+			}
+		},
+	},
+	{
+		name:     "publishedDate",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return !v.PublishedDate.IsZero() },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.PublishedDate = v.PublishedDate },
+	},
+	{
+		name:     "lastModified",
+		strategy: StrategyLatest,
+		present:  func(v vulnerability.Vulnerability) bool { return !v.LastModified.IsZero() },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.LastModified = v.LastModified },
+		time:     func(v vulnerability.Vulnerability) time.Time { return v.LastModified },
+	},
+	{
+		name:     "cvss4",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return v.Cvss4.CvssVector != "" || v.Cvss4.BaseScore > 0 },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.Cvss4 = v.Cvss4 },
+	},
+	{
+		name:     "cvss3",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return v.Cvss3.CvssVector != "" || v.Cvss3.BaseScore > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			m.Cvss3 = v.Cvss3
+			m.BaseMetric3 = v.BaseMetric3
+		},
+	},
+	{
+		name:     "cvss2",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return v.Cvss2.CvssVector != "" || v.Cvss2.BaseScore > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			m.Cvss2 = v.Cvss2
+			m.BaseMetric2 = v.BaseMetric2
+		},
+	},
+	{
+		name:     "kev",
+		strategy: StrategyPrecedence,
+		present:  func(v vulnerability.Vulnerability) bool { return v.Kev.Listed() },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.Kev = v.Kev },
+	},
+	{
+		name:     "epss",
+		strategy: StrategyLatest,
+		present:  func(v vulnerability.Vulnerability) bool { return !v.Epss.Date.IsZero() },
+		merge:    func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) { m.Epss = v.Epss },
+		time:     func(v vulnerability.Vulnerability) time.Time { return v.Epss.Date },
+	},
+	{
+		name:     "vendorSeverities",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.VendorSeverities) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, severity := range v.VendorSeverities {
+				if !containsVendorSeverity(m.VendorSeverities, severity) {
+					m.VendorSeverities = append(m.VendorSeverities, severity)
+				}
+			}
+		},
+	},
+	{
+		name:     "affected",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Affected) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, affected := range v.Affected {
+				if !containsAffected(m.Affected, affected) {
+					m.Affected = append(m.Affected, affected)
+				}
+			}
+		},
+	},
+	{
+		name:     "distributionFixes",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.DistributionFixes) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, fix := range v.DistributionFixes {
+				if !containsDistributionFix(m.DistributionFixes, fix) {
+					m.DistributionFixes = append(m.DistributionFixes, fix)
+				}
+			}
+		},
+	},
+	{
+		name:     "remediations",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Remediations) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, remediation := range v.Remediations {
+				if !containsRemediation(m.Remediations, remediation) {
+					m.Remediations = append(m.Remediations, remediation)
+				}
+			}
+		},
+	},
+	{
+		name:     "exploitability",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Exploitability) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, assessment := range v.Exploitability {
+				if !containsExploitability(m.Exploitability, assessment) {
+					m.Exploitability = append(m.Exploitability, assessment)
+				}
+			}
+		},
+	},
+	{
+		name:     "cwes",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Cwes) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, cwe := range v.Cwes {
+				if !containsCwe(m.Cwes, cwe) {
+					m.Cwes = append(m.Cwes, cwe)
+				}
+			}
+		},
+	},
+	{
+		name:     "techniques",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.Techniques) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, technique := range v.Techniques {
+				if !containsTechnique(m.Techniques, technique) {
+					m.Techniques = append(m.Techniques, technique)
+				}
+			}
+		},
+	},
+	{
+		name:     "references",
+		strategy: StrategyUnion,
+		present:  func(v vulnerability.Vulnerability) bool { return len(v.References) > 0 },
+		merge: func(m *vulnerability.Vulnerability, v vulnerability.Vulnerability) {
+			for _, reference := range v.References {
+				m.References = mergeReference(m.References, reference)
+			}
+		},
+	},
+}
+
+var fieldsByName = func() map[string]field {
+	byName := make(map[string]field)
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+	return byName
+}()
+
+// Fields returns the names of the fields the merge engine handles, which are
+// the keys accepted in Policy.Fields and by Result.Explain.
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func containsDescription(descriptions []vulnerability.Description, description vulnerability.Description) bool {
+	for _, existing := range descriptions {
+		if strings.EqualFold(existing.Lang, description.Lang) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsVendorSeverity(severities []vulnerability.VendorSeverity, severity vulnerability.VendorSeverity) bool {
+	for _, existing := range severities {
+		if strings.EqualFold(existing.Source, severity.Source) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAffected(products []vulnerability.AffectedProduct, product vulnerability.AffectedProduct) bool {
+	for _, existing := range products {
+		if strings.EqualFold(existing.Vendor, product.Vendor) && strings.EqualFold(existing.Product, product.Product) && existing.Ecosystem == product.Ecosystem {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDistributionFix(fixes []vulnerability.DistributionFix, fix vulnerability.DistributionFix) bool {
+	for _, existing := range fixes {
+		if existing.Distribution == fix.Distribution && existing.Release == fix.Release && existing.Package == fix.Package {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRemediation(remediations []vulnerability.Remediation, remediation vulnerability.Remediation) bool {
+	for _, existing := range remediations {
+		if existing.Source == remediation.Source && existing.Type == remediation.Type && existing.Description == remediation.Description && existing.Kb == remediation.Kb {
+			return true
+		}
+	}
+	return false
+}
+
+func containsExploitability(assessments []vulnerability.Exploitability, assessment vulnerability.Exploitability) bool {
+	for _, existing := range assessments {
+		if strings.EqualFold(existing.Source, assessment.Source) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCwe(cwes []vulnerability.Cwe, cwe vulnerability.Cwe) bool {
+	for _, existing := range cwes {
+		if strings.EqualFold(existing.Id, cwe.Id) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTechnique(techniques []vulnerability.Technique, technique vulnerability.Technique) bool {
+	for _, existing := range techniques {
+		if existing.Id == technique.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeReference adds the reference unless its URL is already listed, in
+// which case the tags of both are combined.
+func mergeReference(references []vulnerability.Reference, reference vulnerability.Reference) []vulnerability.Reference {
+	key := referenceKey(reference)
+	for i, existing := range references {
+		if referenceKey(existing) != key {
+			continue
+		}
+		for _, tag := range reference.Tags {
+			if !containsFold(existing.Tags, tag) {
+				references[i].Tags = append(references[i].Tags, tag)
+			}
+		}
+		return references
+	}
+	reference.Tags = append([]string{}, reference.Tags...)
+	return append(references, reference)
+}
+
+func referenceKey(reference vulnerability.Reference) string {
+	return strings.TrimSuffix(strings.ToLower(reference.Url.Host)+reference.Url.RequestURI(), "/")
+}
+
+func containsFold(values []string, value string) bool {
+	for _, existing := range values {
+		if strings.EqualFold(existing, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// Ingester records each source's view of a vulnerability and keeps the
+// vulnerability repository holding the merge of them all, rather than
+// whichever source was ingested last.
+type Ingester struct {
+	Observations    ObservationRepository
+	Vulnerabilities vulnerability.VulnerabilityRepository
+	Merger          *Merger
+	Now             func() time.Time
+}
+
+// Ingest stores what source says about the vulnerability, re-merges it with
+// the other sources and writes the result to the vulnerability repository.
+func (i Ingester) Ingest(ctx context.Context, source string, vuln vulnerability.Vulnerability) (Result, error) {
+	now := time.Now
+	if i.Now != nil {
+		now = i.Now
+	}
+	previous, err := i.Observations.Get(ctx, vuln.CveId)
+	if err != nil {
+		return Result{}, err
+	}
+	observation := Observation{Source: source, Received: now().UTC(), Vulnerability: vuln}
+	if err := i.Observations.Put(ctx, observation); err != nil {
+		return Result{}, err
+	}
+	return i.remerge(ctx, vuln.CveId, previous, true)
+}
+
+// Remerge merges the stored observations of a vulnerability again, for
+// example after the policy or a source has changed.
+func (i Ingester) Remerge(ctx context.Context, cveId string) (Result, error) {
+	observations, err := i.Observations.Get(ctx, cveId)
+	if err != nil {
+		return Result{}, err
+	}
+	return i.remerge(ctx, cveId, observations, true)
+}
+
+// Explain re-merges the stored observations without writing anything, to
+// report where each field of the stored vulnerability came from.
+func (i Ingester) Explain(ctx context.Context, cveId string) (Result, error) {
+	observations, err := i.Observations.Get(ctx, cveId)
+	if err != nil {
+		return Result{}, err
+	}
+	return i.remerge(ctx, cveId, observations, false)
+}
+
+// remerge merges the observations with what enrichers added to the stored
+// vulnerability: every field whose stored value differs from the merge of
+// the previous observations. Fields still as the sources left them are
+// not carried over, so a source can retract what it said.
+func (i Ingester) remerge(ctx context.Context, cveId string, previous []Observation, write bool) (Result, error) {
+	observations, err := i.Observations.Get(ctx, cveId)
+	if err != nil {
+		return Result{}, err
+	}
+	stored, err := i.Vulnerabilities.Get(ctx, cveId)
+	switch {
+	case errors.Is(err, vulnerability.ErrVulnerabilityNotFound):
+		stored = nil
+	case err != nil:
+		return Result{}, err
+	}
+	if stored != nil {
+		observations = append(observations, i.enrichment(*stored, previous))
+	}
+
+	result, err := i.Merger.Merge(observations)
+	if err != nil || !write {
+		return result, err
+	}
+	merged := result.Vulnerability
+	if stored == nil {
+		err = i.Vulnerabilities.Add(ctx, merged)
+	} else {
+		err = i.Vulnerabilities.Update(ctx, merged.CveId, &merged)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+func (i Ingester) enrichment(stored vulnerability.Vulnerability, previous []Observation) Observation {
+	before := vulnerability.Vulnerability{}
+	if result, err := i.Merger.Merge(previous); err == nil {
+		before = result.Vulnerability
+	}
+	kept := vulnerability.Vulnerability{CveId: stored.CveId}
+	for _, f := range fields {
+		if f.present(stored) && !sameField(f, stored, before) {
+			f.merge(&kept, stored)
+		}
+	}
+	return Observation{Source: SourceStored, Vulnerability: kept}
+}
+
+func sameField(f field, a vulnerability.Vulnerability, b vulnerability.Vulnerability) bool {
+	var fromA, fromB vulnerability.Vulnerability
+	if f.present(a) {
+		f.merge(&fromA, a)
+	}
+	if f.present(b) {
+		f.merge(&fromB, b)
+	}
+	return reflect.DeepEqual(fromA, fromB)
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/carbonrook/cvewatch-domain/domain/merge"
+)
+
+type MemoryRepository struct {
+	observations map[string]map[string]merge.Observation
+	lock         *sync.RWMutex
+}
+
+func (mr MemoryRepository) Put(ctx context.Context, observation merge.Observation) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	cveId := strings.ToUpper(observation.Vulnerability.CveId)
+	if _, ok := mr.observations[cveId]; !ok {
+		mr.observations[cveId] = make(map[string]merge.Observation)
+	}
+	mr.observations[cveId][observation.Source] = observation
+	return nil
+}
+
+func (mr MemoryRepository) Get(ctx context.Context, cveId string) ([]merge.Observation, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	observations := []merge.Observation{}
+	for _, observation := range mr.observations[strings.ToUpper(cveId)] {
+		observations = append(observations, observation)
+	}
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Source < observations[j].Source
+	})
+	return observations, nil
+}
+
+func (mr MemoryRepository) Delete(ctx context.Context, cveId string, source string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	delete(mr.observations[strings.ToUpper(cveId)], source)
+	return nil
+}
+
+func NewMemoryObservationRepository() (merge.ObservationRepository, error) {
+	return MemoryRepository{
+		observations: make(map[string]map[string]merge.Observation),
+		lock:         &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewMemoryObservationRepository() merge.ObservationRepository {
+	repo, err := NewMemoryObservationRepository()
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
@@ -0,0 +1,237 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var (
+	ErrUnknownField   = errors.New("the field is not known to the merge engine")
+	ErrNoObservations = errors.New("there are no observations to merge")
+	ErrCveIdMismatch  = errors.New("the observations are for different vulnerabilities")
+)
+
+const (
+	SourceCve5 = "cve5"
+	SourceNvd  = "nvd"
+	SourceGhsa = "ghsa"
+	SourceOsv  = "osv"
+	SourceCsaf = "csaf"
+	// SourceStored is the vulnerability as the repository holds it, so that
+	// values written there by enrichers rather than sources survive a
+	// re-merge. It always ranks last.
+	SourceStored = "stored"
+)
+
+type Strategy string
+
+const (
+	// StrategyPrecedence takes the value from the highest ranked source that
+	// has one.
+	StrategyPrecedence Strategy = "precedence"
+	// StrategyUnion combines the entries of every source, the higher ranked
+	// source winning when two entries share a key.
+	StrategyUnion Strategy = "union"
+	// StrategyLatest takes the most recent time any source reports.
+	StrategyLatest Strategy = "latest"
+)
+
+// Observation is what one source said about a vulnerability.
+type Observation struct {
+	Source        string                      `json:"source"`
+	Received      time.Time                   `json:"received"`
+	Vulnerability vulnerability.Vulnerability `json:"vulnerability"`
+}
+
+type Policy struct {
+	// Precedence ranks sources for every field, highest first. Sources not
+	// listed rank below those that are, in name order.
+	Precedence []string `json:"precedence"`
+	// Fields overrides Precedence for individual fields.
+	Fields map[string][]string `json:"fields,omitempty"`
+}
+
+// DefaultPolicy prefers the CNA's own CVE record, then NVD, then the package
+// ecosystem databases. For CVSS the CNA score is preferred over NVD's, and
+// for affected packages the ecosystem databases know best.
+func DefaultPolicy() Policy {
+	return Policy{
+		Precedence: []string{SourceCve5, SourceNvd, SourceGhsa, SourceOsv, SourceCsaf},
+		Fields: map[string][]string{
+			"affected": {SourceGhsa, SourceOsv, SourceCve5, SourceNvd, SourceCsaf},
+		},
+	}
+}
+
+func (p Policy) precedence(field string) []string {
+	if precedence, ok := p.Fields[field]; ok {
+		return precedence
+	}
+	return p.Precedence
+}
+
+// rank orders the observations by the field's precedence.
+func (p Policy) rank(field string, observations []Observation) []Observation {
+	positions := make(map[string]int)
+	for i, source := range p.precedence(field) {
+		positions[source] = i
+	}
+	ranked := append([]Observation{}, observations...)
+	sort.SliceStable(ranked, func(a, b int) bool {
+		aPosition, aRanked := positions[ranked[a].Source]
+		bPosition, bRanked := positions[ranked[b].Source]
+		switch {
+		case ranked[a].Source == SourceStored || ranked[b].Source == SourceStored:
+			return ranked[b].Source == SourceStored && ranked[a].Source != SourceStored
+		case aRanked && bRanked:
+			return aPosition < bPosition
+		case aRanked != bRanked:
+			return aRanked
+		}
+		return ranked[a].Source < ranked[b].Source
+	})
+	return ranked
+}
+
+// Provenance records where a merged field's value came from.
+type Provenance struct {
+	Field    string   `json:"field"`
+	Strategy Strategy `json:"strategy"`
+	// Sources contributed to the merged value, highest ranked first.
+	Sources []string `json:"sources"`
+	// Overridden sources had a value that lost to a higher ranked source.
+	Overridden []string `json:"overridden,omitempty"`
+}
+
+func (p Provenance) String() string {
+	if len(p.Sources) == 0 {
+		return fmt.Sprintf("%s: no source has a value", p.Field)
+	}
+	explanation := fmt.Sprintf("%s: %s of %s", p.Field, p.Strategy, strings.Join(p.Sources, ", "))
+	if len(p.Overridden) > 0 {
+		explanation += fmt.Sprintf(", overriding %s", strings.Join(p.Overridden, ", "))
+	}
+	return explanation
+}
+
+type Result struct {
+	Vulnerability vulnerability.Vulnerability `json:"vulnerability"`
+	Provenance    map[string]Provenance       `json:"provenance"`
+}
+
+// Explain describes where the merged value of field came from.
+func (r Result) Explain(field string) (string, error) {
+	provenance, ok := r.Provenance[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownField, field)
+	}
+	return provenance.String(), nil
+}
+
+// Sources returns the fields each source contributed to.
+func (r Result) Sources() map[string][]string {
+	contributions := make(map[string][]string)
+	for _, name := range Fields() {
+		for _, source := range r.Provenance[name].Sources {
+			contributions[source] = append(contributions[source], name)
+		}
+	}
+	return contributions
+}
+
+type Merger struct {
+	policy Policy
+}
+
+// Merge combines the observations of a single vulnerability. When a source
+// has several observations only the most recently received is used. The
+// result is the same whatever order the observations are given in.
+func (m *Merger) Merge(observations []Observation) (Result, error) {
+	if len(observations) == 0 {
+		return Result{}, ErrNoObservations
+	}
+
+	latest := make(map[string]Observation)
+	for _, observation := range observations {
+		if !strings.EqualFold(observation.Vulnerability.CveId, observations[0].Vulnerability.CveId) {
+			return Result{}, fmt.Errorf("%w: %s and %s", ErrCveIdMismatch, observations[0].Vulnerability.CveId, observation.Vulnerability.CveId)
+		}
+		existing, ok := latest[observation.Source]
+		if !ok || observation.Received.After(existing.Received) {
+			latest[observation.Source] = observation
+		}
+	}
+	current := make([]Observation, 0, len(latest))
+	for _, observation := range latest {
+		current = append(current, observation)
+	}
+
+	result := Result{
+		Vulnerability: vulnerability.Vulnerability{
+			CveId:      strings.ToUpper(observations[0].Vulnerability.CveId),
+			Cwes:       []vulnerability.Cwe{},
+			References: []vulnerability.Reference{},
+		},
+		Provenance: make(map[string]Provenance),
+	}
+	for _, f := range fields {
+		ranked := m.policy.rank(f.name, current)
+		provenance := Provenance{Field: f.name, Strategy: f.strategy, Sources: []string{}}
+		switch f.strategy {
+		case StrategyPrecedence:
+			for _, observation := range ranked {
+				if !f.present(observation.Vulnerability) {
+					continue
+				}
+				if len(provenance.Sources) == 0 {
+					f.merge(&result.Vulnerability, observation.Vulnerability)
+					provenance.Sources = append(provenance.Sources, observation.Source)
+				} else {
+					provenance.Overridden = append(provenance.Overridden, observation.Source)
+				}
+			}
+		case StrategyLatest:
+			var newest *Observation
+			for i, observation := range ranked {
+				if f.present(observation.Vulnerability) && (newest == nil || f.time(observation.Vulnerability).After(f.time(newest.Vulnerability))) {
+					newest = &ranked[i]
+				}
+			}
+			if newest != nil {
+				f.merge(&result.Vulnerability, newest.Vulnerability)
+				provenance.Sources = append(provenance.Sources, newest.Source)
+			}
+		case StrategyUnion:
+			for _, observation := range ranked {
+				if f.present(observation.Vulnerability) {
+					f.merge(&result.Vulnerability, observation.Vulnerability)
+					provenance.Sources = append(provenance.Sources, observation.Source)
+				}
+			}
+		}
+		result.Provenance[f.name] = provenance
+	}
+	return result, nil
+}
+
+func NewMerger(policy Policy) (*Merger, error) {
+	for name := range policy.Fields {
+		if _, ok := fieldsByName[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+	}
+	return &Merger{policy: policy}, nil
+}
+
+func MustNewMerger(policy Policy) *Merger {
+	merger, err := NewMerger(policy)
+	if err != nil {
+		panic(err)
+	}
+	return merger
+}
@@ -0,0 +1,231 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/distro"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+func testReference(t *testing.T, raw string, tags ...string) vulnerability.Reference {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse url: %s", err)
+	}
+	return vulnerability.Reference{Url: *parsed, Tags: tags}
+}
+
+func testObservations(t *testing.T) []Observation {
+	return []Observation{
+		{
+			Source:   SourceNvd,
+			Received: time.Date(2021, 12, 14, 0, 0, 0, 0, time.UTC),
+			Vulnerability: vulnerability.Vulnerability{
+				CveId:       "CVE-2021-44228",
+				Description: "Apache Log4j2 2.0-beta9 through 2.15.0 JNDI features do not protect against attacker controlled LDAP endpoints.",
+				Descriptions: []vulnerability.Description{
+					{Lang: "en", Value: "Apache Log4j2 2.0-beta9 through 2.15.0 JNDI features do not protect against attacker controlled LDAP endpoints."},
+					{Lang: "es", Value: "Las características JNDI de Apache Log4j2 no protegen contra LDAP controlado por el atacante."},
+				},
+				LastModified: time.Date(2023, 4, 3, 0, 0, 0, 0, time.UTC),
+				Cvss3:        vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0},
+				Cvss2:        vulnerability.Cvss2{CvssVector: "AV:N/AC:M/Au:N/C:C/I:C/A:C", BaseScore: 9.3},
+				Cwes:         []vulnerability.Cwe{{Id: "CWE-502"}, {Id: "CWE-917"}},
+				References: []vulnerability.Reference{
+					testReference(t, "https://logging.apache.org/log4j/2.x/security.html", "Vendor Advisory"),
+					testReference(t, "http://www.openwall.com/lists/oss-security/2021/12/10/1", "Mailing List"),
+				},
+			},
+		},
+		{
+			Source:   SourceCve5,
+			Received: time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC),
+			Vulnerability: vulnerability.Vulnerability{
+				CveId:        "CVE-2021-44228",
+				Assigner:     "security@apache.org",
+				Descriptions: []vulnerability.Description{{Lang: "es", Value: "Inyección remota de código en Log4j."}},
+				LastModified: time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC),
+				Cvss3:        vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0, BaseSeverity: "CRITICAL"},
+				Cwes:         []vulnerability.Cwe{{Id: "CWE-20"}, {Id: "cwe-502"}},
+				References: []vulnerability.Reference{
+					testReference(t, "https://logging.apache.org/log4j/2.x/security.html", "Patch"),
+				},
+			},
+		},
+		{
+			Source:   SourceGhsa,
+			Received: time.Date(2021, 12, 11, 0, 0, 0, 0, time.UTC),
+			Vulnerability: vulnerability.Vulnerability{
+				CveId:       "CVE-2021-44228",
+				Description: "Remote code injection in Log4j",
+				Affected:    []vulnerability.AffectedProduct{{Ecosystem: "Maven", Product: "org.apache.logging.log4j:log4j-core"}},
+			},
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merger := MustNewMerger(DefaultPolicy())
+	observations := testObservations(t)
+	result, err := merger.Merge(observations)
+	if err != nil {
+		t.Fatalf("failed to merge: %s", err)
+	}
+	merged := result.Vulnerability
+
+	if merged.Cvss3.BaseSeverity != "CRITICAL" || merged.Cvss2.BaseScore != 9.3 || merged.Assigner != "security@apache.org" {
+		t.Errorf("failed to apply precedence, got %+v", merged)
+	}
+	if merged.Description != observations[0].Vulnerability.Description {
+		t.Errorf("failed to fall back to the next source for description, got %q", merged.Description)
+	}
+	if !merged.LastModified.Equal(time.Date(2023, 4, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("failed to take the latest modified time, got %s", merged.LastModified)
+	}
+	if !reflect.DeepEqual(merged.Languages(), []string{"es", "en"}) || merged.DescriptionIn("es").Value != "Inyección remota de código en Log4j." {
+		t.Errorf("failed to union descriptions by language, got %+v", merged.Descriptions)
+	}
+	if len(merged.Cwes) != 3 || merged.Cwes[0].Id != "CWE-20" {
+		t.Errorf("failed to union cwes, got %+v", merged.Cwes)
+	}
+	if len(merged.References) != 2 || !reflect.DeepEqual(merged.References[0].Tags, []string{"Patch", "Vendor Advisory"}) {
+		t.Errorf("failed to union references, got %+v", merged.References)
+	}
+
+	explanation, err := result.Explain("cvss3")
+	if err != nil || explanation != "cvss3: precedence of cve5, overriding nvd" {
+		t.Errorf("failed to explain cvss3, got %q: %v", explanation, err)
+	}
+	if !reflect.DeepEqual(result.Provenance["description"].Sources, []string{SourceNvd}) {
+		t.Errorf("failed to record description provenance, got %+v", result.Provenance["description"])
+	}
+	if _, err := result.Explain("colour"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("failed to reject unknown field, got %v", err)
+	}
+
+	// The same observations in another order merge identically.
+	reversed := []Observation{observations[2], observations[1], observations[0]}
+	again, err := merger.Merge(reversed)
+	if err != nil || !reflect.DeepEqual(again, result) {
+		t.Errorf("failed to merge independently of order")
+	}
+}
+
+func TestMergePolicy(t *testing.T) {
+	if _, err := NewMerger(Policy{Fields: map[string][]string{"colour": {SourceNvd}}}); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("failed to reject policy for unknown field, got %v", err)
+	}
+
+	policy := DefaultPolicy()
+	policy.Fields["cvss3"] = []string{SourceNvd, SourceCve5}
+	result, err := MustNewMerger(policy).Merge(testObservations(t))
+	if err != nil {
+		t.Fatalf("failed to merge: %s", err)
+	}
+	if result.Vulnerability.Cvss3.BaseSeverity != "" {
+		t.Errorf("failed to prefer nvd cvss, got %+v", result.Vulnerability.Cvss3)
+	}
+}
+
+// observationMap stands in for the memory repository, which cannot be
+// imported here without a cycle.
+type observationMap map[string]Observation
+
+func (om observationMap) Put(ctx context.Context, observation Observation) error {
+	om[observation.Source] = observation
+	return nil
+}
+
+func (om observationMap) Get(ctx context.Context, cveId string) ([]Observation, error) {
+	observations := []Observation{}
+	for _, observation := range om {
+		observations = append(observations, observation)
+	}
+	return observations, nil
+}
+
+func (om observationMap) Delete(ctx context.Context, cveId string, source string) error {
+	delete(om, source)
+	return nil
+}
+
+func TestIngester(t *testing.T) {
+	ctx := context.Background()
+	ingester := Ingester{
+		Observations:    observationMap{},
+		Vulnerabilities: memory.MustNewMemoryVulnerabilityRepository(),
+		Merger:          MustNewMerger(DefaultPolicy()),
+	}
+	for _, observation := range testObservations(t) {
+		if _, err := ingester.Ingest(ctx, observation.Source, observation.Vulnerability); err != nil {
+			t.Fatalf("failed to ingest %s: %s", observation.Source, err)
+		}
+	}
+
+	stored, err := ingester.Vulnerabilities.Get(ctx, "CVE-2021-44228")
+	if err != nil {
+		t.Fatalf("failed to get merged vulnerability: %s", err)
+	}
+	if stored.Assigner != "security@apache.org" || len(stored.Affected) != 1 || len(stored.Cwes) != 3 {
+		t.Errorf("failed to store merged vulnerability, got %+v", stored)
+	}
+
+	// A later GHSA update replaces only GHSA's contribution.
+	if _, err := ingester.Ingest(ctx, SourceGhsa, vulnerability.Vulnerability{CveId: "CVE-2021-44228"}); err != nil {
+		t.Fatalf("failed to ingest update: %s", err)
+	}
+	stored, _ = ingester.Vulnerabilities.Get(ctx, "CVE-2021-44228")
+	if len(stored.Affected) != 0 || stored.Assigner != "security@apache.org" {
+		t.Errorf("failed to replace source observation, got %+v", stored)
+	}
+}
+
+func TestIngesterKeepsEnrichments(t *testing.T) {
+	ctx := context.Background()
+	ingester := Ingester{
+		Observations:    observationMap{},
+		Vulnerabilities: memory.MustNewMemoryVulnerabilityRepository(),
+		Merger:          MustNewMerger(DefaultPolicy()),
+	}
+	observations := testObservations(t)
+	if _, err := ingester.Ingest(ctx, SourceNvd, observations[0].Vulnerability); err != nil {
+		t.Fatalf("failed to ingest: %s", err)
+	}
+
+	fix := vulnerability.DistributionFix{Distribution: "debian", Release: "bullseye", Package: "apache-log4j2", Status: vulnerability.FixStatusFixed, FixedVersion: "2.15.0-1~deb11u1"}
+	if err := distro.Apply(ctx, ingester.Vulnerabilities, []distro.Record{{CveId: "CVE-2021-44228", Fixes: []vulnerability.DistributionFix{fix}}}); err != nil {
+		t.Fatalf("failed to apply distribution fixes: %s", err)
+	}
+
+	result, err := ingester.Ingest(ctx, SourceCve5, observations[1].Vulnerability)
+	if err != nil {
+		t.Fatalf("failed to ingest again: %s", err)
+	}
+	stored, _ := ingester.Vulnerabilities.Get(ctx, "CVE-2021-44228")
+	if !reflect.DeepEqual(stored.DistributionFixes, []vulnerability.DistributionFix{fix}) {
+		t.Errorf("failed to keep distribution fixes, got %+v", stored.DistributionFixes)
+	}
+	if stored.Assigner != "security@apache.org" {
+		t.Errorf("failed to merge the new observation, got %+v", stored)
+	}
+	if !reflect.DeepEqual(result.Provenance["distributionFixes"].Sources, []string{SourceStored}) {
+		t.Errorf("failed to attribute distribution fixes to the stored record, got %+v", result.Provenance["distributionFixes"])
+	}
+	if !reflect.DeepEqual(result.Provenance["cvss3"].Sources, []string{SourceCve5}) || len(result.Provenance["cvss3"].Overridden) != 1 {
+		t.Errorf("failed to leave source fields to the sources, got %+v", result.Provenance["cvss3"])
+	}
+
+	if _, err := ingester.Remerge(ctx, "CVE-2021-44228"); err != nil {
+		t.Fatalf("failed to remerge: %s", err)
+	}
+	stored, _ = ingester.Vulnerabilities.Get(ctx, "CVE-2021-44228")
+	if len(stored.DistributionFixes) != 1 {
+		t.Errorf("failed to keep distribution fixes on remerge, got %+v", stored.DistributionFixes)
+	}
+}
@@ -0,0 +1,17 @@
+package merge
+
+import (
+	"context"
+)
+
+// ObservationRepository keeps the latest observation from each source so a
+// vulnerability can be re-merged when any one of them changes.
+type ObservationRepository interface {
+	// Put stores the observation, replacing any earlier one from the same
+	// source for the same vulnerability.
+	Put(ctx context.Context, observation Observation) error
+	// Get returns the observations of a vulnerability, an empty slice when
+	// there are none.
+	Get(ctx context.Context, cveId string) ([]Observation, error)
+	Delete(ctx context.Context, cveId string, source string) error
+}
@@ -0,0 +1,278 @@
+package nvd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/product"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type nvdLangString struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+type nvdCvssData struct {
+	Version               string  `json:"version"`
+	VectorString          string  `json:"vectorString"`
+	AttackVector          string  `json:"attackVector"`
+	AttackComplexity      string  `json:"attackComplexity"`
+	PrivilegesRequired    string  `json:"privilegesRequired"`
+	UserInteraction       string  `json:"userInteraction"`
+	Scope                 string  `json:"scope"`
+	AccessVector          string  `json:"accessVector"`
+	AccessComplexity      string  `json:"accessComplexity"`
+	Authentication        string  `json:"authentication"`
+	ConfidentialityImpact string  `json:"confidentialityImpact"`
+	IntegrityImpact       string  `json:"integrityImpact"`
+	AvailabilityImpact    string  `json:"availabilityImpact"`
+	BaseScore             float64 `json:"baseScore"`
+	BaseSeverity          string  `json:"baseSeverity"`
+}
+
+type nvdCvssMetric struct {
+	Source                  string      `json:"source"`
+	Type                    string      `json:"type"`
+	CvssData                nvdCvssData `json:"cvssData"`
+	BaseSeverity            string      `json:"baseSeverity"`
+	ExploitabilityScore     float64     `json:"exploitabilityScore"`
+	ImpactScore             float64     `json:"impactScore"`
+	AcInsufInfo             bool        `json:"acInsufInfo"`
+	ObtainAllPrivilege      bool        `json:"obtainAllPrivilege"`
+	ObtainUserPrivilege     bool        `json:"obtainUserPrivilege"`
+	ObtainOtherPrivilege    bool        `json:"obtainOtherPrivilege"`
+	UserInteractionRequired bool        `json:"userInteractionRequired"`
+}
+
+type nvdCve struct {
+	Id                 string          `json:"id"`
+	SourceIdentifier   string          `json:"sourceIdentifier"`
+	Published          string          `json:"published"`
+	LastModified       string          `json:"lastModified"`
+	CisaExploitAdd     string          `json:"cisaExploitAdd"`
+	CisaActionDue      string          `json:"cisaActionDue"`
+	CisaRequiredAction string          `json:"cisaRequiredAction"`
+	Descriptions       []nvdLangString `json:"descriptions"`
+	Metrics            struct {
+		CvssMetricV40 []nvdCvssMetric `json:"cvssMetricV40"`
+		CvssMetricV31 []nvdCvssMetric `json:"cvssMetricV31"`
+		CvssMetricV30 []nvdCvssMetric `json:"cvssMetricV30"`
+		CvssMetricV2  []nvdCvssMetric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+	Weaknesses []struct {
+		Description []nvdLangString `json:"description"`
+	} `json:"weaknesses"`
+	Configurations []struct {
+		Nodes []struct {
+			CpeMatch []struct {
+				Vulnerable bool   `json:"vulnerable"`
+				Criteria   string `json:"criteria"`
+			} `json:"cpeMatch"`
+		} `json:"nodes"`
+	} `json:"configurations"`
+	References []struct {
+		Url    string   `json:"url"`
+		Source string   `json:"source"`
+		Tags   []string `json:"tags"`
+	} `json:"references"`
+}
+
+// ReadNvd reads a response from the NVD CVE API 2.0
+// (https://services.nvd.nist.gov/rest/json/cves/2.0) and returns its
+// vulnerabilities. Descriptions in every language are kept. Entries are
+// decoded one at a time so a full result page is never held twice.
+func ReadNvd(r io.Reader) ([]vulnerability.Vulnerability, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, fmt.Errorf("failed to read nvd response: %s", err)
+	}
+
+	vulns := []vulnerability.Vulnerability{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nvd response: %s", err)
+		}
+		if token != "vulnerabilities" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return nil, fmt.Errorf("failed to read nvd response: %s", err)
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return nil, fmt.Errorf("failed to read nvd vulnerabilities: %s", err)
+		}
+		for decoder.More() {
+			var item struct {
+				Cve nvdCve `json:"cve"`
+			}
+			if err := decoder.Decode(&item); err != nil {
+				return nil, fmt.Errorf("failed to decode nvd vulnerability: %s", err)
+			}
+			vulns = append(vulns, item.Cve.vulnerability())
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, fmt.Errorf("failed to read nvd vulnerabilities: %s", err)
+		}
+	}
+	return vulns, nil
+}
+
+func (cve nvdCve) vulnerability() vulnerability.Vulnerability {
+	vuln := vulnerability.Vulnerability{
+		CveId:         cve.Id,
+		Assigner:      cve.SourceIdentifier,
+		PublishedDate: parseTime(cve.Published),
+		LastModified:  parseTime(cve.LastModified),
+		Cwes:          []vulnerability.Cwe{},
+		References:    []vulnerability.Reference{},
+	}
+	for _, description := range cve.Descriptions {
+		vuln.AddDescription(description.Lang, description.Value)
+	}
+
+	if metric, ok := primary(cve.Metrics.CvssMetricV40); ok {
+		vuln.Cvss4 = vulnerability.Cvss4{
+			Version:      metric.CvssData.Version,
+			CvssVector:   metric.CvssData.VectorString,
+			BaseScore:    metric.CvssData.BaseScore,
+			BaseSeverity: metric.CvssData.BaseSeverity,
+		}
+	}
+	metric, ok := primary(cve.Metrics.CvssMetricV31)
+	if !ok {
+		metric, ok = primary(cve.Metrics.CvssMetricV30)
+	}
+	if ok {
+		data := metric.CvssData
+		vuln.Cvss3 = vulnerability.Cvss3{
+			Version:               data.Version,
+			CvssVector:            data.VectorString,
+			AttackVector:          data.AttackVector,
+			AttackComplexity:      data.AttackComplexity,
+			PrivilegesRequired:    data.PrivilegesRequired,
+			UserInteraction:       data.UserInteraction,
+			Scope:                 data.Scope,
+			ConfidentialityImpact: data.ConfidentialityImpact,
+			IntegrityImpact:       data.IntegrityImpact,
+			AvailabilityImpact:    data.AvailabilityImpact,
+			BaseScore:             data.BaseScore,
+			BaseSeverity:          data.BaseSeverity,
+		}
+		vuln.BaseMetric3 = vulnerability.BaseMetric3{ExploitabilityScore: metric.ExploitabilityScore, ImpactScore: metric.ImpactScore}
+	}
+	if metric, ok := primary(cve.Metrics.CvssMetricV2); ok {
+		data := metric.CvssData
+		vuln.Cvss2 = vulnerability.Cvss2{
+			Version:               data.Version,
+			CvssVector:            data.VectorString,
+			AccessVector:          data.AccessVector,
+			AccessComplexity:      data.AccessComplexity,
+			Authentication:        data.Authentication,
+			ConfidentialityImpact: data.ConfidentialityImpact,
+			IntegrityImpact:       data.IntegrityImpact,
+			AvailabilityImpact:    data.AvailabilityImpact,
+			BaseScore:             data.BaseScore,
+		}
+		vuln.BaseMetric2 = vulnerability.BaseMetric2{
+			Severity:                metric.BaseSeverity,
+			ExploitabilityScore:     metric.ExploitabilityScore,
+			ImpactScore:             metric.ImpactScore,
+			AcInsuffInfo:            metric.AcInsufInfo,
+			ObtainAllPrivilege:      metric.ObtainAllPrivilege,
+			ObtainUserPrivilege:     metric.ObtainUserPrivilege,
+			ObtainOtherPrivilege:    metric.ObtainOtherPrivilege,
+			UserInteractionRequired: metric.UserInteractionRequired,
+		}
+	}
+
+	if cve.CisaExploitAdd != "" {
+		vuln.Kev = vulnerability.Kev{
+			DateAdded:      parseTime(cve.CisaExploitAdd),
+			DueDate:        parseTime(cve.CisaActionDue),
+			RequiredAction: cve.CisaRequiredAction,
+		}
+	}
+
+	seenCwes := make(map[string]bool)
+	for _, weakness := range cve.Weaknesses {
+		for _, description := range weakness.Description {
+			// NVD-CWE-Other and NVD-CWE-noinfo are placeholders, not CWEs.
+			if !strings.HasPrefix(description.Value, "CWE-") || seenCwes[description.Value] {
+				continue
+			}
+			seenCwes[description.Value] = true
+			vuln.Cwes = append(vuln.Cwes, vulnerability.Cwe{Id: description.Value})
+		}
+	}
+
+	seenCpes := make(map[string]bool)
+	for _, configuration := range cve.Configurations {
+		for _, node := range configuration.Nodes {
+			for _, match := range node.CpeMatch {
+				if !match.Vulnerable || seenCpes[match.Criteria] {
+					continue
+				}
+				cpe, err := product.ParseCpe(match.Criteria)
+				if err != nil {
+					continue
+				}
+				seenCpes[match.Criteria] = true
+				vuln.Affected = append(vuln.Affected, vulnerability.AffectedProduct{Vendor: cpe.Vendor, Product: cpe.Product, Cpe: match.Criteria})
+			}
+		}
+	}
+
+	for _, reference := range cve.References {
+		parsed, err := url.Parse(reference.Url)
+		if err != nil {
+			continue
+		}
+		tags := reference.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		vuln.References = append(vuln.References, vulnerability.Reference{Url: *parsed, Name: reference.Url, Source: reference.Source, Tags: tags})
+	}
+	return vuln
+}
+
+// primary picks NVD's own Primary score, falling back to the first listed.
+func primary(metrics []nvdCvssMetric) (nvdCvssMetric, bool) {
+	for _, metric := range metrics {
+		if metric.Type == "Primary" {
+			return metric, true
+		}
+	}
+	if len(metrics) > 0 {
+		return metrics[0], true
+	}
+	return nvdCvssMetric{}, false
+}
+
+func parseTime(value string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.000", time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package nvd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testResponse = `{
+	"resultsPerPage": 1,
+	"startIndex": 0,
+	"totalResults": 1,
+	"format": "NVD_CVE",
+	"version": "2.0",
+	"timestamp": "2024-01-05T11:31:04.523",
+	"vulnerabilities": [
+		{
+			"cve": {
+				"id": "CVE-2021-44228",
+				"sourceIdentifier": "security@apache.org",
+				"published": "2021-12-10T10:15:09.143",
+				"lastModified": "2023-11-07T03:39:36.747",
+				"vulnStatus": "Modified",
+				"cisaExploitAdd": "2021-12-10",
+				"cisaActionDue": "2021-12-24",
+				"cisaRequiredAction": "For all affected software assets for which updates exist, the only acceptable remediation actions are: 1) Apply updates; OR 2) remove affected assets from agency networks.",
+				"descriptions": [
+					{"lang": "en", "value": "Apache Log4j2 2.0-beta9 through 2.15.0 JNDI features used in configuration, log messages, and parameters do not protect against attacker controlled LDAP and other JNDI related endpoints."},
+					{"lang": "es", "value": "Las características JNDI de Apache Log4j2 2.0-beta9 hasta 2.15.0 no protegen contra LDAP controlado por el atacante y otros endpoints relacionados con JNDI."}
+				],
+				"metrics": {
+					"cvssMetricV31": [
+						{"source": "nvd@nist.gov", "type": "Primary", "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", "attackVector": "NETWORK", "attackComplexity": "LOW", "privilegesRequired": "NONE", "userInteraction": "NONE", "scope": "CHANGED", "confidentialityImpact": "HIGH", "integrityImpact": "HIGH", "availabilityImpact": "HIGH", "baseScore": 10.0, "baseSeverity": "CRITICAL"}, "exploitabilityScore": 3.9, "impactScore": 6.0}
+					],
+					"cvssMetricV2": [
+						{"source": "nvd@nist.gov", "type": "Primary", "cvssData": {"version": "2.0", "vectorString": "AV:N/AC:M/Au:N/C:C/I:C/A:C", "accessVector": "NETWORK", "accessComplexity": "MEDIUM", "authentication": "NONE", "confidentialityImpact": "COMPLETE", "integrityImpact": "COMPLETE", "availabilityImpact": "COMPLETE", "baseScore": 9.3}, "baseSeverity": "HIGH", "exploitabilityScore": 8.6, "impactScore": 10.0, "acInsufInfo": false, "obtainAllPrivilege": false, "obtainUserPrivilege": false, "obtainOtherPrivilege": false, "userInteractionRequired": false}
+					]
+				},
+				"weaknesses": [
+					{"source": "security@apache.org", "type": "Primary", "description": [{"lang": "en", "value": "CWE-20"}, {"lang": "en", "value": "CWE-917"}]},
+					{"source": "nvd@nist.gov", "type": "Secondary", "description": [{"lang": "en", "value": "CWE-917"}, {"lang": "en", "value": "NVD-CWE-noinfo"}]}
+				],
+				"configurations": [
+					{"nodes": [{"operator": "OR", "negate": false, "cpeMatch": [
+						{"vulnerable": true, "criteria": "cpe:2.3:a:apache:log4j:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.13.0", "versionEndExcluding": "2.15.0", "matchCriteriaId": "5C8B2F0A-0F8B-4F08-9D38-E6F0E6E1A0F6"},
+						{"vulnerable": true, "criteria": "cpe:2.3:a:apache:log4j:*:*:*:*:*:*:*:*", "versionStartIncluding": "2.0.1", "versionEndExcluding": "2.3.1", "matchCriteriaId": "03FA5E81-F9C0-403E-8A4B-E4284E4E7B72"},
+						{"vulnerable": false, "criteria": "cpe:2.3:o:debian:debian_linux:9.0:*:*:*:*:*:*:*", "matchCriteriaId": "DEECE5FC-CACF-4496-A3E7-164736409252"}
+					]}]}
+				],
+				"references": [
+					{"url": "https://logging.apache.org/log4j/2.x/security.html", "source": "security@apache.org", "tags": ["Release Notes", "Vendor Advisory"]},
+					{"url": "http://www.openwall.com/lists/oss-security/2021/12/10/1", "source": "security@apache.org"}
+				]
+			}
+		}
+	]
+}`
+
+func TestReadNvd(t *testing.T) {
+	vulns, err := ReadNvd(strings.NewReader(testResponse))
+	if err != nil {
+		t.Fatalf("failed to read nvd response: %s", err)
+	}
+	if len(vulns) != 1 {
+		t.Fatalf("failed to read nvd response, got %d vulnerabilities", len(vulns))
+	}
+	vuln := vulns[0]
+
+	if !reflect.DeepEqual(vuln.Languages(), []string{"en", "es"}) || !strings.HasPrefix(vuln.Description, "Apache Log4j2") {
+		t.Errorf("failed to keep all descriptions, got %+v", vuln.Descriptions)
+	}
+	if !vuln.PublishedDate.Equal(time.Date(2021, 12, 10, 10, 15, 9, 143000000, time.UTC)) {
+		t.Errorf("failed to parse published date, got %s", vuln.PublishedDate)
+	}
+	if vuln.Cvss3.BaseScore != 10.0 || vuln.Cvss3.Scope != "CHANGED" || vuln.BaseMetric3.ExploitabilityScore != 3.9 {
+		t.Errorf("failed to read cvss3, got %+v", vuln.Cvss3)
+	}
+	if vuln.Cvss2.BaseScore != 9.3 || vuln.BaseMetric2.Severity != "HIGH" {
+		t.Errorf("failed to read cvss2, got %+v", vuln.Cvss2)
+	}
+	if !vuln.Kev.Listed() || !vuln.Kev.DueDate.Equal(time.Date(2021, 12, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("failed to read kev, got %+v", vuln.Kev)
+	}
+	if len(vuln.Cwes) != 2 || vuln.Cwes[1].Id != "CWE-917" {
+		t.Errorf("failed to read cwes, got %+v", vuln.Cwes)
+	}
+	if len(vuln.Affected) != 1 || vuln.Affected[0].Vendor != "apache" || vuln.Affected[0].Product != "log4j" {
+		t.Errorf("failed to read affected products, got %+v", vuln.Affected)
+	}
+	if len(vuln.References) != 2 || len(vuln.References[0].Tags) != 2 || vuln.References[1].Tags == nil {
+		t.Errorf("failed to read references, got %+v", vuln.References)
+	}
+}
@@ -0,0 +1,278 @@
+package priority
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var (
+	ErrNegativeWeight = errors.New("priority weights must not be negative")
+	ErrNoWeights      = errors.New("at least one priority weight must be positive")
+)
+
+const (
+	FactorCvss            = "cvss"
+	FactorExploit         = "exploit"
+	FactorKev             = "kev"
+	FactorEpss            = "epss"
+	FactorMentionVolume   = "mentionVolume"
+	FactorMentionVelocity = "mentionVelocity"
+)
+
+type Weights struct {
+	Cvss            float64 `json:"cvss"`
+	Exploit         float64 `json:"exploit"`
+	Kev             float64 `json:"kev"`
+	Epss            float64 `json:"epss"`
+	MentionVolume   float64 `json:"mentionVolume"`
+	MentionVelocity float64 `json:"mentionVelocity"`
+}
+
+type Config struct {
+	Weights Weights `json:"weights"`
+	// CveTopicName is the indicator topic that carries CVE mentions. When
+	// empty any mention whose value equals the CVE ID is counted.
+	CveTopicName string `json:"cveTopicName"`
+	// VolumeSaturation is the number of mentions at which the volume factor
+	// reaches its maximum. Volume is scored on a logarithmic scale.
+	VolumeSaturation int `json:"volumeSaturation"`
+	// VelocityWindow is the trailing window whose mentions are compared with
+	// the window before it to measure velocity, and VelocitySaturation the
+	// growth between them, as a multiple, at which the velocity factor
+	// reaches its maximum. Steady or falling mentions score nothing.
+	VelocityWindow     time.Duration `json:"velocityWindow"`
+	VelocitySaturation int           `json:"velocitySaturation"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Weights: Weights{
+			Cvss:            0.30,
+			Exploit:         0.15,
+			Kev:             0.20,
+			Epss:            0.20,
+			MentionVolume:   0.075,
+			MentionVelocity: 0.075,
+		},
+		CveTopicName:       "cve",
+		VolumeSaturation:   50,
+		VelocityWindow:     24 * time.Hour,
+		VelocitySaturation: 10,
+	}
+}
+
+type Factor struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+	Explanation  string  `json:"explanation"`
+}
+
+type Priority struct {
+	CveId   string   `json:"cveId"`
+	Score   float64  `json:"score"`
+	Factors []Factor `json:"factors"`
+}
+
+func (p Priority) Factor(name string) (Factor, bool) {
+	for _, factor := range p.Factors {
+		if factor.Name == name {
+			return factor, true
+		}
+	}
+	return Factor{}, false
+}
+
+type Prioritiser struct {
+	Config Config
+}
+
+// Score combines the factors into a 0-100 score. Each factor is normalised to
+// 0-1 and contributes its share of the total weight.
+func (p Prioritiser) Score(vuln vulnerability.Vulnerability, indicators []indicator.Indicator, now time.Time) Priority {
+	mentions := p.mentionsOf(vuln.CveId, indicators)
+
+	factors := []Factor{
+		p.cvssFactor(vuln),
+		p.exploitFactor(vuln),
+		p.kevFactor(vuln),
+		p.epssFactor(vuln),
+		p.volumeFactor(mentions),
+		p.velocityFactor(mentions, now),
+	}
+
+	totalWeight := 0.0
+	for _, factor := range factors {
+		totalWeight += factor.Weight
+	}
+
+	score := 0.0
+	for i := range factors {
+		if totalWeight > 0 {
+			factors[i].Contribution = 100 * factors[i].Value * factors[i].Weight / totalWeight
+		}
+		score += factors[i].Contribution
+	}
+
+	return Priority{
+		CveId:   vuln.CveId,
+		Score:   math.Round(score*100) / 100,
+		Factors: factors,
+	}
+}
+
+func (p Prioritiser) mentionsOf(cveId string, indicators []indicator.Indicator) []indicator.Indicator {
+	collection := indicator.IndicatorCollection{Indicators: indicators}
+	if p.Config.CveTopicName != "" {
+		return collection.Mentioning(indicator.MustNewMention(p.Config.CveTopicName, []byte(cveId))).Indicators
+	}
+
+	matches := []indicator.Indicator{}
+	for _, i := range indicators {
+		for _, mention := range i.Mentions {
+			if strings.EqualFold(mention.Mention, cveId) {
+				matches = append(matches, i)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func (p Prioritiser) cvssFactor(vuln vulnerability.Vulnerability) Factor {
+	factor := Factor{Name: FactorCvss, Weight: p.Config.Weights.Cvss}
+	switch {
+	case vuln.Cvss4.CvssVector != "":
+		factor.Value = vuln.Cvss4.BaseScore / 10
+		factor.Explanation = fmt.Sprintf("cvss4 base score %.1f", vuln.Cvss4.BaseScore)
+	case vuln.Cvss3.CvssVector != "":
+		factor.Value = vuln.Cvss3.BaseScore / 10
+		factor.Explanation = fmt.Sprintf("cvss3 base score %.1f", vuln.Cvss3.BaseScore)
+	case vuln.Cvss2.CvssVector != "":
+		factor.Value = vuln.Cvss2.BaseScore / 10
+		factor.Explanation = fmt.Sprintf("cvss2 base score %.1f", vuln.Cvss2.BaseScore)
+	default:
+		factor.Explanation = "no cvss score available"
+	}
+	return factor
+}
+
+func (p Prioritiser) exploitFactor(vuln vulnerability.Vulnerability) Factor {
+	factor := Factor{Name: FactorExploit, Weight: p.Config.Weights.Exploit, Explanation: "no public exploit referenced"}
+	if vuln.ExploitAvailable() {
+		factor.Value = 1
+		factor.Explanation = "public exploit referenced"
+	}
+	return factor
+}
+
+func (p Prioritiser) kevFactor(vuln vulnerability.Vulnerability) Factor {
+	factor := Factor{Name: FactorKev, Weight: p.Config.Weights.Kev, Explanation: "not in the known exploited vulnerabilities catalog"}
+	if vuln.Kev.Listed() {
+		factor.Value = 1
+		factor.Explanation = fmt.Sprintf("added to the known exploited vulnerabilities catalog on %s", vuln.Kev.DateAdded.Format("2006-01-02"))
+	}
+	return factor
+}
+
+func (p Prioritiser) epssFactor(vuln vulnerability.Vulnerability) Factor {
+	factor := Factor{Name: FactorEpss, Weight: p.Config.Weights.Epss, Explanation: "no epss score available"}
+	if !vuln.Epss.Date.IsZero() || vuln.Epss.Score > 0 {
+		factor.Value = clamp(vuln.Epss.Score)
+		factor.Explanation = fmt.Sprintf("epss probability %.4f (percentile %.4f)", vuln.Epss.Score, vuln.Epss.Percentile)
+	}
+	return factor
+}
+
+func (p Prioritiser) volumeFactor(mentions []indicator.Indicator) Factor {
+	factor := Factor{
+		Name:        FactorMentionVolume,
+		Weight:      p.Config.Weights.MentionVolume,
+		Explanation: fmt.Sprintf("%d indicators mention the vulnerability", len(mentions)),
+	}
+	if len(mentions) > 0 && p.Config.VolumeSaturation > 0 {
+		factor.Value = clamp(math.Log1p(float64(len(mentions))) / math.Log1p(float64(p.Config.VolumeSaturation)))
+	}
+	return factor
+}
+
+func (p Prioritiser) velocityFactor(mentions []indicator.Indicator, now time.Time) Factor {
+	window := p.Config.VelocityWindow
+	recent, previous := 0, 0
+	for _, mention := range mentions {
+		age := now.Sub(mention.CreatedDate)
+		switch {
+		case age < 0:
+			continue
+		case age <= window:
+			recent++
+		case age <= 2*window:
+			previous++
+		}
+	}
+
+	factor := Factor{
+		Name:        FactorMentionVelocity,
+		Weight:      p.Config.Weights.MentionVelocity,
+		Explanation: fmt.Sprintf("%d mentions in the last %s, %d in the window before", recent, window, previous),
+	}
+	// One is added to the earlier window so a first burst of mentions counts
+	// as growth rather than dividing by zero.
+	growth := float64(recent) / float64(previous+1)
+	if p.Config.VelocitySaturation > 1 {
+		factor.Value = clamp((growth - 1) / float64(p.Config.VelocitySaturation-1))
+	}
+	return factor
+}
+
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+// Rank orders priorities from most to least urgent, breaking ties by CVE ID.
+func Rank(priorities []Priority) {
+	sort.SliceStable(priorities, func(i, j int) bool {
+		if priorities[i].Score != priorities[j].Score {
+			return priorities[i].Score > priorities[j].Score
+		}
+		return priorities[i].CveId < priorities[j].CveId
+	})
+}
+
+func NewPrioritiser(config Config) (Prioritiser, error) {
+	weights := []float64{
+		config.Weights.Cvss,
+		config.Weights.Exploit,
+		config.Weights.Kev,
+		config.Weights.Epss,
+		config.Weights.MentionVolume,
+		config.Weights.MentionVelocity,
+	}
+	total := 0.0
+	for _, weight := range weights {
+		if weight < 0 {
+			return Prioritiser{}, ErrNegativeWeight
+		}
+		total += weight
+	}
+	if total == 0 {
+		return Prioritiser{}, ErrNoWeights
+	}
+	return Prioritiser{Config: config}, nil
+}
+
+func MustNewPrioritiser(config Config) Prioritiser {
+	prioritiser, err := NewPrioritiser(config)
+	if err != nil {
+		panic(err)
+	}
+	return prioritiser
+}
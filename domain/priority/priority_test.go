@@ -0,0 +1,91 @@
+package priority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func TestScoreCombinesFactors(t *testing.T) {
+	now := time.Date(2021, 12, 14, 12, 0, 0, 0, time.UTC)
+	vuln := vulnerability.Vulnerability{
+		CveId: "CVE-2021-44228",
+		Cvss3: vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0},
+		Kev:   vulnerability.Kev{DateAdded: time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)},
+		Epss:  vulnerability.Epss{Score: 0.97, Percentile: 0.99, Date: now},
+	}
+
+	factory := indicator.MustNewIndicatorFactory("reddit")
+	indicators := []indicator.Indicator{}
+	for i := 0; i < 3; i++ {
+		mentioning := factory.MustNewIndicator()
+		mentioning.CreatedDate = now.Add(-time.Duration(i) * time.Hour)
+		mentioning.AddMention(indicator.MustNewMention("cve", []byte("cve-2021-44228")))
+		indicators = append(indicators, mentioning)
+	}
+	unrelated := factory.MustNewIndicator()
+	unrelated.CreatedDate = now
+	unrelated.AddMention(indicator.MustNewMention("cve", []byte("CVE-2021-45046")))
+	indicators = append(indicators, unrelated)
+
+	prioritiser := MustNewPrioritiser(DefaultConfig())
+	priority := prioritiser.Score(vuln, indicators, now)
+
+	if volume, _ := priority.Factor(FactorMentionVolume); volume.Explanation != "3 indicators mention the vulnerability" {
+		t.Errorf("unexpected volume explanation: %s", volume.Explanation)
+	}
+	if exploit, _ := priority.Factor(FactorExploit); exploit.Contribution != 0 {
+		t.Errorf("expected no exploit contribution, got %f", exploit.Contribution)
+	}
+
+	total := 0.0
+	for _, factor := range priority.Factors {
+		total += factor.Contribution
+	}
+	if priority.Score < 70 || priority.Score > 100 {
+		t.Errorf("expected a high priority score, got %f", priority.Score)
+	}
+	if diff := total - priority.Score; diff > 0.01 || diff < -0.01 {
+		t.Errorf("factor contributions %f do not add up to score %f", total, priority.Score)
+	}
+}
+
+func TestNewPrioritiserRejectsInvalidWeights(t *testing.T) {
+	if _, err := NewPrioritiser(Config{}); err != ErrNoWeights {
+		t.Errorf("expected ErrNoWeights, got %v", err)
+	}
+	if _, err := NewPrioritiser(Config{Weights: Weights{Cvss: 1, Kev: -1}}); err != ErrNegativeWeight {
+		t.Errorf("expected ErrNegativeWeight, got %v", err)
+	}
+}
+
+func TestVelocityComparesWindows(t *testing.T) {
+	now := time.Date(2021, 12, 14, 12, 0, 0, 0, time.UTC)
+	prioritiser := MustNewPrioritiser(DefaultConfig())
+	mentions := func(recent int, previous int) []indicator.Indicator {
+		indicators := []indicator.Indicator{}
+		for i := 0; i < recent+previous; i++ {
+			age := time.Hour
+			if i >= recent {
+				age = 30 * time.Hour
+			}
+			indicators = append(indicators, indicator.Indicator{CreatedDate: now.Add(-age)})
+		}
+		return indicators
+	}
+
+	steady := prioritiser.velocityFactor(mentions(6, 6), now)
+	rising := prioritiser.velocityFactor(mentions(6, 1), now)
+	burst := prioritiser.velocityFactor(mentions(6, 0), now)
+	if steady.Value != 0 {
+		t.Errorf("expected steady mentions to have no velocity, got %f", steady.Value)
+	}
+	if rising.Value <= 0 || rising.Value >= burst.Value {
+		t.Errorf("expected velocity to grow with the change between windows, got %f and %f", rising.Value, burst.Value)
+	}
+	if flood := prioritiser.velocityFactor(mentions(100, 1), now); flood.Value != 1 {
+		t.Errorf("expected velocity to saturate, got %f", flood.Value)
+	}
+}
@@ -0,0 +1,152 @@
+package product
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+type Cpe struct {
+	Part    string
+	Vendor  string
+	Product string
+	Version string
+}
+
+// ParseCpe accepts both CPE 2.2 URIs (cpe:/a:apache:log4j:2.0) and CPE 2.3
+// formatted strings (cpe:2.3:a:apache:log4j:2.0:*:*:*:*:*:*:*).
+func ParseCpe(name string) (Cpe, error) {
+	var components []string
+	switch {
+	case strings.HasPrefix(name, "cpe:2.3:"):
+		components = splitEscaped(strings.TrimPrefix(name, "cpe:2.3:"))
+	case strings.HasPrefix(name, "cpe:/"):
+		for _, component := range strings.Split(strings.TrimPrefix(name, "cpe:/"), ":") {
+			unescaped, err := url.PathUnescape(component)
+			if err != nil {
+				return Cpe{}, fmt.Errorf("%w: %s", ErrInvalidCpe, name)
+			}
+			components = append(components, unescaped)
+		}
+	default:
+		return Cpe{}, fmt.Errorf("%w: %s", ErrInvalidCpe, name)
+	}
+
+	if len(components) < 3 || components[1] == "" || components[2] == "" {
+		return Cpe{}, fmt.Errorf("%w: %s", ErrInvalidCpe, name)
+	}
+	cpe := Cpe{
+		Part:    components[0],
+		Vendor:  strings.ToLower(components[1]),
+		Product: strings.ToLower(components[2]),
+	}
+	if len(components) > 3 && components[3] != "*" && components[3] != "-" {
+		cpe.Version = components[3]
+	}
+	return cpe, nil
+}
+
+// splitEscaped splits a CPE 2.3 formatted string on unescaped colons and
+// removes the escaping backslashes.
+func splitEscaped(formatted string) []string {
+	components := []string{}
+	var current strings.Builder
+	escaped := false
+	for _, r := range formatted {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			components = append(components, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(components, current.String())
+}
+
+type cpeItem struct {
+	Name       string `xml:"name,attr"`
+	Deprecated bool   `xml:"deprecated,attr"`
+	Titles     []struct {
+		Lang  string `xml:"lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"title"`
+	Cpe23 struct {
+		Name string `xml:"name,attr"`
+	} `xml:"cpe23-item"`
+}
+
+func (item cpeItem) title() string {
+	for _, title := range item.Titles {
+		if strings.HasPrefix(strings.ToLower(title.Lang), "en") {
+			return strings.TrimSpace(title.Value)
+		}
+	}
+	if len(item.Titles) > 0 {
+		return strings.TrimSpace(item.Titles[0].Value)
+	}
+	return ""
+}
+
+// LoadCpeDictionary seeds a dictionary from the NVD official CPE dictionary
+// XML feed. The feed is decoded item by item so the full file is never held
+// in memory. Titles, with the version stripped, become product aliases.
+func LoadCpeDictionary(r io.Reader) (*Dictionary, error) {
+	dictionary := MustNewDictionary()
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cpe dictionary: %s", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "cpe-item" {
+			continue
+		}
+		var item cpeItem
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return nil, fmt.Errorf("failed to decode cpe item: %s", err)
+		}
+		if item.Deprecated {
+			continue
+		}
+
+		name := item.Cpe23.Name
+		if name == "" {
+			name = item.Name
+		}
+		cpe, err := ParseCpe(name)
+		if err != nil {
+			continue
+		}
+
+		dictionary.AddVendor(Vendor{Name: cpe.Vendor, Title: cpe.Vendor})
+		title := versionlessTitle(item.title(), cpe.Version)
+		product := Product{Vendor: cpe.Vendor, Name: cpe.Product, Title: title}
+		if title != "" {
+			product.Aliases = []string{title}
+		}
+		dictionary.AddProduct(product)
+	}
+	return dictionary, nil
+}
+
+func versionlessTitle(title string, version string) string {
+	if version != "" {
+		if index := strings.Index(title, " "+version); index > 0 {
+			title = title[:index]
+		}
+	}
+	return strings.TrimSpace(title)
+}
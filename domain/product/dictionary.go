@@ -0,0 +1,263 @@
+package product
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type Dictionary struct {
+	vendors        map[string]*Vendor
+	products       map[string]*Product
+	vendorAliases  map[string]string
+	productAliases map[string]map[string]bool
+	maxAliasWords  int
+	lock           *sync.RWMutex
+}
+
+func (d *Dictionary) AddVendor(vendor Vendor) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.addVendor(vendor)
+}
+
+func (d *Dictionary) addVendor(vendor Vendor) *Vendor {
+	vendor.Name = strings.ToLower(vendor.Name)
+	existing, ok := d.vendors[vendor.Name]
+	if !ok {
+		existing = &Vendor{Name: vendor.Name, Title: vendor.Title, Aliases: []string{}}
+		d.vendors[vendor.Name] = existing
+		d.indexVendorAlias(existing, vendor.Name)
+	}
+	if existing.Title == "" {
+		existing.Title = vendor.Title
+	}
+	if vendor.Title != "" {
+		d.indexVendorAlias(existing, vendor.Title)
+	}
+	for _, alias := range vendor.Aliases {
+		if existing.addAlias(alias) {
+			d.indexVendorAlias(existing, alias)
+		}
+	}
+	return existing
+}
+
+func (d *Dictionary) indexVendorAlias(vendor *Vendor, alias string) {
+	d.vendorAliases[Normalise(alias)] = vendor.Name
+}
+
+func (d *Dictionary) AddProduct(product Product) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.addProduct(product)
+}
+
+func (d *Dictionary) addProduct(product Product) *Product {
+	product.Vendor = strings.ToLower(product.Vendor)
+	product.Name = strings.ToLower(product.Name)
+	d.addVendor(Vendor{Name: product.Vendor})
+
+	existing, ok := d.products[product.Key()]
+	if !ok {
+		existing = &Product{Vendor: product.Vendor, Name: product.Name, Title: product.Title, Aliases: []string{}}
+		d.products[existing.Key()] = existing
+		d.indexProductAlias(existing, existing.Name)
+		d.indexProductAlias(existing, existing.Key())
+	}
+	if existing.Title == "" {
+		existing.Title = product.Title
+	}
+	for _, alias := range product.Aliases {
+		if existing.addAlias(alias) {
+			d.indexProductAlias(existing, alias)
+		}
+	}
+	return existing
+}
+
+func (d *Dictionary) indexProductAlias(product *Product, alias string) {
+	normalised := Normalise(alias)
+	if normalised == "" {
+		return
+	}
+	if _, ok := d.productAliases[normalised]; !ok {
+		d.productAliases[normalised] = make(map[string]bool)
+	}
+	d.productAliases[normalised][product.Key()] = true
+	if words := len(strings.Fields(normalised)); words > d.maxAliasWords {
+		d.maxAliasWords = words
+	}
+}
+
+func (d *Dictionary) AddVendorAlias(vendor string, alias string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	existing, ok := d.vendors[strings.ToLower(vendor)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrVendorNotFound, vendor)
+	}
+	if existing.addAlias(alias) {
+		d.indexVendorAlias(existing, alias)
+	}
+	return nil
+}
+
+func (d *Dictionary) AddProductAlias(vendor string, product string, alias string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	existing, ok := d.products[strings.ToLower(vendor)+":"+strings.ToLower(product)]
+	if !ok {
+		return fmt.Errorf("%w: %s:%s", ErrProductNotFound, vendor, product)
+	}
+	if existing.addAlias(alias) {
+		d.indexProductAlias(existing, alias)
+	}
+	return nil
+}
+
+// ResolveVendor accepts a vendor name, alias or CNA assigner address such as
+// security@apache.org.
+func (d *Dictionary) ResolveVendor(name string) (Vendor, error) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	candidates := []string{name}
+	if at := strings.LastIndex(name, "@"); at >= 0 {
+		domain := strings.Split(name[at+1:], ".")
+		if len(domain) >= 2 {
+			candidates = append(candidates, domain[len(domain)-2])
+		}
+	}
+	for _, candidate := range candidates {
+		if vendorName, ok := d.vendorAliases[Normalise(candidate)]; ok {
+			return *d.vendors[vendorName], nil
+		}
+	}
+	return Vendor{}, fmt.Errorf("%w: %s", ErrVendorNotFound, name)
+}
+
+// Resolve accepts a CPE name, a vendor:product key or any product alias.
+func (d *Dictionary) Resolve(name string) (Product, error) {
+	if strings.HasPrefix(name, "cpe:") {
+		return d.ResolveCpe(name)
+	}
+	candidates := d.Candidates(name)
+	switch len(candidates) {
+	case 0:
+		return Product{}, fmt.Errorf("%w: %s", ErrProductNotFound, name)
+	case 1:
+		return candidates[0], nil
+	}
+	keys := []string{}
+	for _, candidate := range candidates {
+		keys = append(keys, candidate.Key())
+	}
+	return Product{}, fmt.Errorf("%w: %s could be %s", ErrAmbiguousProduct, name, strings.Join(keys, ", "))
+}
+
+func (d *Dictionary) ResolveCpe(name string) (Product, error) {
+	cpe, err := ParseCpe(name)
+	if err != nil {
+		return Product{}, err
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	product, ok := d.products[cpe.Vendor+":"+cpe.Product]
+	if !ok {
+		return Product{}, fmt.Errorf("%w: %s", ErrProductNotFound, name)
+	}
+	return *product, nil
+}
+
+func (d *Dictionary) Candidates(name string) []Product {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	candidates := []Product{}
+	for key := range d.productAliases[Normalise(name)] {
+		candidates = append(candidates, *d.products[key])
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Key() < candidates[j].Key()
+	})
+	return candidates
+}
+
+// FindInText returns the products mentioned in free text, in order of first
+// appearance. Aliases shared by several products are ignored.
+func (d *Dictionary) FindInText(text string) []Product {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	words := tokenise(text)
+	found := []Product{}
+	seen := make(map[string]bool)
+	for start := 0; start < len(words); start++ {
+		for length := d.maxAliasWords; length >= 1; length-- {
+			if start+length > len(words) {
+				continue
+			}
+			keys := d.productAliases[strings.Join(words[start:start+length], " ")]
+			if len(keys) != 1 {
+				continue
+			}
+			for key := range keys {
+				if !seen[key] {
+					seen[key] = true
+					found = append(found, *d.products[key])
+				}
+			}
+			start += length - 1
+			break
+		}
+	}
+	return found
+}
+
+// tokenise splits on underscores as Normalise does, so CPE names written in
+// text match the aliases they were indexed under.
+func tokenise(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(".-+:", r)
+	})
+	words := []string{}
+	for _, field := range fields {
+		if word := strings.Trim(field, ".-:"); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func (d *Dictionary) Products() []Product {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	products := []Product{}
+	for _, product := range d.products {
+		products = append(products, *product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Key() < products[j].Key()
+	})
+	return products
+}
+
+func NewDictionary() (*Dictionary, error) {
+	return &Dictionary{
+		vendors:        make(map[string]*Vendor),
+		products:       make(map[string]*Product),
+		vendorAliases:  make(map[string]string),
+		productAliases: make(map[string]map[string]bool),
+		lock:           &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewDictionary() *Dictionary {
+	dictionary, err := NewDictionary()
+	if err != nil {
+		panic(err)
+	}
+	return dictionary
+}
@@ -0,0 +1,61 @@
+package product
+
+import (
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// Enrich rewrites the vulnerability's affected products to their canonical
+// vendor and product names, resolving by CPE where one is present. Products
+// mentioned in the description that are not yet listed are appended. The
+// entries that could not be resolved are returned.
+func (d *Dictionary) Enrich(vuln *vulnerability.Vulnerability) []vulnerability.AffectedProduct {
+	unresolved := []vulnerability.AffectedProduct{}
+	listed := make(map[string]bool)
+
+	for i, affected := range vuln.Affected {
+		product, err := d.resolveAffected(affected)
+		if err != nil {
+			unresolved = append(unresolved, affected)
+			continue
+		}
+		vuln.Affected[i].Vendor = product.Vendor
+		vuln.Affected[i].Product = product.Name
+		listed[product.Key()] = true
+	}
+
+	for _, product := range d.FindInText(vuln.Description) {
+		if listed[product.Key()] {
+			continue
+		}
+		listed[product.Key()] = true
+		vuln.Affected = append(vuln.Affected, vulnerability.AffectedProduct{
+			Vendor:  product.Vendor,
+			Product: product.Name,
+		})
+	}
+
+	return unresolved
+}
+
+func (d *Dictionary) resolveAffected(affected vulnerability.AffectedProduct) (Product, error) {
+	if affected.Cpe != "" {
+		if product, err := d.ResolveCpe(affected.Cpe); err == nil {
+			return product, nil
+		}
+	}
+	if affected.Vendor != "" {
+		if vendor, err := d.ResolveVendor(affected.Vendor); err == nil {
+			for _, candidate := range d.Candidates(affected.Product) {
+				if candidate.Vendor == vendor.Name {
+					return candidate, nil
+				}
+			}
+		}
+	}
+	return d.Resolve(affected.Product)
+}
+
+// ResolveAssigner maps the vulnerability's assigning CNA onto a vendor.
+func (d *Dictionary) ResolveAssigner(vuln vulnerability.Vulnerability) (Vendor, error) {
+	return d.ResolveVendor(vuln.Assigner)
+}
@@ -0,0 +1,61 @@
+package product
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrVendorNotFound   = errors.New("the vendor was not found")
+	ErrProductNotFound  = errors.New("the product was not found")
+	ErrAmbiguousProduct = errors.New("the name matches more than one product")
+	ErrInvalidCpe       = errors.New("the cpe name is not valid")
+)
+
+type Vendor struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Aliases []string `json:"aliases"`
+}
+
+type Product struct {
+	Vendor  string   `json:"vendor"`
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Aliases []string `json:"aliases"`
+}
+
+// Key identifies the product in the CPE vendor:product form.
+func (p Product) Key() string {
+	return p.Vendor + ":" + p.Name
+}
+
+func (p *Product) addAlias(alias string) bool {
+	for _, existing := range p.Aliases {
+		if existing == alias {
+			return false
+		}
+	}
+	p.Aliases = append(p.Aliases, alias)
+	return true
+}
+
+func (v *Vendor) addAlias(alias string) bool {
+	for _, existing := range v.Aliases {
+		if existing == alias {
+			return false
+		}
+	}
+	v.Aliases = append(v.Aliases, alias)
+	return true
+}
+
+// Normalise folds case and treats underscores and runs of whitespace as a
+// single space so CPE tokens and free text compare equal.
+func Normalise(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '_' || unicode.IsSpace(r)
+	})
+	return strings.Join(fields, " ")
+}
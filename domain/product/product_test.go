@@ -0,0 +1,92 @@
+package product
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const testCpeDictionary = `<?xml version='1.0' encoding='UTF-8'?>
+<cpe-list xmlns="http://cpe.mitre.org/dictionary/2.0" xmlns:cpe-23="http://scap.nist.gov/schema/cpe-extension/2.3">
+  <cpe-item name="cpe:/a:apache:log4j:2.14.1">
+    <title xml:lang="en-US">Apache Log4j 2.14.1</title>
+    <cpe-23:cpe23-item name="cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*"/>
+  </cpe-item>
+  <cpe-item name="cpe:/a:apache:http_server:2.4.49">
+    <title xml:lang="en-US">Apache HTTP Server 2.4.49</title>
+    <cpe-23:cpe23-item name="cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*"/>
+  </cpe-item>
+  <cpe-item name="cpe:/a:oldvendor:oldproduct:1.0" deprecated="true">
+    <title xml:lang="en-US">Old Product 1.0</title>
+  </cpe-item>
+</cpe-list>`
+
+func TestLoadCpeDictionaryAndResolve(t *testing.T) {
+	dictionary, err := LoadCpeDictionary(strings.NewReader(testCpeDictionary))
+	if err != nil {
+		t.Fatalf("failed to load cpe dictionary: %s", err)
+	}
+	if len(dictionary.Products()) != 2 {
+		t.Fatalf("expected 2 products, got %d", len(dictionary.Products()))
+	}
+	if err := dictionary.AddProductAlias("apache", "log4j", "Log4j2"); err != nil {
+		t.Fatalf("failed to add alias: %s", err)
+	}
+	if err := dictionary.AddProductAlias("apache", "log4j", "log4j-core"); err != nil {
+		t.Fatalf("failed to add alias: %s", err)
+	}
+
+	for _, name := range []string{"apache:log4j", "Log4j2", "log4j-core", "Apache Log4j", "cpe:2.3:a:apache:log4j:2.15.0:*:*:*:*:*:*:*"} {
+		product, err := dictionary.Resolve(name)
+		if err != nil {
+			t.Errorf("failed to resolve %q: %s", name, err)
+			continue
+		}
+		if product.Key() != "apache:log4j" {
+			t.Errorf("expected %q to resolve to apache:log4j, got %s", name, product.Key())
+		}
+	}
+
+	if _, err := dictionary.Resolve("oldproduct"); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected deprecated items to be skipped, got %v", err)
+	}
+	if vendor, err := dictionary.ResolveVendor("security@apache.org"); err != nil || vendor.Name != "apache" {
+		t.Errorf("expected assigner to resolve to apache, got %v %v", vendor, err)
+	}
+}
+
+func TestEnrichVulnerability(t *testing.T) {
+	dictionary, err := LoadCpeDictionary(strings.NewReader(testCpeDictionary))
+	if err != nil {
+		t.Fatalf("failed to load cpe dictionary: %s", err)
+	}
+	vuln := vulnerability.Vulnerability{
+		CveId:       "CVE-2021-41773",
+		Description: "A flaw was found in a change made to path normalization in Apache HTTP Server 2.4.49.",
+		Affected: []vulnerability.AffectedProduct{
+			{Cpe: "cpe:/a:apache:log4j:2.14.1"},
+			{Vendor: "unknown", Product: "unknown"},
+		},
+	}
+	unresolved := dictionary.Enrich(&vuln)
+	if len(unresolved) != 1 {
+		t.Errorf("expected one unresolved product, got %v", unresolved)
+	}
+	if vuln.Affected[0].Product != "log4j" || vuln.Affected[2].Product != "http_server" {
+		t.Errorf("unexpected affected products: %+v", vuln.Affected)
+	}
+
+	mentions, _ := MustNewProductTopic("product", dictionary).Mentions("Patch your apache http server now")
+	if len(mentions) != 1 || mentions[0].Mention != "apache:http_server" {
+		t.Errorf("unexpected product mentions: %+v", mentions)
+	}
+
+	for _, text := range []string{"Exploit for apache:http_server released", "http_server path traversal"} {
+		found := dictionary.FindInText(text)
+		if len(found) != 1 || found[0].Key() != "apache:http_server" {
+			t.Errorf("expected to find apache:http_server in %q, got %+v", text, found)
+		}
+	}
+}
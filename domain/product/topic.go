@@ -0,0 +1,47 @@
+package product
+
+import (
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+)
+
+// ProductTopic is an indicator topic whose mentions are canonical
+// vendor:product keys found through the dictionary.
+type ProductTopic struct {
+	name       string
+	dictionary *Dictionary
+}
+
+func (pt ProductTopic) Name() string {
+	return pt.name
+}
+
+func (pt ProductTopic) Mentions(post string) ([]indicator.Mention, error) {
+	mentions := []indicator.Mention{}
+	for _, product := range pt.dictionary.FindInText(post) {
+		mentions = append(mentions, indicator.MustNewMention(pt.name, []byte(product.Key())))
+	}
+	return mentions, nil
+}
+
+func (pt ProductTopic) Mentioned(post string) (bool, error) {
+	mentions, err := pt.Mentions(post)
+	if err != nil {
+		return false, err
+	}
+	return len(mentions) > 0, nil
+}
+
+func NewProductTopic(name string, dictionary *Dictionary) (indicator.Topic, error) {
+	return ProductTopic{
+		name:       name,
+		dictionary: dictionary,
+	}, nil
+}
+
+func MustNewProductTopic(name string, dictionary *Dictionary) indicator.Topic {
+	topic, err := NewProductTopic(name, dictionary)
+	if err != nil {
+		panic(err)
+	}
+	return topic
+}
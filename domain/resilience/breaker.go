@@ -0,0 +1,161 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("the circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// CircuitBreaker opens after FailureThreshold retryable failures in a row and
+// fails fast for OpenDuration. It then lets a single call through, closing
+// again if that succeeds. Errors that are not retryable, such as not found,
+// show the backend is up and count as successes. Results of calls allowed
+// before the last transition are ignored, so only that single call decides
+// whether a half-open breaker closes.
+type CircuitBreaker struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+	Now              func() time.Time
+	// OnStateChange is called after every transition, outside the lock.
+	OnStateChange func(from State, to State)
+
+	lock     *sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	// generation counts transitions, to tell which state a Token was
+	// handed out in.
+	generation int
+}
+
+// Token identifies a call allowed by the breaker, for Record.
+type Token struct {
+	breaker    *CircuitBreaker
+	generation int
+	probe      bool
+}
+
+func (cb *CircuitBreaker) State() State {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.state == StateOpen && cb.Now().Sub(cb.openedAt) >= cb.OpenDuration {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Allow returns ErrCircuitOpen when the call should not be made. Every
+// allowed call must be followed by Record with the returned token.
+func (cb *CircuitBreaker) Allow() (Token, error) {
+	cb.lock.Lock()
+	from := cb.state
+	if cb.state == StateOpen && cb.Now().Sub(cb.openedAt) >= cb.OpenDuration {
+		cb.transition(StateHalfOpen)
+	}
+	allowed := cb.state == StateClosed || (cb.state == StateHalfOpen && !cb.probing)
+	token := Token{breaker: cb, generation: cb.generation, probe: allowed && cb.state == StateHalfOpen}
+	if token.probe {
+		cb.probing = true
+	}
+	to := cb.state
+	cb.lock.Unlock()
+
+	cb.changed(from, to)
+	if !allowed {
+		return Token{}, ErrCircuitOpen
+	}
+	return token, nil
+}
+
+// Record counts the result of the call token was handed out for. Only the
+// probe of a half-open breaker can close or reopen it.
+func (cb *CircuitBreaker) Record(token Token, err error) {
+	failed := IsRetryable(err)
+	cb.lock.Lock()
+	if token.breaker != cb || token.generation != cb.generation {
+		cb.lock.Unlock()
+		return
+	}
+	from := cb.state
+	switch {
+	case token.probe && failed:
+		cb.open()
+	case token.probe:
+		cb.transition(StateClosed)
+	case failed:
+		cb.failures++
+		if cb.failures >= cb.FailureThreshold {
+			cb.open()
+		}
+	default:
+		cb.failures = 0
+	}
+	to := cb.state
+	cb.lock.Unlock()
+
+	cb.changed(from, to)
+}
+
+// open must be called with the lock held.
+func (cb *CircuitBreaker) open() {
+	cb.transition(StateOpen)
+	cb.openedAt = cb.Now()
+}
+
+// transition must be called with the lock held.
+func (cb *CircuitBreaker) transition(to State) {
+	cb.state = to
+	cb.failures = 0
+	cb.probing = false
+	cb.generation++
+}
+
+func (cb *CircuitBreaker) changed(from State, to State) {
+	if from != to && cb.OnStateChange != nil {
+		cb.OnStateChange(from, to)
+	}
+}
+
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) (*CircuitBreaker, error) {
+	if failureThreshold < 1 || openDuration <= 0 {
+		return nil, fmt.Errorf("%w: the breaker needs a failure threshold and open duration", ErrInvalidPolicy)
+	}
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		OpenDuration:     openDuration,
+		Now:              time.Now,
+		lock:             &sync.Mutex{},
+	}, nil
+}
+
+func MustNewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	breaker, err := NewCircuitBreaker(failureThreshold, openDuration)
+	if err != nil {
+		panic(err)
+	}
+	return breaker
+}
@@ -0,0 +1,229 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// ResilientIndicatorRepository makes every call on the wrapped repository
+// through Policy, so that a struggling backend is retried and a down one
+// fails fast with ErrCircuitOpen.
+type ResilientIndicatorRepository struct {
+	Repository indicator.IndicatorRepository
+	Policy     Policy
+}
+
+func (rir ResilientIndicatorRepository) Add(ctx context.Context, i indicator.Indicator) error {
+	return rir.Policy.Do(ctx, func(ctx context.Context) error {
+		return rir.Repository.Add(ctx, i)
+	})
+}
+
+func (rir ResilientIndicatorRepository) Update(ctx context.Context, id string, i *indicator.Indicator) error {
+	return rir.Policy.Do(ctx, func(ctx context.Context) error {
+		return rir.Repository.Update(ctx, id, i)
+	})
+}
+
+func (rir ResilientIndicatorRepository) Upsert(ctx context.Context, i indicator.Indicator) (*indicator.Indicator, error) {
+	var stored *indicator.Indicator
+	err := rir.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		stored, err = rir.Repository.Upsert(ctx, i)
+		return err
+	})
+	return stored, err
+}
+
+func (rir ResilientIndicatorRepository) Delete(ctx context.Context, id string) error {
+	return rir.Policy.Do(ctx, func(ctx context.Context) error {
+		return rir.Repository.Delete(ctx, id)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetById(ctx context.Context, id string) (*indicator.Indicator, error) {
+	return rir.one(ctx, func(ctx context.Context) (*indicator.Indicator, error) {
+		return rir.Repository.GetById(ctx, id)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetByLink(ctx context.Context, link string) (*indicator.Indicator, error) {
+	return rir.one(ctx, func(ctx context.Context) (*indicator.Indicator, error) {
+		return rir.Repository.GetByLink(ctx, link)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetByTopic(ctx context.Context, topicName string) (*indicator.IndicatorCollection, error) {
+	return rir.collection(ctx, func(ctx context.Context) (*indicator.IndicatorCollection, error) {
+		return rir.Repository.GetByTopic(ctx, topicName)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetByMention(ctx context.Context, mention indicator.Mention) (*indicator.IndicatorCollection, error) {
+	return rir.collection(ctx, func(ctx context.Context) (*indicator.IndicatorCollection, error) {
+		return rir.Repository.GetByMention(ctx, mention)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetBySource(ctx context.Context, source string) (*indicator.IndicatorCollection, error) {
+	return rir.collection(ctx, func(ctx context.Context) (*indicator.IndicatorCollection, error) {
+		return rir.Repository.GetBySource(ctx, source)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetBySourceId(ctx context.Context, source string, sourceId string) (*indicator.Indicator, error) {
+	return rir.one(ctx, func(ctx context.Context) (*indicator.Indicator, error) {
+		return rir.Repository.GetBySourceId(ctx, source, sourceId)
+	})
+}
+
+func (rir ResilientIndicatorRepository) GetLatest(ctx context.Context) (*indicator.Indicator, error) {
+	return rir.one(ctx, rir.Repository.GetLatest)
+}
+
+func (rir ResilientIndicatorRepository) GetBetween(ctx context.Context, start time.Time, end time.Time) (*indicator.IndicatorCollection, error) {
+	return rir.collection(ctx, func(ctx context.Context) (*indicator.IndicatorCollection, error) {
+		return rir.Repository.GetBetween(ctx, start, end)
+	})
+}
+
+func (rir ResilientIndicatorRepository) Find(ctx context.Context, query indicator.Query) (*indicator.Page, error) {
+	var page *indicator.Page
+	err := rir.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		page, err = rir.Repository.Find(ctx, query)
+		return err
+	})
+	return page, err
+}
+
+// Stream is not retried or given a timeout, since results already sent
+// cannot be taken back. It still fails fast while the breaker is open, and
+// the error that ends the stream, if any, is recorded on the breaker.
+func (rir ResilientIndicatorRepository) Stream(ctx context.Context, query indicator.Query) <-chan indicator.StreamResult {
+	results := make(chan indicator.StreamResult)
+	breaker := rir.Policy.Breaker
+	var token Token
+	if breaker != nil {
+		var err error
+		if token, err = breaker.Allow(); err != nil {
+			go func() {
+				defer close(results)
+				indicator.SendStreamResult(ctx, results, indicator.StreamResult{Err: err})
+			}()
+			return results
+		}
+	}
+	go func() {
+		defer close(results)
+		var err error
+		for result := range rir.Repository.Stream(ctx, query) {
+			err = result.Err
+			if !indicator.SendStreamResult(ctx, results, result) {
+				break
+			}
+		}
+		if breaker != nil {
+			breaker.Record(token, err)
+		}
+	}()
+	return results
+}
+
+func (rir ResilientIndicatorRepository) one(ctx context.Context, get func(ctx context.Context) (*indicator.Indicator, error)) (*indicator.Indicator, error) {
+	var i *indicator.Indicator
+	err := rir.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		i, err = get(ctx)
+		return err
+	})
+	return i, err
+}
+
+func (rir ResilientIndicatorRepository) collection(ctx context.Context, get func(ctx context.Context) (*indicator.IndicatorCollection, error)) (*indicator.IndicatorCollection, error) {
+	var collection *indicator.IndicatorCollection
+	err := rir.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		collection, err = get(ctx)
+		return err
+	})
+	return collection, err
+}
+
+func NewResilientIndicatorRepository(repository indicator.IndicatorRepository, policy Policy) (indicator.IndicatorRepository, error) {
+	if err := policy.Retry.Validate(); err != nil {
+		return nil, err
+	}
+	return ResilientIndicatorRepository{
+		Repository: repository,
+		Policy:     policy,
+	}, nil
+}
+
+func MustNewResilientIndicatorRepository(repository indicator.IndicatorRepository, policy Policy) indicator.IndicatorRepository {
+	repo, err := NewResilientIndicatorRepository(repository, policy)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
+
+// ResilientVulnerabilityRepository makes every call on the wrapped repository
+// through Policy.
+type ResilientVulnerabilityRepository struct {
+	Repository vulnerability.VulnerabilityRepository
+	Policy     Policy
+}
+
+func (rvr ResilientVulnerabilityRepository) Get(ctx context.Context, cveId string) (*vulnerability.Vulnerability, error) {
+	var vuln *vulnerability.Vulnerability
+	err := rvr.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		vuln, err = rvr.Repository.Get(ctx, cveId)
+		return err
+	})
+	return vuln, err
+}
+
+func (rvr ResilientVulnerabilityRepository) Add(ctx context.Context, vuln vulnerability.Vulnerability) error {
+	return rvr.Policy.Do(ctx, func(ctx context.Context) error {
+		return rvr.Repository.Add(ctx, vuln)
+	})
+}
+
+func (rvr ResilientVulnerabilityRepository) Update(ctx context.Context, cveId string, vuln *vulnerability.Vulnerability) error {
+	return rvr.Policy.Do(ctx, func(ctx context.Context) error {
+		return rvr.Repository.Update(ctx, cveId, vuln)
+	})
+}
+
+func (rvr ResilientVulnerabilityRepository) Delete(ctx context.Context, cveId string) error {
+	return rvr.Policy.Do(ctx, func(ctx context.Context) error {
+		return rvr.Repository.Delete(ctx, cveId)
+	})
+}
+
+func (rvr ResilientVulnerabilityRepository) List(ctx context.Context) (*vulnerability.VulnerabilityCollection, error) {
+	var collection *vulnerability.VulnerabilityCollection
+	err := rvr.Policy.Do(ctx, func(ctx context.Context) (err error) {
+		collection, err = rvr.Repository.List(ctx)
+		return err
+	})
+	return collection, err
+}
+
+func NewResilientVulnerabilityRepository(repository vulnerability.VulnerabilityRepository, policy Policy) (vulnerability.VulnerabilityRepository, error) {
+	if err := policy.Retry.Validate(); err != nil {
+		return nil, err
+	}
+	return ResilientVulnerabilityRepository{
+		Repository: repository,
+		Policy:     policy,
+	}, nil
+}
+
+func MustNewResilientVulnerabilityRepository(repository vulnerability.VulnerabilityRepository, policy Policy) vulnerability.VulnerabilityRepository {
+	repo, err := NewResilientVulnerabilityRepository(repository, policy)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
@@ -0,0 +1,98 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Policy decides how a call to a backend is made: each attempt bounded by
+// Timeout, retryable failures retried under Retry, and everything refused
+// while Breaker is open. Timeout and Breaker are optional.
+type Policy struct {
+	Retry   RetryPolicy
+	Timeout time.Duration
+	Breaker *CircuitBreaker
+	// Random returns a number in [0, 1) for jitter, and Sleep waits out the
+	// backoff. Both default to the real thing when nil.
+	Random func() float64
+	Sleep  func(ctx context.Context, delay time.Duration) error
+}
+
+// Do runs the call until it succeeds, fails with an error that is not
+// retryable, runs out of attempts or ctx is done, and returns the last error.
+// Writes are retried too, so an Add whose first attempt timed out after
+// landing may come back as already existing.
+func (p Policy) Do(ctx context.Context, call func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var token Token
+		if p.Breaker != nil {
+			var allowErr error
+			if token, allowErr = p.Breaker.Allow(); allowErr != nil {
+				if err != nil {
+					return fmt.Errorf("%w after: %s", allowErr, err)
+				}
+				return allowErr
+			}
+		}
+		err = p.attempt(ctx, call)
+		if p.Breaker != nil {
+			p.Breaker.Record(token, err)
+		}
+		if err == nil || !IsRetryable(err) || attempt >= p.Retry.MaxAttempts {
+			return err
+		}
+		random, wait := rand.Float64, sleep
+		if p.Random != nil {
+			random = p.Random
+		}
+		if p.Sleep != nil {
+			wait = p.Sleep
+		}
+		if sleepErr := wait(ctx, p.Retry.Backoff(attempt, random())); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (p Policy) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	if p.Timeout <= 0 {
+		return call(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	err := call(attemptCtx)
+	// Only the attempt's own deadline is a timeout worth retrying; the
+	// caller's deadline or cancellation ends the call.
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %s", ErrCallTimeout, p.Timeout, err)
+	}
+	return err
+}
+
+func NewPolicy(retry RetryPolicy, timeout time.Duration, breaker *CircuitBreaker) (Policy, error) {
+	if err := retry.Validate(); err != nil {
+		return Policy{}, err
+	}
+	if timeout < 0 {
+		return Policy{}, fmt.Errorf("%w: timeout cannot be negative", ErrInvalidPolicy)
+	}
+	return Policy{
+		Retry:   retry,
+		Timeout: timeout,
+		Breaker: breaker,
+		Random:  rand.Float64,
+		Sleep:   sleep,
+	}, nil
+}
+
+func MustNewPolicy(retry RetryPolicy, timeout time.Duration, breaker *CircuitBreaker) Policy {
+	policy, err := NewPolicy(retry, timeout, breaker)
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
@@ -0,0 +1,210 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+type unavailableError struct{}
+
+func (unavailableError) Error() string   { return "the backend is unavailable" }
+func (unavailableError) Retryable() bool { return true }
+
+// flakyRepository fails the next Failures calls with a retryable error.
+type flakyRepository struct {
+	vulnerability.VulnerabilityRepository
+	Failures int
+	Calls    int
+}
+
+func (fr *flakyRepository) Get(ctx context.Context, cveId string) (*vulnerability.Vulnerability, error) {
+	fr.Calls++
+	if fr.Failures > 0 {
+		fr.Failures--
+		return nil, unavailableError{}
+	}
+	return fr.VulnerabilityRepository.Get(ctx, cveId)
+}
+
+func newTestPolicy(breaker *CircuitBreaker) (Policy, *[]time.Duration) {
+	policy := MustNewPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}, 0, breaker)
+	slept := &[]time.Duration{}
+	policy.Random = func() float64 { return 0 }
+	policy.Sleep = func(ctx context.Context, delay time.Duration) error {
+		*slept = append(*slept, delay)
+		return nil
+	}
+	return policy, slept
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	backing := memory.MustNewMemoryVulnerabilityRepository()
+	if err := backing.Add(ctx, vulnerability.Vulnerability{CveId: "CVE-2021-44228"}); err != nil {
+		t.Fatalf("failed to add vulnerability: %s", err)
+	}
+	flaky := &flakyRepository{VulnerabilityRepository: backing, Failures: 2}
+	policy, slept := newTestPolicy(nil)
+	repo := MustNewResilientVulnerabilityRepository(flaky, policy)
+
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); err != nil {
+		t.Fatalf("failed to get through retries: %s", err)
+	}
+	if flaky.Calls != 3 || len(*slept) != 2 || (*slept)[0] != 100*time.Millisecond || (*slept)[1] != 200*time.Millisecond {
+		t.Errorf("expected 3 calls backing off 100ms then 200ms, got %d calls after %v", flaky.Calls, *slept)
+	}
+
+	flaky.Calls, flaky.Failures = 0, 3
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); !IsRetryable(err) || flaky.Calls != 3 {
+		t.Errorf("expected the last retryable error after 3 calls, got %v after %d", err, flaky.Calls)
+	}
+
+	flaky.Calls = 0
+	if _, err := repo.Get(ctx, "CVE-2021-3711"); !errors.Is(err, vulnerability.ErrVulnerabilityNotFound) || flaky.Calls != 1 {
+		t.Errorf("expected not found without retrying, got %v after %d calls", err, flaky.Calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := DefaultRetryPolicy
+	if backoff := policy.Backoff(1, 0.5); backoff != 75*time.Millisecond {
+		t.Errorf("expected jitter to take a quarter off, got %s", backoff)
+	}
+	if backoff := policy.Backoff(20, 0); backoff != policy.MaxBackoff {
+		t.Errorf("expected backoff to be capped, got %s", backoff)
+	}
+	if err := (RetryPolicy{MaxAttempts: 1, Multiplier: 0.5}).Validate(); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("expected a shrinking multiplier to be invalid, got %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	policy, _ := newTestPolicy(nil)
+	policy.Timeout = time.Millisecond
+	calls := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrCallTimeout) || calls != 3 {
+		t.Errorf("expected timed out attempts to be retried, got %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	err = policy.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("expected a cancelled call not to be retried, got %v after %d calls", err, calls)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := MustNewCircuitBreaker(3, time.Minute)
+	breaker.Now = func() time.Time { return now }
+	transitions := []string{}
+	breaker.OnStateChange = func(from State, to State) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	}
+
+	backing := memory.MustNewMemoryVulnerabilityRepository()
+	if err := backing.Add(ctx, vulnerability.Vulnerability{CveId: "CVE-2021-44228"}); err != nil {
+		t.Fatalf("failed to add vulnerability: %s", err)
+	}
+	flaky := &flakyRepository{VulnerabilityRepository: backing, Failures: 100}
+	policy, _ := newTestPolicy(breaker)
+	repo := MustNewResilientVulnerabilityRepository(flaky, policy)
+
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); !IsRetryable(err) || breaker.State() != StateOpen {
+		t.Fatalf("expected the breaker to open after 3 failures, got %v and %s", err, breaker.State())
+	}
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); !errors.Is(err, ErrCircuitOpen) || flaky.Calls != 3 {
+		t.Errorf("expected an open breaker to fail fast, got %v after %d calls", err, flaky.Calls)
+	}
+
+	now = now.Add(time.Minute)
+	if breaker.State() != StateHalfOpen {
+		t.Errorf("expected the breaker to be half-open, got %s", breaker.State())
+	}
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); !errors.Is(err, ErrCircuitOpen) || flaky.Calls != 4 {
+		t.Errorf("expected a failed probe to reopen the breaker, got %v after %d calls", err, flaky.Calls)
+	}
+
+	now = now.Add(time.Minute)
+	flaky.Failures = 0
+	if _, err := repo.Get(ctx, "CVE-2021-44228"); err != nil || breaker.State() != StateClosed {
+		t.Errorf("expected a good probe to close the breaker, got %v and %s", err, breaker.State())
+	}
+
+	expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected transitions %v, got %v", expected, transitions)
+	}
+	for index := range expected {
+		if transitions[index] != expected[index] {
+			t.Errorf("expected transitions %v, got %v", expected, transitions)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := MustNewCircuitBreaker(1, time.Minute)
+	breaker.Now = func() time.Time { return now }
+
+	failing, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("failed to allow a call on a closed breaker: %s", err)
+	}
+	slow, _ := breaker.Allow()
+	breaker.Record(failing, unavailableError{})
+	if breaker.State() != StateOpen {
+		t.Fatalf("expected the breaker to open, got %s", breaker.State())
+	}
+
+	now = now.Add(time.Minute)
+	probe, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("failed to allow the probe: %s", err)
+	}
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected a second call to wait for the probe, got %v", err)
+	}
+
+	// A call allowed before the breaker opened finishes during the probe.
+	breaker.Record(slow, nil)
+	breaker.Record(Token{}, nil)
+	if breaker.State() != StateHalfOpen {
+		t.Errorf("expected only the probe to close the breaker, got %s", breaker.State())
+	}
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the probe to still be outstanding, got %v", err)
+	}
+
+	breaker.Record(probe, nil)
+	if breaker.State() != StateClosed {
+		t.Errorf("expected the probe to close the breaker, got %s", breaker.State())
+	}
+	breaker.Record(probe, unavailableError{})
+	if breaker.State() != StateClosed {
+		t.Errorf("expected a spent probe token to be ignored, got %s", breaker.State())
+	}
+}
@@ -0,0 +1,84 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidPolicy = errors.New("the resilience policy is invalid")
+	ErrCallTimeout   = errors.New("the call timed out")
+)
+
+// IsRetryable reports whether err is worth another attempt: a backend error
+// that says so through a Retryable method, such as
+// elasticsearch.ElasticsearchError, or a call that hit its timeout.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCallTimeout) {
+		return true
+	}
+	var retryable interface{ Retryable() bool }
+	return errors.As(err, &retryable) && retryable.Retryable()
+}
+
+// RetryPolicy backs off exponentially between attempts. Jitter takes up to
+// that fraction off each delay at random so that clients retrying together
+// spread out.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.5,
+}
+
+func (rp RetryPolicy) Validate() error {
+	if rp.MaxAttempts < 1 {
+		return fmt.Errorf("%w: at least one attempt is needed", ErrInvalidPolicy)
+	}
+	if rp.InitialBackoff < 0 || rp.MaxBackoff < rp.InitialBackoff {
+		return fmt.Errorf("%w: backoff must be positive and no more than the maximum", ErrInvalidPolicy)
+	}
+	if rp.Multiplier < 1 {
+		return fmt.Errorf("%w: multiplier must be at least 1", ErrInvalidPolicy)
+	}
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		return fmt.Errorf("%w: jitter must be between 0 and 1", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// Backoff is the delay after the given failed attempt, counting from 1.
+// random is a number in [0, 1).
+func (rp RetryPolicy) Backoff(attempt int, random float64) time.Duration {
+	backoff := float64(rp.InitialBackoff) * math.Pow(rp.Multiplier, float64(attempt-1))
+	if backoff > float64(rp.MaxBackoff) {
+		backoff = float64(rp.MaxBackoff)
+	}
+	return time.Duration(backoff * (1 - rp.Jitter*random))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
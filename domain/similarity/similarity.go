@@ -0,0 +1,255 @@
+package similarity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var ErrVulnerabilityNotIndexed = errors.New("the vulnerability is not in the similarity index")
+
+type Config struct {
+	// CweBoost and ProductBoost are added to the description similarity for
+	// each CWE and each affected product the two vulnerabilities share.
+	CweBoost     float64 `json:"cweBoost"`
+	ProductBoost float64 `json:"productBoost"`
+	// MinScore drops matches scoring below it.
+	MinScore float64 `json:"minScore"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		CweBoost:     0.10,
+		ProductBoost: 0.15,
+		MinScore:     0.05,
+	}
+}
+
+type Match struct {
+	CveId          string   `json:"cveId"`
+	Score          float64  `json:"score"`
+	TextScore      float64  `json:"textScore"`
+	SharedCwes     []string `json:"sharedCwes,omitempty"`
+	SharedProducts []string `json:"sharedProducts,omitempty"`
+}
+
+type document struct {
+	cveId    string
+	vector   map[string]float64
+	cwes     map[string]bool
+	products map[string]bool
+}
+
+// Index is a TF-IDF index over vulnerability descriptions. It is immutable
+// once built; rebuild it to pick up new vulnerabilities.
+type Index struct {
+	config    Config
+	documents []document
+	byCveId   map[string]int
+	idf       map[string]float64
+	total     int
+}
+
+func (i *Index) Len() int {
+	return len(i.documents)
+}
+
+// Related returns up to n indexed vulnerabilities most similar to the
+// indexed vulnerability cveId, best first.
+func (i *Index) Related(cveId string, n int) ([]Match, error) {
+	position, ok := i.byCveId[strings.ToUpper(cveId)]
+	if !ok {
+		return nil, ErrVulnerabilityNotIndexed
+	}
+	return i.rank(i.documents[position], n), nil
+}
+
+// Similar returns up to n indexed vulnerabilities most similar to vuln,
+// which need not be in the index.
+func (i *Index) Similar(vuln vulnerability.Vulnerability, n int) []Match {
+	return i.rank(i.document(vuln), n)
+}
+
+func (i *Index) rank(query document, n int) []Match {
+	matches := []Match{}
+	for _, candidate := range i.documents {
+		if candidate.cveId == query.cveId {
+			continue
+		}
+		match := Match{
+			CveId:          candidate.cveId,
+			TextScore:      dot(query.vector, candidate.vector),
+			SharedCwes:     shared(query.cwes, candidate.cwes),
+			SharedProducts: shared(query.products, candidate.products),
+		}
+		match.Score = match.TextScore +
+			i.config.CweBoost*float64(len(match.SharedCwes)) +
+			i.config.ProductBoost*float64(len(match.SharedProducts))
+		if match.Score <= 0 || match.Score < i.config.MinScore {
+			continue
+		}
+		match.Score = math.Round(match.Score*10000) / 10000
+		matches = append(matches, match)
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].CveId < matches[b].CveId
+	})
+	if n >= 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+func (i *Index) document(vuln vulnerability.Vulnerability) document {
+	counts := make(map[string]int)
+	for _, term := range tokenise(vuln.Description) {
+		counts[term]++
+	}
+
+	vector := make(map[string]float64)
+	norm := 0.0
+	for term, count := range counts {
+		idf, ok := i.idf[term]
+		if !ok {
+			idf = inverseDocumentFrequency(i.total, 0)
+		}
+		weight := (1 + math.Log(float64(count))) * idf
+		vector[term] = weight
+		norm += weight * weight
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for term := range vector {
+			vector[term] /= norm
+		}
+	}
+
+	doc := document{
+		cveId:    strings.ToUpper(vuln.CveId),
+		vector:   vector,
+		cwes:     make(map[string]bool),
+		products: make(map[string]bool),
+	}
+	for _, cwe := range vuln.Cwes {
+		doc.cwes[strings.ToUpper(cwe.Id)] = true
+	}
+	for _, affected := range vuln.Affected {
+		if affected.Product != "" {
+			doc.products[strings.ToLower(affected.Vendor+":"+affected.Product)] = true
+		}
+	}
+	return doc
+}
+
+func inverseDocumentFrequency(total int, frequency int) float64 {
+	return math.Log(float64(total+1)/float64(frequency+1)) + 1
+}
+
+func dot(a map[string]float64, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	sum := 0.0
+	for term, weight := range a {
+		sum += weight * b[term]
+	}
+	return sum
+}
+
+func shared(a map[string]bool, b map[string]bool) []string {
+	common := []string{}
+	for key := range a {
+		if b[key] {
+			common = append(common, key)
+		}
+	}
+	sort.Strings(common)
+	return common
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "could": true, "for": true, "from": true, "has": true, "have": true, "in": true,
+	"is": true, "it": true, "its": true, "may": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "via": true, "when": true, "which": true, "with": true,
+	"allows": true, "allow": true, "version": true, "versions": true, "before": true, "prior": true,
+	"through": true, "vulnerability": true, "issue": true, "attacker": true, "attackers": true,
+}
+
+func tokenise(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := []string{}
+	for _, field := range fields {
+		if len(field) < 3 || stopwords[field] || isNumeric(field) {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+func isNumeric(term string) bool {
+	for _, r := range term {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewIndex(vulns []vulnerability.Vulnerability, config Config) (*Index, error) {
+	frequencies := make(map[string]int)
+	for _, vuln := range vulns {
+		seen := make(map[string]bool)
+		for _, term := range tokenise(vuln.Description) {
+			if !seen[term] {
+				seen[term] = true
+				frequencies[term]++
+			}
+		}
+	}
+
+	index := &Index{
+		config:    config,
+		documents: make([]document, 0, len(vulns)),
+		byCveId:   make(map[string]int),
+		idf:       make(map[string]float64),
+		total:     len(vulns),
+	}
+	for term, frequency := range frequencies {
+		index.idf[term] = inverseDocumentFrequency(index.total, frequency)
+	}
+	for _, vuln := range vulns {
+		doc := index.document(vuln)
+		index.byCveId[doc.cveId] = len(index.documents)
+		index.documents = append(index.documents, doc)
+	}
+	return index, nil
+}
+
+func MustNewIndex(vulns []vulnerability.Vulnerability, config Config) *Index {
+	index, err := NewIndex(vulns, config)
+	if err != nil {
+		panic(err)
+	}
+	return index
+}
+
+func NewIndexFromRepository(ctx context.Context, repository vulnerability.VulnerabilityRepository, config Config) (*Index, error) {
+	collection, err := repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewIndex(collection.Vulnerabilities, config)
+}
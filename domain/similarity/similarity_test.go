@@ -0,0 +1,65 @@
+package similarity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+var testVulnerabilities = []vulnerability.Vulnerability{
+	{
+		CveId:       "CVE-2021-44228",
+		Description: "Apache Log4j2 JNDI features used in configuration, log messages, and parameters do not protect against attacker controlled LDAP and other JNDI related endpoints.",
+		Cwes:        []vulnerability.Cwe{{Id: "CWE-502"}, {Id: "CWE-917"}},
+		Affected:    []vulnerability.AffectedProduct{{Vendor: "apache", Product: "log4j"}},
+	},
+	{
+		CveId:       "CVE-2021-45046",
+		Description: "The fix to address CVE-2021-44228 in Apache Log4j 2.15.0 was incomplete in certain non-default configurations, allowing JNDI lookup patterns to be abused.",
+		Cwes:        []vulnerability.Cwe{{Id: "CWE-917"}},
+		Affected:    []vulnerability.AffectedProduct{{Vendor: "apache", Product: "log4j"}},
+	},
+	{
+		CveId:       "CVE-2021-3711",
+		Description: "In order to decrypt SM2 encrypted data an application is expected to call the API function EVP_PKEY_decrypt, resulting in a buffer overflow in OpenSSL.",
+		Cwes:        []vulnerability.Cwe{{Id: "CWE-120"}},
+		Affected:    []vulnerability.AffectedProduct{{Vendor: "openssl", Product: "openssl"}},
+	},
+}
+
+func TestRelated(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.MustNewMemoryVulnerabilityRepository()
+	for _, vuln := range testVulnerabilities {
+		if err := repo.Add(ctx, vuln); err != nil {
+			t.Fatalf("failed to add vulnerability: %s", err)
+		}
+	}
+
+	index, err := NewIndexFromRepository(ctx, repo, DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to build index: %s", err)
+	}
+
+	matches, err := index.Related("CVE-2021-44228", 5)
+	if err != nil {
+		t.Fatalf("failed to find related vulnerabilities: %s", err)
+	}
+	if len(matches) != 1 || matches[0].CveId != "CVE-2021-45046" {
+		t.Fatalf("expected only CVE-2021-45046 to be related, got %+v", matches)
+	}
+	if len(matches[0].SharedCwes) != 1 || len(matches[0].SharedProducts) != 1 || matches[0].TextScore <= 0 {
+		t.Errorf("unexpected match breakdown: %+v", matches[0])
+	}
+
+	if _, err := index.Related("CVE-1999-0001", 5); err != ErrVulnerabilityNotIndexed {
+		t.Errorf("expected ErrVulnerabilityNotIndexed, got %v", err)
+	}
+
+	similar := index.Similar(vulnerability.Vulnerability{CveId: "CVE-2022-0778", Description: "A bug in OpenSSL BN_mod_sqrt can loop forever when parsing certificates, a buffer issue."}, 1)
+	if len(similar) != 1 || similar[0].CveId != "CVE-2021-3711" {
+		t.Errorf("expected CVE-2021-3711 to be most similar, got %+v", similar)
+	}
+}
@@ -0,0 +1,97 @@
+package sla
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const Day = 24 * time.Hour
+
+type Policy struct {
+	// Deadlines is the time allowed to remediate, keyed by effective
+	// severity. Severities without an entry carry no SLA.
+	Deadlines map[vulnerability.Severity]time.Duration `json:"deadlines"`
+	// UseKevDueDate applies the KEV catalog dueDate when it is earlier than
+	// the severity deadline.
+	UseKevDueDate bool `json:"useKevDueDate"`
+	// SeverityPolicy decides the effective severity. Without a precedence,
+	// as in a config that leaves it out, the default policy is used.
+	SeverityPolicy vulnerability.SeverityPolicy `json:"severityPolicy"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Deadlines: map[vulnerability.Severity]time.Duration{
+			vulnerability.SeverityCritical: 7 * Day,
+			vulnerability.SeverityHigh:     30 * Day,
+			vulnerability.SeverityMedium:   90 * Day,
+			vulnerability.SeverityLow:      180 * Day,
+		},
+		UseKevDueDate:  true,
+		SeverityPolicy: vulnerability.DefaultSeverityPolicy(),
+	}
+}
+
+// Finding is an occurrence of a vulnerability on an asset. A finding with no
+// asset tracks the vulnerability itself, measured from its publication.
+type Finding struct {
+	CveId        string    `json:"cveId"`
+	Asset        string    `json:"asset,omitempty"`
+	Product      string    `json:"product,omitempty"`
+	DetectedDate time.Time `json:"detectedDate"`
+	ResolvedDate time.Time `json:"resolvedDate"`
+}
+
+func (f Finding) Resolved() bool {
+	return !f.ResolvedDate.IsZero()
+}
+
+type Deadline struct {
+	CveId    string                 `json:"cveId"`
+	Asset    string                 `json:"asset,omitempty"`
+	Severity vulnerability.Severity `json:"severity"`
+	Start    time.Time              `json:"start"`
+	DueDate  time.Time              `json:"dueDate"`
+	Basis    string                 `json:"basis"`
+}
+
+func (d Deadline) Overdue(now time.Time) bool {
+	return now.After(d.DueDate)
+}
+
+func (d Deadline) Remaining(now time.Time) time.Duration {
+	return d.DueDate.Sub(now)
+}
+
+// DueDate computes the remediation deadline for the vulnerability counted from
+// start. It reports false when neither the severity nor KEV imposes one.
+func (p Policy) DueDate(vuln vulnerability.Vulnerability, start time.Time) (Deadline, bool) {
+	severityPolicy := p.SeverityPolicy
+	if len(severityPolicy.Precedence) == 0 {
+		severityPolicy = vulnerability.DefaultSeverityPolicy()
+	}
+	assessment := vuln.EffectiveSeverity(severityPolicy)
+	deadline := Deadline{
+		CveId:    vuln.CveId,
+		Severity: assessment.Severity,
+		Start:    start,
+	}
+
+	allowed, hasSeverityDeadline := p.Deadlines[assessment.Severity]
+	if hasSeverityDeadline {
+		deadline.DueDate = start.Add(allowed)
+		deadline.Basis = fmt.Sprintf("%s severity allows %d days", assessment.Severity, int(allowed/Day))
+	}
+
+	if p.UseKevDueDate && !vuln.Kev.DueDate.IsZero() {
+		if !hasSeverityDeadline || vuln.Kev.DueDate.Before(deadline.DueDate) {
+			deadline.DueDate = vuln.Kev.DueDate
+			deadline.Basis = "known exploited vulnerabilities catalog due date"
+			return deadline, true
+		}
+	}
+
+	return deadline, hasSeverityDeadline
+}
@@ -0,0 +1,108 @@
+package sla
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vex"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+func TestTrackerOverdueAndDueWithin(t *testing.T) {
+	ctx := context.Background()
+	published := time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)
+	repo := memory.MustNewMemoryVulnerabilityRepository()
+	vulns := []vulnerability.Vulnerability{
+		{
+			CveId:         "CVE-2021-44228",
+			PublishedDate: published,
+			Cvss3:         vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0, BaseSeverity: "CRITICAL"},
+		},
+		{
+			CveId:         "CVE-2021-45046",
+			PublishedDate: published,
+			Cvss3:         vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 9.0, BaseSeverity: "CRITICAL"},
+		},
+		{
+			CveId:         "CVE-2021-45105",
+			PublishedDate: published,
+			Cvss3:         vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H", BaseScore: 5.9, BaseSeverity: "MEDIUM"},
+			Kev:           vulnerability.Kev{DateAdded: published, DueDate: published.Add(14 * Day)},
+		},
+	}
+	for _, vuln := range vulns {
+		if err := repo.Add(ctx, vuln); err != nil {
+			t.Fatalf("failed to add vulnerability: %s", err)
+		}
+	}
+
+	document := vex.MustNewDocument("Product Security")
+	statement := vex.MustNewStatement(vulns[1], "pkg:maven/com.example/app@1.0.0", vex.StatusNotAffected)
+	statement.Justification = vex.JustificationVulnerableCodeNotInExecutePath
+	if err := document.AddStatement(statement); err != nil {
+		t.Fatalf("failed to add vex statement: %s", err)
+	}
+
+	tracker := MustNewTracker(repo, DefaultPolicy())
+	tracker.Vex = &document
+	findings := []Finding{
+		{CveId: "CVE-2021-44228", Asset: "web-01", DetectedDate: published.Add(Day)},
+		{CveId: "CVE-2021-45046", Asset: "web-01", Product: "pkg:maven/com.example/app@1.0.0", DetectedDate: published},
+		{CveId: "CVE-2021-45105"},
+	}
+
+	now := published.Add(10 * Day)
+	overdue, err := tracker.Overdue(ctx, findings, now)
+	if err != nil {
+		t.Fatalf("failed to query overdue findings: %s", err)
+	}
+	if len(overdue) != 1 || overdue[0].CveId != "CVE-2021-44228" || !overdue[0].DueDate.Equal(published.Add(8*Day)) {
+		t.Errorf("unexpected overdue findings: %+v", overdue)
+	}
+
+	due, err := tracker.DueWithin(ctx, findings, now, 7*Day)
+	if err != nil {
+		t.Fatalf("failed to query soon to breach findings: %s", err)
+	}
+	if len(due) != 1 || due[0].CveId != "CVE-2021-45105" || due[0].Basis != "known exploited vulnerabilities catalog due date" {
+		t.Errorf("unexpected soon to breach findings: %+v", due)
+	}
+}
+
+func TestPolicyJsonRoundTrip(t *testing.T) {
+	published := time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)
+	vuln := vulnerability.Vulnerability{
+		CveId:         "CVE-2021-44228",
+		PublishedDate: published,
+		Cvss3:         vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0, BaseSeverity: "CRITICAL"},
+	}
+
+	policy := DefaultPolicy()
+	policy.SeverityPolicy.Precedence = []vulnerability.SeveritySource{vulnerability.SeveritySourceCvss3}
+	encoded, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatalf("failed to encode policy: %s", err)
+	}
+	var decoded Policy
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to decode policy: %s", err)
+	}
+	if len(decoded.SeverityPolicy.Precedence) != 1 || decoded.SeverityPolicy.Precedence[0] != vulnerability.SeveritySourceCvss3 {
+		t.Errorf("expected the severity policy to survive the round trip, got %+v", decoded.SeverityPolicy)
+	}
+	if deadline, ok := decoded.DueDate(vuln, published); !ok || !deadline.DueDate.Equal(published.Add(7*Day)) {
+		t.Errorf("expected a critical deadline from the decoded policy, got %+v", deadline)
+	}
+
+	var partial Policy
+	if err := json.NewDecoder(strings.NewReader(`{"deadlines": {"CRITICAL": 259200000000000}}`)).Decode(&partial); err != nil {
+		t.Fatalf("failed to decode policy: %s", err)
+	}
+	if deadline, ok := partial.DueDate(vuln, published); !ok || deadline.Severity != vulnerability.SeverityCritical {
+		t.Errorf("expected a policy without severity precedence to use the default, got %+v", deadline)
+	}
+}
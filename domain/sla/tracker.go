@@ -0,0 +1,104 @@
+package sla
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vex"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type Tracker struct {
+	Repository vulnerability.VulnerabilityRepository
+	Policy     Policy
+	// Vex, when set, excludes findings whose product has been declared not
+	// affected or fixed.
+	Vex *vex.Document
+}
+
+func (t Tracker) Deadline(ctx context.Context, finding Finding) (Deadline, bool, error) {
+	vuln, err := t.Repository.Get(ctx, finding.CveId)
+	if err != nil {
+		return Deadline{}, false, err
+	}
+
+	if t.Vex != nil && finding.Product != "" && !t.Vex.Affects(*vuln, finding.Product) {
+		return Deadline{}, false, nil
+	}
+
+	start := finding.DetectedDate
+	if start.IsZero() {
+		start = vuln.PublishedDate
+	}
+	deadline, ok := t.Policy.DueDate(*vuln, start)
+	deadline.Asset = finding.Asset
+	return deadline, ok, nil
+}
+
+// Deadlines returns the deadlines for every open finding that carries one,
+// ordered by due date.
+func (t Tracker) Deadlines(ctx context.Context, findings []Finding) ([]Deadline, error) {
+	deadlines := []Deadline{}
+	for _, finding := range findings {
+		if finding.Resolved() {
+			continue
+		}
+		deadline, ok, err := t.Deadline(ctx, finding)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deadlines = append(deadlines, deadline)
+		}
+	}
+	sort.SliceStable(deadlines, func(i, j int) bool {
+		return deadlines[i].DueDate.Before(deadlines[j].DueDate)
+	})
+	return deadlines, nil
+}
+
+func (t Tracker) Overdue(ctx context.Context, findings []Finding, now time.Time) ([]Deadline, error) {
+	deadlines, err := t.Deadlines(ctx, findings)
+	if err != nil {
+		return nil, err
+	}
+	overdue := []Deadline{}
+	for _, deadline := range deadlines {
+		if deadline.Overdue(now) {
+			overdue = append(overdue, deadline)
+		}
+	}
+	return overdue, nil
+}
+
+// DueWithin returns the open deadlines that have not yet passed but will
+// within the window.
+func (t Tracker) DueWithin(ctx context.Context, findings []Finding, now time.Time, window time.Duration) ([]Deadline, error) {
+	deadlines, err := t.Deadlines(ctx, findings)
+	if err != nil {
+		return nil, err
+	}
+	due := []Deadline{}
+	for _, deadline := range deadlines {
+		if !deadline.Overdue(now) && deadline.Remaining(now) <= window {
+			due = append(due, deadline)
+		}
+	}
+	return due, nil
+}
+
+func NewTracker(repository vulnerability.VulnerabilityRepository, policy Policy) (Tracker, error) {
+	return Tracker{
+		Repository: repository,
+		Policy:     policy,
+	}, nil
+}
+
+func MustNewTracker(repository vulnerability.VulnerabilityRepository, policy Policy) Tracker {
+	tracker, err := NewTracker(repository, policy)
+	if err != nil {
+		panic(err)
+	}
+	return tracker
+}
@@ -0,0 +1,105 @@
+package ssvc
+
+const (
+	PointExploitation     = "exploitation"
+	PointAutomatable      = "automatable"
+	PointTechnicalImpact  = "technicalImpact"
+	PointMissionWellbeing = "missionWellbeing"
+
+	PointMissionPrevalence = "missionPrevalence"
+	PointPublicWellbeing   = "publicWellbeing"
+)
+
+const (
+	ExploitationNone   = "none"
+	ExploitationPoc    = "poc"
+	ExploitationActive = "active"
+
+	AutomatableNo  = "no"
+	AutomatableYes = "yes"
+
+	TechnicalImpactPartial = "partial"
+	TechnicalImpactTotal   = "total"
+
+	MissionWellbeingLow    = "low"
+	MissionWellbeingMedium = "medium"
+	MissionWellbeingHigh   = "high"
+
+	MissionPrevalenceMinimal   = "minimal"
+	MissionPrevalenceSupport   = "support"
+	MissionPrevalenceEssential = "essential"
+
+	PublicWellbeingMinimal      = "minimal"
+	PublicWellbeingMaterial     = "material"
+	PublicWellbeingIrreversible = "irreversible"
+)
+
+var (
+	ExploitationPoint     = DecisionPoint{Name: PointExploitation, Values: []string{ExploitationNone, ExploitationPoc, ExploitationActive}}
+	AutomatablePoint      = DecisionPoint{Name: PointAutomatable, Values: []string{AutomatableNo, AutomatableYes}}
+	TechnicalImpactPoint  = DecisionPoint{Name: PointTechnicalImpact, Values: []string{TechnicalImpactPartial, TechnicalImpactTotal}}
+	MissionWellbeingPoint = DecisionPoint{Name: PointMissionWellbeing, Values: []string{MissionWellbeingLow, MissionWellbeingMedium, MissionWellbeingHigh}}
+
+	MissionPrevalencePoint = DecisionPoint{Name: PointMissionPrevalence, Values: []string{MissionPrevalenceMinimal, MissionPrevalenceSupport, MissionPrevalenceEssential}}
+	PublicWellbeingPoint   = DecisionPoint{Name: PointPublicWellbeing, Values: []string{PublicWellbeingMinimal, PublicWellbeingMaterial, PublicWellbeingIrreversible}}
+)
+
+// MissionWellbeing combines mission prevalence and public well-being impact
+// as described in the CISA SSVC guide.
+func MissionWellbeing(missionPrevalence string, publicWellbeing string) string {
+	if missionPrevalence == MissionPrevalenceEssential || publicWellbeing == PublicWellbeingIrreversible {
+		return MissionWellbeingHigh
+	}
+	if missionPrevalence == MissionPrevalenceSupport || publicWellbeing == PublicWellbeingMaterial {
+		return MissionWellbeingMedium
+	}
+	return MissionWellbeingLow
+}
+
+// CisaDeployerTree is the decision tree published in the CISA SSVC guide.
+func CisaDeployerTree() Tree {
+	rules := []Rule{
+		{[]string{"none", "no", "partial", "low"}, DecisionTrack},
+		{[]string{"none", "no", "partial", "medium"}, DecisionTrack},
+		{[]string{"none", "no", "partial", "high"}, DecisionTrack},
+		{[]string{"none", "no", "total", "low"}, DecisionTrack},
+		{[]string{"none", "no", "total", "medium"}, DecisionTrack},
+		{[]string{"none", "no", "total", "high"}, DecisionTrackStar},
+		{[]string{"none", "yes", "partial", "low"}, DecisionTrack},
+		{[]string{"none", "yes", "partial", "medium"}, DecisionTrack},
+		{[]string{"none", "yes", "partial", "high"}, DecisionAttend},
+		{[]string{"none", "yes", "total", "low"}, DecisionTrack},
+		{[]string{"none", "yes", "total", "medium"}, DecisionTrack},
+		{[]string{"none", "yes", "total", "high"}, DecisionAttend},
+		{[]string{"poc", "no", "partial", "low"}, DecisionTrack},
+		{[]string{"poc", "no", "partial", "medium"}, DecisionTrack},
+		{[]string{"poc", "no", "partial", "high"}, DecisionTrackStar},
+		{[]string{"poc", "no", "total", "low"}, DecisionTrack},
+		{[]string{"poc", "no", "total", "medium"}, DecisionTrackStar},
+		{[]string{"poc", "no", "total", "high"}, DecisionAttend},
+		{[]string{"poc", "yes", "partial", "low"}, DecisionTrack},
+		{[]string{"poc", "yes", "partial", "medium"}, DecisionTrack},
+		{[]string{"poc", "yes", "partial", "high"}, DecisionAttend},
+		{[]string{"poc", "yes", "total", "low"}, DecisionTrack},
+		{[]string{"poc", "yes", "total", "medium"}, DecisionTrackStar},
+		{[]string{"poc", "yes", "total", "high"}, DecisionAttend},
+		{[]string{"active", "no", "partial", "low"}, DecisionTrack},
+		{[]string{"active", "no", "partial", "medium"}, DecisionTrack},
+		{[]string{"active", "no", "partial", "high"}, DecisionAttend},
+		{[]string{"active", "no", "total", "low"}, DecisionTrack},
+		{[]string{"active", "no", "total", "medium"}, DecisionAttend},
+		{[]string{"active", "no", "total", "high"}, DecisionAct},
+		{[]string{"active", "yes", "partial", "low"}, DecisionAttend},
+		{[]string{"active", "yes", "partial", "medium"}, DecisionAttend},
+		{[]string{"active", "yes", "partial", "high"}, DecisionAct},
+		{[]string{"active", "yes", "total", "low"}, DecisionAttend},
+		{[]string{"active", "yes", "total", "medium"}, DecisionAct},
+		{[]string{"active", "yes", "total", "high"}, DecisionAct},
+	}
+	return MustNewTree("cisa-deployer", []DecisionPoint{
+		ExploitationPoint,
+		AutomatablePoint,
+		TechnicalImpactPoint,
+		MissionWellbeingPoint,
+	}, rules)
+}
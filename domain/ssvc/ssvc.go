@@ -0,0 +1,201 @@
+package ssvc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type Inputs struct {
+	Exploitation      string `json:"exploitation"`
+	Automatable       string `json:"automatable"`
+	TechnicalImpact   string `json:"technicalImpact"`
+	MissionPrevalence string `json:"missionPrevalence"`
+	PublicWellbeing   string `json:"publicWellbeing"`
+}
+
+func (i Inputs) Map() map[string]string {
+	outMap := make(map[string]string)
+	outMap[PointExploitation] = i.Exploitation
+	outMap[PointAutomatable] = i.Automatable
+	outMap[PointTechnicalImpact] = i.TechnicalImpact
+	outMap[PointMissionWellbeing] = MissionWellbeing(i.MissionPrevalence, i.PublicWellbeing)
+	outMap[PointMissionPrevalence] = i.MissionPrevalence
+	outMap[PointPublicWellbeing] = i.PublicWellbeing
+	return outMap
+}
+
+type Assessment struct {
+	CveId     string   `json:"cveId"`
+	Tree      string   `json:"tree"`
+	Decision  Decision `json:"decision"`
+	Inputs    Inputs   `json:"inputs"`
+	Rationale []string `json:"rationale"`
+}
+
+type Evaluator struct {
+	Tree Tree
+	// MissionPrevalence and PublicWellbeing describe the deployer's own
+	// context and cannot be derived from vulnerability data.
+	MissionPrevalence string
+	PublicWellbeing   string
+	// CveTopicName is the indicator topic carrying CVE mentions, and PocTags
+	// the indicator tags that signal a public proof of concept.
+	CveTopicName string
+	PocTags      []string
+}
+
+// Derive fills the decision inputs from the vulnerability and the indicators
+// mentioning it, recording why each value was chosen.
+func (e Evaluator) Derive(vuln vulnerability.Vulnerability, indicators []indicator.Indicator) (Inputs, []string) {
+	rationale := []string{}
+	inputs := Inputs{
+		MissionPrevalence: e.MissionPrevalence,
+		PublicWellbeing:   e.PublicWellbeing,
+	}
+
+	exploitation, reason := e.deriveExploitation(vuln, indicators)
+	inputs.Exploitation = exploitation
+	rationale = append(rationale, reason)
+
+	automatable, reason := deriveAutomatable(vuln)
+	inputs.Automatable = automatable
+	rationale = append(rationale, reason)
+
+	technicalImpact, reason := deriveTechnicalImpact(vuln)
+	inputs.TechnicalImpact = technicalImpact
+	rationale = append(rationale, reason)
+
+	rationale = append(rationale, fmt.Sprintf("mission and well-being %s from %s mission prevalence and %s public well-being impact",
+		MissionWellbeing(inputs.MissionPrevalence, inputs.PublicWellbeing), inputs.MissionPrevalence, inputs.PublicWellbeing))
+
+	return inputs, rationale
+}
+
+func (e Evaluator) Decide(cveId string, inputs Inputs, rationale []string) (Assessment, error) {
+	decision, err := e.Tree.Evaluate(inputs.Map())
+	if err != nil {
+		return Assessment{}, err
+	}
+	return Assessment{
+		CveId:     cveId,
+		Tree:      e.Tree.Name,
+		Decision:  decision,
+		Inputs:    inputs,
+		Rationale: append(rationale, fmt.Sprintf("%s tree decision %s", e.Tree.Name, decision)),
+	}, nil
+}
+
+func (e Evaluator) Evaluate(vuln vulnerability.Vulnerability, indicators []indicator.Indicator) (Assessment, error) {
+	inputs, rationale := e.Derive(vuln, indicators)
+	return e.Decide(vuln.CveId, inputs, rationale)
+}
+
+func (e Evaluator) deriveExploitation(vuln vulnerability.Vulnerability, indicators []indicator.Indicator) (string, string) {
+	if vuln.Kev.Listed() {
+		return ExploitationActive, "exploitation active: listed in the known exploited vulnerabilities catalog"
+	}
+	if vuln.ExploitAvailable() {
+		return ExploitationPoc, "exploitation poc: a reference is tagged as an exploit"
+	}
+
+	collection := indicator.IndicatorCollection{Indicators: indicators}
+	mentioning := collection.Mentioning(indicator.MustNewMention(e.CveTopicName, []byte(vuln.CveId)))
+	for _, mention := range mentioning.Indicators {
+		for _, tag := range mention.Tags {
+			for _, pocTag := range e.PocTags {
+				if strings.EqualFold(tag, pocTag) {
+					return ExploitationPoc, fmt.Sprintf("exploitation poc: indicator %s is tagged %q", mention.Id, tag)
+				}
+			}
+		}
+	}
+	return ExploitationNone, "exploitation none: no known exploitation or public exploit"
+}
+
+func deriveAutomatable(vuln vulnerability.Vulnerability) (string, string) {
+	metrics, version := cvssMetrics(vuln)
+	if metrics == nil {
+		return AutomatableNo, "automatable no: no cvss vector to assess"
+	}
+	automatable := metrics["AV"] == "N" && metrics["AC"] == "L" && metrics["PR"] == "N" && metrics["UI"] == "N"
+	if version == "cvss4" {
+		automatable = automatable && metrics["AT"] == "N"
+	}
+	if automatable {
+		return AutomatableYes, fmt.Sprintf("automatable yes: %s vector is network reachable with low complexity and no privileges or interaction", version)
+	}
+	return AutomatableNo, fmt.Sprintf("automatable no: %s vector requires local access, privileges, interaction or complex conditions", version)
+}
+
+func deriveTechnicalImpact(vuln vulnerability.Vulnerability) (string, string) {
+	metrics, version := cvssMetrics(vuln)
+	if metrics == nil {
+		return TechnicalImpactPartial, "technical impact partial: no cvss vector to assess"
+	}
+	confidentiality, integrity := metrics["C"], metrics["I"]
+	if version == "cvss4" {
+		confidentiality, integrity = metrics["VC"], metrics["VI"]
+	}
+	if confidentiality == "H" && integrity == "H" {
+		return TechnicalImpactTotal, fmt.Sprintf("technical impact total: %s vector has high confidentiality and integrity impact", version)
+	}
+	return TechnicalImpactPartial, fmt.Sprintf("technical impact partial: %s vector does not give total control", version)
+}
+
+// cvssMetrics parses the newest available CVSS vector into its metrics.
+func cvssMetrics(vuln vulnerability.Vulnerability) (map[string]string, string) {
+	vectors := []struct {
+		version string
+		vector  string
+	}{
+		{"cvss4", vuln.Cvss4.CvssVector},
+		{"cvss3", vuln.Cvss3.CvssVector},
+	}
+	for _, candidate := range vectors {
+		if candidate.vector == "" {
+			continue
+		}
+		metrics := make(map[string]string)
+		for _, part := range strings.Split(candidate.vector, "/") {
+			keyValue := strings.SplitN(part, ":", 2)
+			if len(keyValue) == 2 {
+				metrics[keyValue[0]] = keyValue[1]
+			}
+		}
+		return metrics, candidate.version
+	}
+	return nil, ""
+}
+
+func NewEvaluator(tree Tree, missionPrevalence string, publicWellbeing string) (Evaluator, error) {
+	if missionPrevalence == "" {
+		missionPrevalence = MissionPrevalenceSupport
+	}
+	if publicWellbeing == "" {
+		publicWellbeing = PublicWellbeingMinimal
+	}
+	if !MissionPrevalencePoint.Valid(missionPrevalence) {
+		return Evaluator{}, fmt.Errorf("%w: %s=%s", ErrInvalidValue, PointMissionPrevalence, missionPrevalence)
+	}
+	if !PublicWellbeingPoint.Valid(publicWellbeing) {
+		return Evaluator{}, fmt.Errorf("%w: %s=%s", ErrInvalidValue, PointPublicWellbeing, publicWellbeing)
+	}
+	return Evaluator{
+		Tree:              tree,
+		MissionPrevalence: missionPrevalence,
+		PublicWellbeing:   publicWellbeing,
+		CveTopicName:      "cve",
+		PocTags:           []string{"exploit", "poc"},
+	}, nil
+}
+
+func MustNewEvaluator(tree Tree, missionPrevalence string, publicWellbeing string) Evaluator {
+	evaluator, err := NewEvaluator(tree, missionPrevalence, publicWellbeing)
+	if err != nil {
+		panic(err)
+	}
+	return evaluator
+}
@@ -0,0 +1,52 @@
+package ssvc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func TestEvaluateDerivesInputs(t *testing.T) {
+	evaluator := MustNewEvaluator(CisaDeployerTree(), MissionPrevalenceEssential, PublicWellbeingMinimal)
+
+	log4shell := vulnerability.Vulnerability{
+		CveId: "CVE-2021-44228",
+		Cvss3: vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"},
+		Kev:   vulnerability.Kev{DateAdded: time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	assessment, err := evaluator.Evaluate(log4shell, nil)
+	if err != nil {
+		t.Fatalf("failed to evaluate: %s", err)
+	}
+	if assessment.Decision != DecisionAct {
+		t.Errorf("expected Act, got %s (%v)", assessment.Decision, assessment.Rationale)
+	}
+
+	local := vulnerability.Vulnerability{
+		CveId: "CVE-2022-0001",
+		Cvss3: vulnerability.Cvss3{CvssVector: "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N"},
+	}
+	poc := indicator.MustNewIndicatorFactory("reddit").MustNewIndicator()
+	poc.AddMention(indicator.MustNewMention("cve", []byte("CVE-2022-0001")))
+	poc.AddTag("poc")
+	assessment, err = evaluator.Evaluate(local, []indicator.Indicator{poc})
+	if err != nil {
+		t.Fatalf("failed to evaluate: %s", err)
+	}
+	if assessment.Inputs.Exploitation != ExploitationPoc || assessment.Inputs.Automatable != AutomatableNo || assessment.Inputs.TechnicalImpact != TechnicalImpactPartial {
+		t.Errorf("unexpected derived inputs: %+v", assessment.Inputs)
+	}
+	if assessment.Decision != DecisionTrackStar {
+		t.Errorf("expected Track*, got %s", assessment.Decision)
+	}
+}
+
+func TestNewTreeRequiresCompleteCoverage(t *testing.T) {
+	_, err := NewTree("partial", []DecisionPoint{AutomatablePoint}, []Rule{{Values: []string{AutomatableYes}, Decision: DecisionAct}})
+	if !errors.Is(err, ErrIncompleteTree) {
+		t.Errorf("expected ErrIncompleteTree, got %v", err)
+	}
+}
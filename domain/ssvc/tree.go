@@ -0,0 +1,110 @@
+package ssvc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidValue   = errors.New("the value is not valid for the decision point")
+	ErrMissingInput   = errors.New("a decision point has no input value")
+	ErrIncompleteTree = errors.New("the decision tree does not cover every combination of values")
+)
+
+type Decision string
+
+const (
+	DecisionTrack     Decision = "Track"
+	DecisionTrackStar Decision = "Track*"
+	DecisionAttend    Decision = "Attend"
+	DecisionAct       Decision = "Act"
+)
+
+type DecisionPoint struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+func (dp DecisionPoint) Valid(value string) bool {
+	for _, candidate := range dp.Values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+type Rule struct {
+	Values   []string `json:"values"`
+	Decision Decision `json:"decision"`
+}
+
+type Tree struct {
+	Name     string
+	Points   []DecisionPoint
+	outcomes map[string]Decision
+}
+
+func (t Tree) Evaluate(inputs map[string]string) (Decision, error) {
+	values := make([]string, 0, len(t.Points))
+	for _, point := range t.Points {
+		value, ok := inputs[point.Name]
+		if !ok || value == "" {
+			return "", fmt.Errorf("%w: %s", ErrMissingInput, point.Name)
+		}
+		if !point.Valid(value) {
+			return "", fmt.Errorf("%w: %s=%s", ErrInvalidValue, point.Name, value)
+		}
+		values = append(values, value)
+	}
+	decision, ok := t.outcomes[ruleKey(values)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrIncompleteTree, strings.Join(values, ", "))
+	}
+	return decision, nil
+}
+
+func ruleKey(values []string) string {
+	return strings.Join(values, "/")
+}
+
+// combinations counts the leaves a complete tree over the points must have.
+func combinations(points []DecisionPoint) int {
+	total := 1
+	for _, point := range points {
+		total *= len(point.Values)
+	}
+	return total
+}
+
+func NewTree(name string, points []DecisionPoint, rules []Rule) (Tree, error) {
+	outcomes := make(map[string]Decision)
+	for _, rule := range rules {
+		if len(rule.Values) != len(points) {
+			return Tree{}, fmt.Errorf("rule %v has %d values, the tree has %d decision points", rule.Values, len(rule.Values), len(points))
+		}
+		for i, value := range rule.Values {
+			if !points[i].Valid(value) {
+				return Tree{}, fmt.Errorf("%w: %s=%s", ErrInvalidValue, points[i].Name, value)
+			}
+		}
+		outcomes[ruleKey(rule.Values)] = rule.Decision
+	}
+	if len(outcomes) != combinations(points) {
+		return Tree{}, fmt.Errorf("%w: %d of %d combinations defined", ErrIncompleteTree, len(outcomes), combinations(points))
+	}
+	return Tree{
+		Name:     name,
+		Points:   points,
+		outcomes: outcomes,
+	}, nil
+}
+
+func MustNewTree(name string, points []DecisionPoint, rules []Rule) Tree {
+	tree, err := NewTree(name, points, rules)
+	if err != nil {
+		panic(err)
+	}
+	return tree
+}
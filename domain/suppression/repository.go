@@ -0,0 +1,205 @@
+package suppression
+
+import (
+	"context"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+// SuppressedIndicatorRepository hides suppressed indicators from reads on the
+// wrapped repository, reporting them as not found. Writes pass through.
+type SuppressedIndicatorRepository struct {
+	Repository indicator.IndicatorRepository
+	Rules      *RuleSet
+	Now        func() time.Time
+}
+
+func (sir SuppressedIndicatorRepository) Add(ctx context.Context, i indicator.Indicator) error {
+	return sir.Repository.Add(ctx, i)
+}
+
+func (sir SuppressedIndicatorRepository) Update(ctx context.Context, id string, i *indicator.Indicator) error {
+	return sir.Repository.Update(ctx, id, i)
+}
+
+func (sir SuppressedIndicatorRepository) Upsert(ctx context.Context, i indicator.Indicator) (*indicator.Indicator, error) {
+	return sir.Repository.Upsert(ctx, i)
+}
+
+func (sir SuppressedIndicatorRepository) Delete(ctx context.Context, id string) error {
+	return sir.Repository.Delete(ctx, id)
+}
+
+func (sir SuppressedIndicatorRepository) GetById(ctx context.Context, id string) (*indicator.Indicator, error) {
+	return sir.visible(sir.Repository.GetById(ctx, id))
+}
+
+func (sir SuppressedIndicatorRepository) GetByLink(ctx context.Context, link string) (*indicator.Indicator, error) {
+	return sir.visible(sir.Repository.GetByLink(ctx, link))
+}
+
+func (sir SuppressedIndicatorRepository) GetByTopic(ctx context.Context, topicName string) (*indicator.IndicatorCollection, error) {
+	return sir.visibleCollection(sir.Repository.GetByTopic(ctx, topicName))
+}
+
+func (sir SuppressedIndicatorRepository) GetByMention(ctx context.Context, mention indicator.Mention) (*indicator.IndicatorCollection, error) {
+	return sir.visibleCollection(sir.Repository.GetByMention(ctx, mention))
+}
+
+func (sir SuppressedIndicatorRepository) GetBySource(ctx context.Context, source string) (*indicator.IndicatorCollection, error) {
+	return sir.visibleCollection(sir.Repository.GetBySource(ctx, source))
+}
+
+func (sir SuppressedIndicatorRepository) GetBySourceId(ctx context.Context, source string, sourceId string) (*indicator.Indicator, error) {
+	return sir.visible(sir.Repository.GetBySourceId(ctx, source, sourceId))
+}
+
+// GetLatest returns the newest indicator that is not suppressed, paging
+// back from the newest while they are.
+func (sir SuppressedIndicatorRepository) GetLatest(ctx context.Context) (*indicator.Indicator, error) {
+	query := indicator.Query{SortBy: indicator.SortByCreatedDate, Descending: true, Limit: indicator.DefaultPageSize}
+	for {
+		page, err := sir.Repository.Find(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range page.Indicators {
+			if _, suppressed := sir.Rules.SuppressingIndicator(i, sir.Now()); !suppressed {
+				return &i, nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil, indicator.ErrIndicatorNotFound
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (sir SuppressedIndicatorRepository) GetBetween(ctx context.Context, start time.Time, end time.Time) (*indicator.IndicatorCollection, error) {
+	return sir.visibleCollection(sir.Repository.GetBetween(ctx, start, end))
+}
+
+// Find drops suppressed indicators from the page, so pages can come back
+// shorter than the query's limit while a NextCursor remains.
+func (sir SuppressedIndicatorRepository) Find(ctx context.Context, query indicator.Query) (*indicator.Page, error) {
+	page, err := sir.Repository.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &indicator.Page{
+		Indicators: sir.Rules.FilterIndicators(page.Indicators, sir.Now()),
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+func (sir SuppressedIndicatorRepository) Stream(ctx context.Context, query indicator.Query) <-chan indicator.StreamResult {
+	results := make(chan indicator.StreamResult)
+	go func() {
+		defer close(results)
+		for result := range sir.Repository.Stream(ctx, query) {
+			if result.Err == nil {
+				if _, suppressed := sir.Rules.SuppressingIndicator(result.Indicator, sir.Now()); suppressed {
+					continue
+				}
+			}
+			if !indicator.SendStreamResult(ctx, results, result) {
+				return
+			}
+		}
+	}()
+	return results
+}
+
+func (sir SuppressedIndicatorRepository) visible(i *indicator.Indicator, err error) (*indicator.Indicator, error) {
+	if err != nil || i == nil {
+		return i, err
+	}
+	if _, suppressed := sir.Rules.SuppressingIndicator(*i, sir.Now()); suppressed {
+		return nil, indicator.ErrIndicatorNotFound
+	}
+	return i, nil
+}
+
+func (sir SuppressedIndicatorRepository) visibleCollection(collection *indicator.IndicatorCollection, err error) (*indicator.IndicatorCollection, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &indicator.IndicatorCollection{
+		Indicators: sir.Rules.FilterIndicators(collection.Indicators, sir.Now()),
+	}, nil
+}
+
+func NewSuppressedIndicatorRepository(repository indicator.IndicatorRepository, rules *RuleSet) (indicator.IndicatorRepository, error) {
+	return SuppressedIndicatorRepository{
+		Repository: repository,
+		Rules:      rules,
+		Now:        time.Now,
+	}, nil
+}
+
+func MustNewSuppressedIndicatorRepository(repository indicator.IndicatorRepository, rules *RuleSet) indicator.IndicatorRepository {
+	repo, err := NewSuppressedIndicatorRepository(repository, rules)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
+
+// SuppressedVulnerabilityRepository hides suppressed vulnerabilities from
+// reads on the wrapped repository, reporting them as not found.
+type SuppressedVulnerabilityRepository struct {
+	Repository vulnerability.VulnerabilityRepository
+	Rules      *RuleSet
+	Now        func() time.Time
+}
+
+func (svr SuppressedVulnerabilityRepository) Get(ctx context.Context, cveId string) (*vulnerability.Vulnerability, error) {
+	vuln, err := svr.Repository.Get(ctx, cveId)
+	if err != nil {
+		return nil, err
+	}
+	if _, suppressed := svr.Rules.SuppressingVulnerability(*vuln, svr.Now()); suppressed {
+		return nil, vulnerability.ErrVulnerabilityNotFound
+	}
+	return vuln, nil
+}
+
+func (svr SuppressedVulnerabilityRepository) Add(ctx context.Context, vuln vulnerability.Vulnerability) error {
+	return svr.Repository.Add(ctx, vuln)
+}
+
+func (svr SuppressedVulnerabilityRepository) Update(ctx context.Context, cveId string, vuln *vulnerability.Vulnerability) error {
+	return svr.Repository.Update(ctx, cveId, vuln)
+}
+
+func (svr SuppressedVulnerabilityRepository) Delete(ctx context.Context, cveId string) error {
+	return svr.Repository.Delete(ctx, cveId)
+}
+
+func (svr SuppressedVulnerabilityRepository) List(ctx context.Context) (*vulnerability.VulnerabilityCollection, error) {
+	collection, err := svr.Repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &vulnerability.VulnerabilityCollection{
+		Vulnerabilities: svr.Rules.FilterVulnerabilities(collection.Vulnerabilities, svr.Now()),
+	}, nil
+}
+
+func NewSuppressedVulnerabilityRepository(repository vulnerability.VulnerabilityRepository, rules *RuleSet) (vulnerability.VulnerabilityRepository, error) {
+	return SuppressedVulnerabilityRepository{
+		Repository: repository,
+		Rules:      rules,
+		Now:        time.Now,
+	}, nil
+}
+
+func MustNewSuppressedVulnerabilityRepository(repository vulnerability.VulnerabilityRepository, rules *RuleSet) vulnerability.VulnerabilityRepository {
+	repo, err := NewSuppressedVulnerabilityRepository(repository, rules)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
@@ -0,0 +1,129 @@
+package suppression
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type RuleSet struct {
+	rules map[string]Rule
+	lock  *sync.RWMutex
+}
+
+func (rs *RuleSet) Add(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if _, ok := rs.rules[rule.Id]; ok {
+		return ErrRuleAlreadyExists
+	}
+	rs.rules[rule.Id] = rule
+	return nil
+}
+
+func (rs *RuleSet) Remove(id string) error {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if _, ok := rs.rules[id]; !ok {
+		return ErrRuleNotFound
+	}
+	delete(rs.rules, id)
+	return nil
+}
+
+// Rules returns every rule, including expired ones, ordered by expiry date.
+func (rs *RuleSet) Rules() []Rule {
+	return rs.filter(func(Rule) bool { return true })
+}
+
+func (rs *RuleSet) Active(now time.Time) []Rule {
+	return rs.filter(func(rule Rule) bool { return rule.Active(now) })
+}
+
+func (rs *RuleSet) Expired(now time.Time) []Rule {
+	return rs.filter(func(rule Rule) bool { return !rule.Active(now) })
+}
+
+// Expiring reports the active rules that will expire within the window.
+func (rs *RuleSet) Expiring(now time.Time, window time.Duration) []Rule {
+	return rs.filter(func(rule Rule) bool {
+		return rule.Active(now) && !rule.ExpiryDate.After(now.Add(window))
+	})
+}
+
+func (rs *RuleSet) filter(match func(Rule) bool) []Rule {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	rules := []Rule{}
+	for _, rule := range rs.rules {
+		if match(rule) {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if !rules[i].ExpiryDate.Equal(rules[j].ExpiryDate) {
+			return rules[i].ExpiryDate.Before(rules[j].ExpiryDate)
+		}
+		return rules[i].Id < rules[j].Id
+	})
+	return rules
+}
+
+func (rs *RuleSet) SuppressingVulnerability(vuln vulnerability.Vulnerability, now time.Time) (Rule, bool) {
+	for _, rule := range rs.Active(now) {
+		if rule.MatchesVulnerability(vuln) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (rs *RuleSet) SuppressingIndicator(i indicator.Indicator, now time.Time) (Rule, bool) {
+	for _, rule := range rs.Active(now) {
+		if rule.MatchesIndicator(i) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (rs *RuleSet) FilterVulnerabilities(vulns []vulnerability.Vulnerability, now time.Time) []vulnerability.Vulnerability {
+	kept := []vulnerability.Vulnerability{}
+	for _, vuln := range vulns {
+		if _, suppressed := rs.SuppressingVulnerability(vuln, now); !suppressed {
+			kept = append(kept, vuln)
+		}
+	}
+	return kept
+}
+
+func (rs *RuleSet) FilterIndicators(indicators []indicator.Indicator, now time.Time) []indicator.Indicator {
+	kept := []indicator.Indicator{}
+	for _, i := range indicators {
+		if _, suppressed := rs.SuppressingIndicator(i, now); !suppressed {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+func NewRuleSet() (*RuleSet, error) {
+	return &RuleSet{
+		rules: make(map[string]Rule),
+		lock:  &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewRuleSet() *RuleSet {
+	ruleSet, err := NewRuleSet()
+	if err != nil {
+		panic(err)
+	}
+	return ruleSet
+}
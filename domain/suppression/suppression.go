@@ -0,0 +1,158 @@
+package suppression
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRuleNotFound      = errors.New("the suppression rule was not found")
+	ErrRuleAlreadyExists = errors.New("the suppression rule already exists")
+	ErrNoCriteria        = errors.New("a suppression rule needs at least one criterion")
+	ErrMixedCriteria     = errors.New("a suppression rule cannot combine vulnerability and indicator criteria")
+	ErrMissingReason     = errors.New("a suppression rule needs a reason and an owner")
+	ErrMissingExpiry     = errors.New("a suppression rule needs an expiry date")
+)
+
+// Rule suppresses the vulnerabilities or indicators matching every criterion
+// it sets. CveId, Cwe and Product match vulnerabilities; IndicatorSource, Tag
+// and Mention match indicators.
+type Rule struct {
+	Id              string             `json:"id"`
+	CveId           string             `json:"cveId,omitempty"`
+	Cwe             string             `json:"cwe,omitempty"`
+	Product         string             `json:"product,omitempty"`
+	IndicatorSource string             `json:"indicatorSource,omitempty"`
+	Tag             string             `json:"tag,omitempty"`
+	Mention         *indicator.Mention `json:"mention,omitempty"`
+	Reason          string             `json:"reason"`
+	Owner           string             `json:"owner"`
+	CreatedDate     time.Time          `json:"createdDate"`
+	ExpiryDate      time.Time          `json:"expiryDate"`
+}
+
+func (r Rule) hasVulnerabilityCriteria() bool {
+	return r.CveId != "" || r.Cwe != "" || r.Product != ""
+}
+
+func (r Rule) hasIndicatorCriteria() bool {
+	return r.IndicatorSource != "" || r.Tag != "" || r.Mention != nil
+}
+
+func (r Rule) Validate() error {
+	if !r.hasVulnerabilityCriteria() && !r.hasIndicatorCriteria() {
+		return ErrNoCriteria
+	}
+	if r.hasVulnerabilityCriteria() && r.hasIndicatorCriteria() {
+		return ErrMixedCriteria
+	}
+	if r.Reason == "" || r.Owner == "" {
+		return ErrMissingReason
+	}
+	if r.ExpiryDate.IsZero() {
+		return ErrMissingExpiry
+	}
+	return nil
+}
+
+func (r Rule) Active(now time.Time) bool {
+	return now.Before(r.ExpiryDate)
+}
+
+func (r Rule) MatchesVulnerability(vuln vulnerability.Vulnerability) bool {
+	if !r.hasVulnerabilityCriteria() {
+		return false
+	}
+	if r.CveId != "" && !strings.EqualFold(r.CveId, vuln.CveId) {
+		return false
+	}
+	if r.Cwe != "" && !hasCwe(vuln, r.Cwe) {
+		return false
+	}
+	if r.Product != "" && !hasProduct(vuln, r.Product) {
+		return false
+	}
+	return true
+}
+
+func hasCwe(vuln vulnerability.Vulnerability, cwe string) bool {
+	for _, existing := range vuln.Cwes {
+		if strings.EqualFold(existing.Id, cwe) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProduct accepts either a bare product name or a vendor:product key.
+func hasProduct(vuln vulnerability.Vulnerability, product string) bool {
+	for _, affected := range vuln.Affected {
+		if strings.EqualFold(affected.Product, product) || strings.EqualFold(affected.Vendor+":"+affected.Product, product) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) MatchesIndicator(i indicator.Indicator) bool {
+	if !r.hasIndicatorCriteria() {
+		return false
+	}
+	if r.IndicatorSource != "" && !strings.EqualFold(r.IndicatorSource, i.Source) {
+		return false
+	}
+	if r.Tag != "" && !hasTag(i, r.Tag) {
+		return false
+	}
+	if r.Mention != nil && !hasMention(i, *r.Mention) {
+		return false
+	}
+	return true
+}
+
+func hasTag(i indicator.Indicator, tag string) bool {
+	for _, existing := range i.Tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMention(i indicator.Indicator, mention indicator.Mention) bool {
+	for _, existing := range i.Mentions {
+		if existing.Equal(mention) {
+			return true
+		}
+	}
+	return false
+}
+
+func NewRule(reason string, owner string, expiryDate time.Time) (Rule, error) {
+	if reason == "" || owner == "" {
+		return Rule{}, ErrMissingReason
+	}
+	if expiryDate.IsZero() {
+		return Rule{}, ErrMissingExpiry
+	}
+	return Rule{
+		Id:          uuid.New().String(),
+		Reason:      reason,
+		Owner:       owner,
+		CreatedDate: time.Now().UTC(),
+		ExpiryDate:  expiryDate,
+	}, nil
+}
+
+func MustNewRule(reason string, owner string, expiryDate time.Time) Rule {
+	rule, err := NewRule(reason, owner, expiryDate)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
@@ -0,0 +1,165 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/indicator"
+	indicatormemory "github.com/carbonrook/cvewatch-domain/domain/indicator/memory"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability/memory"
+)
+
+func TestSuppressedVulnerabilityRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := memory.MustNewMemoryVulnerabilityRepository()
+	for _, vuln := range []vulnerability.Vulnerability{
+		{CveId: "CVE-2021-44228", Cwes: []vulnerability.Cwe{{Id: "CWE-502"}}},
+		{CveId: "CVE-2021-3711", Cwes: []vulnerability.Cwe{{Id: "CWE-120"}}},
+	} {
+		if err := repo.Add(ctx, vuln); err != nil {
+			t.Fatalf("failed to add vulnerability: %s", err)
+		}
+	}
+
+	rules := MustNewRuleSet()
+	rule := MustNewRule("we do not ship openssl", "platform-team", now.Add(3*24*time.Hour))
+	rule.CveId = "CVE-2021-3711"
+	if err := rules.Add(rule); err != nil {
+		t.Fatalf("failed to add rule: %s", err)
+	}
+
+	suppressed := SuppressedVulnerabilityRepository{Repository: repo, Rules: rules, Now: func() time.Time { return now }}
+	if _, err := suppressed.Get(ctx, "CVE-2021-3711"); !errors.Is(err, vulnerability.ErrVulnerabilityNotFound) {
+		t.Errorf("expected suppressed vulnerability to be hidden, got %v", err)
+	}
+	if _, err := suppressed.Get(ctx, "CVE-2021-44228"); err != nil {
+		t.Errorf("expected unsuppressed vulnerability to be returned, got %v", err)
+	}
+
+	suppressed.Now = func() time.Time { return now.Add(4 * 24 * time.Hour) }
+	if _, err := suppressed.Get(ctx, "CVE-2021-3711"); err != nil {
+		t.Errorf("expected vulnerability to reappear after the rule expired, got %v", err)
+	}
+
+	if expiring := rules.Expiring(now, 7*24*time.Hour); len(expiring) != 1 || expiring[0].Id != rule.Id {
+		t.Errorf("expected the rule to be reported as expiring, got %+v", expiring)
+	}
+}
+
+func TestFilterIndicators(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	factory := indicator.MustNewIndicatorFactory("reddit")
+	noisy := factory.MustNewIndicator()
+	noisy.AddTag("meme")
+	useful := factory.MustNewIndicator()
+	useful.AddTag("scanner")
+
+	rules := MustNewRuleSet()
+	rule := MustNewRule("memes are not threat intel", "soc", now.Add(24*time.Hour))
+	rule.IndicatorSource = "reddit"
+	rule.Tag = "MEME"
+	if err := rules.Add(rule); err != nil {
+		t.Fatalf("failed to add rule: %s", err)
+	}
+
+	kept := rules.FilterIndicators([]indicator.Indicator{noisy, useful}, now)
+	if len(kept) != 1 || kept[0].Id != useful.Id {
+		t.Errorf("expected only the useful indicator to be kept, got %+v", kept)
+	}
+
+	mixed := MustNewRule("mixed", "soc", now.Add(24*time.Hour))
+	mixed.CveId = "CVE-2021-44228"
+	mixed.Tag = "meme"
+	if err := rules.Add(mixed); err != ErrMixedCriteria {
+		t.Errorf("expected ErrMixedCriteria, got %v", err)
+	}
+}
+
+func TestSuppressedIndicatorRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	factory := indicator.MustNewIndicatorFactory("reddit")
+	log4shell := indicator.MustNewMention("cve", []byte("CVE-2021-44228"))
+
+	repo := indicatormemory.MustNewMemoryIndicatorRepository()
+	for day, tag := range []string{"scanner", "exploit", "meme"} {
+		i := factory.MustNewIndicator()
+		i.SourceId = tag
+		i.CreatedDate = now.Add(time.Duration(day-3) * 24 * time.Hour)
+		i.AddTag(tag)
+		i.AddMention(log4shell)
+		if err := repo.Add(ctx, i); err != nil {
+			t.Fatalf("failed to add indicator: %s", err)
+		}
+	}
+
+	rules := MustNewRuleSet()
+	rule := MustNewRule("memes are not threat intel", "soc", now.Add(24*time.Hour))
+	rule.Tag = "meme"
+	if err := rules.Add(rule); err != nil {
+		t.Fatalf("failed to add rule: %s", err)
+	}
+	suppressed := SuppressedIndicatorRepository{Repository: repo, Rules: rules, Now: func() time.Time { return now }}
+
+	if all, err := repo.GetByTopic(ctx, "CVE"); err != nil || all.Length() != 3 {
+		t.Errorf("failed to get indicators by topic, got %v %v", all, err)
+	}
+	mentioning, err := suppressed.GetByMention(ctx, log4shell)
+	if err != nil || mentioning.Length() != 2 || mentioning.Indicators[0].SourceId != "scanner" {
+		t.Errorf("expected suppressed indicator to be hidden oldest first, got %v %v", mentioning, err)
+	}
+	if empty, err := suppressed.GetBySource(ctx, "twitter"); err != nil || !empty.IsEmpty() {
+		t.Errorf("expected empty collection for unknown source, got %v %v", empty, err)
+	}
+	if _, err := suppressed.GetBySourceId(ctx, "reddit", "meme"); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected suppressed indicator to be hidden, got %v", err)
+	}
+	if _, err := repo.GetByLink(ctx, "https://example.com"); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected not found for unknown link, got %v", err)
+	}
+	if latest, err := suppressed.GetLatest(ctx); err != nil || latest.SourceId != "exploit" {
+		t.Errorf("expected latest unsuppressed indicator, got %v %v", latest, err)
+	}
+	if between, err := suppressed.GetBetween(ctx, now.Add(-3*24*time.Hour), now.Add(-2*24*time.Hour)); err != nil || between.Length() != 1 {
+		t.Errorf("expected start to be inclusive and end exclusive, got %v %v", between, err)
+	}
+}
+
+func TestSuppressedGetLatestPagesBack(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := indicatormemory.MustNewMemoryIndicatorRepository()
+	for n := 0; n <= indicator.DefaultPageSize+1; n++ {
+		i := indicator.MustNewIndicatorFactory("reddit").MustNewIndicator()
+		i.CreatedDate = now.Add(time.Duration(n) * time.Minute)
+		if n > 0 {
+			i.Source = "bot"
+		}
+		if err := repo.Add(ctx, i); err != nil {
+			t.Fatalf("failed to add indicator: %s", err)
+		}
+	}
+
+	rules := MustNewRuleSet()
+	rule := MustNewRule("bots repost everything", "soc", now.Add(24*time.Hour))
+	rule.IndicatorSource = "bot"
+	if err := rules.Add(rule); err != nil {
+		t.Fatalf("failed to add rule: %s", err)
+	}
+	suppressed := SuppressedIndicatorRepository{Repository: repo, Rules: rules, Now: func() time.Time { return now }}
+
+	latest, err := suppressed.GetLatest(ctx)
+	if err != nil || latest.Source != "reddit" || !latest.CreatedDate.Equal(now) {
+		t.Errorf("expected the unsuppressed indicator behind a page of suppressed ones, got %v %v", latest, err)
+	}
+
+	suppressed.Repository = indicatormemory.MustNewMemoryIndicatorRepository()
+	if _, err := suppressed.GetLatest(ctx); !errors.Is(err, indicator.ErrIndicatorNotFound) {
+		t.Errorf("expected ErrIndicatorNotFound from an empty repository, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/carbonrook/cvewatch-domain/domain/triage"
+)
+
+type MemoryRepository struct {
+	triages map[string]triage.Triage
+	lock    *sync.RWMutex
+}
+
+func (mr MemoryRepository) Add(ctx context.Context, t triage.Triage) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.triages[t.CveId]; ok {
+		return triage.ErrTriageAlreadyExists
+	}
+	mr.triages[t.CveId] = t.Copy()
+	return nil
+}
+
+func (mr MemoryRepository) Get(ctx context.Context, cveId string) (*triage.Triage, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	t, ok := mr.triages[cveId]
+	if !ok {
+		return nil, triage.ErrTriageNotFound
+	}
+	copied := t.Copy()
+	return &copied, nil
+}
+
+func (mr MemoryRepository) Update(ctx context.Context, t triage.Triage) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	stored, ok := mr.triages[t.CveId]
+	if !ok {
+		return triage.ErrTriageNotFound
+	}
+	if t.Version != stored.Version {
+		return triage.ErrTriageConflict
+	}
+	if err := t.VerifyUpdate(stored); err != nil {
+		return err
+	}
+	updated := t.Copy()
+	updated.Version++
+	mr.triages[t.CveId] = updated
+	return nil
+}
+
+func (mr MemoryRepository) Delete(ctx context.Context, cveId string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.triages[cveId]; !ok {
+		return triage.ErrTriageNotFound
+	}
+	delete(mr.triages, cveId)
+	return nil
+}
+
+func (mr MemoryRepository) GetByState(ctx context.Context, state triage.State) ([]triage.Triage, error) {
+	return mr.filter(func(t triage.Triage) bool {
+		return t.State == state
+	}), nil
+}
+
+func (mr MemoryRepository) GetByAssignee(ctx context.Context, assignee string) ([]triage.Triage, error) {
+	return mr.filter(func(t triage.Triage) bool {
+		return t.Assignee == assignee
+	}), nil
+}
+
+func (mr MemoryRepository) filter(match func(triage.Triage) bool) []triage.Triage {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	matches := []triage.Triage{}
+	for _, t := range mr.triages {
+		if match(t) {
+			matches = append(matches, t.Copy())
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].CveId < matches[j].CveId
+	})
+	return matches
+}
+
+func NewMemoryTriageRepository() (triage.TriageRepository, error) {
+	return MemoryRepository{
+		triages: make(map[string]triage.Triage),
+		lock:    &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewMemoryTriageRepository() triage.TriageRepository {
+	repo, err := NewMemoryTriageRepository()
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
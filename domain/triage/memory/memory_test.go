@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/triage"
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func TestMemoryRepositoryUpdate(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2021, 12, 14, 9, 0, 0, 0, time.UTC)
+	repo := MustNewMemoryTriageRepository()
+	if err := repo.Add(ctx, triage.MustNewTriage(vulnerability.Vulnerability{CveId: "CVE-2021-44228"}, "scanner")); err != nil {
+		t.Fatalf("failed to add triage: %s", err)
+	}
+
+	first, _ := repo.Get(ctx, "CVE-2021-44228")
+	second, _ := repo.Get(ctx, "CVE-2021-44228")
+	first.Assign("alice", "bob", at)
+	if err := first.Transition(triage.StateInvestigating, "alice", "", at); err != nil {
+		t.Fatalf("failed to transition: %s", err)
+	}
+	if err := repo.Update(ctx, *first); err != nil {
+		t.Fatalf("failed to update triage: %s", err)
+	}
+
+	second.AddNote("carol", "seen in the wild", at)
+	if err := repo.Update(ctx, *second); !errors.Is(err, triage.ErrTriageConflict) {
+		t.Errorf("expected a stale copy to conflict, got %v", err)
+	}
+
+	stored, _ := repo.Get(ctx, "CVE-2021-44228")
+	if stored.Version != 1 || stored.State != triage.StateInvestigating || len(stored.History) != 3 {
+		t.Errorf("unexpected stored triage: %+v", stored)
+	}
+
+	skipped := stored.Copy()
+	skipped.State = triage.StateClosed
+	if err := repo.Update(ctx, skipped); !errors.Is(err, triage.ErrHistoryRewritten) {
+		t.Errorf("expected a state change without a transition to fail, got %v", err)
+	}
+
+	truncated := stored.Copy()
+	truncated.History = truncated.History[:1]
+	truncated.State, truncated.Assignee = triage.StateNew, ""
+	if err := repo.Update(ctx, truncated); !errors.Is(err, triage.ErrHistoryRewritten) {
+		t.Errorf("expected a truncated history to fail, got %v", err)
+	}
+
+	forged := stored.Copy()
+	forged.History = append(forged.History, triage.Event{Type: triage.EventTransition, Actor: "mallory", From: triage.StateInvestigating, To: triage.StateMitigated, Timestamp: at})
+	forged.State = triage.StateMitigated
+	if err := repo.Update(ctx, forged); !errors.Is(err, triage.ErrInvalidTransition) {
+		t.Errorf("expected an illegal appended transition to fail, got %v", err)
+	}
+
+	stored.AddNote("carol", "seen in the wild", at)
+	if err := repo.Update(ctx, *stored); err != nil {
+		t.Errorf("failed to update from the latest copy: %s", err)
+	}
+	if latest, _ := repo.Get(ctx, "CVE-2021-44228"); latest.Version != 2 || len(latest.Notes) != 1 {
+		t.Errorf("expected the note to be stored as version 2, got %+v", latest)
+	}
+}
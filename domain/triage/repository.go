@@ -0,0 +1,26 @@
+package triage
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrTriageNotFound      = errors.New("the triage was not found")
+	ErrTriageAlreadyExists = errors.New("the triage already exists")
+	ErrTriageConflict      = errors.New("the triage was changed by another writer")
+)
+
+type TriageRepository interface {
+	Add(ctx context.Context, triage Triage) error
+	Get(ctx context.Context, cveId string) (*Triage, error)
+	// Update stores a triage read with Get and changed through Transition,
+	// Assign and AddNote. It fails with ErrTriageConflict when the stored
+	// Version has moved on since, and with ErrHistoryRewritten or
+	// ErrInvalidTransition unless Triage.VerifyUpdate passes. The stored
+	// Version is then incremented.
+	Update(ctx context.Context, triage Triage) error
+	Delete(ctx context.Context, cveId string) error
+	GetByState(ctx context.Context, state State) ([]Triage, error)
+	GetByAssignee(ctx context.Context, assignee string) ([]Triage, error)
+}
@@ -0,0 +1,215 @@
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTransition = errors.New("the triage state transition is not allowed")
+	ErrUnknownState      = errors.New("the triage state is not recognised")
+	ErrHistoryRewritten  = errors.New("the triage history can only be appended to")
+)
+
+type State string
+
+const (
+	StateNew           State = "new"
+	StateInvestigating State = "investigating"
+	StateAffected      State = "affected"
+	StateNotAffected   State = "not-affected"
+	StateMitigated     State = "mitigated"
+	StateClosed        State = "closed"
+)
+
+var transitions = map[State][]State{
+	StateNew:           {StateInvestigating, StateClosed},
+	StateInvestigating: {StateAffected, StateNotAffected, StateClosed},
+	StateAffected:      {StateMitigated, StateInvestigating},
+	StateNotAffected:   {StateClosed, StateInvestigating},
+	StateMitigated:     {StateClosed, StateAffected},
+	StateClosed:        {StateInvestigating},
+}
+
+func (s State) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s State) CanTransitionTo(to State) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+type EventType string
+
+const (
+	EventCreated    EventType = "created"
+	EventTransition EventType = "transition"
+	EventAssigned   EventType = "assigned"
+	EventNote       EventType = "note"
+)
+
+type Note struct {
+	Author      string    `json:"author"`
+	Text        string    `json:"text"`
+	CreatedDate time.Time `json:"createdDate"`
+}
+
+type Event struct {
+	Type      EventType `json:"type"`
+	Actor     string    `json:"actor"`
+	From      State     `json:"from,omitempty"`
+	To        State     `json:"to,omitempty"`
+	Assignee  string    `json:"assignee,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type Triage struct {
+	Id          string    `json:"id"`
+	CveId       string    `json:"cveId"`
+	State       State     `json:"state"`
+	Assignee    string    `json:"assignee,omitempty"`
+	Notes       []Note    `json:"notes"`
+	History     []Event   `json:"history"`
+	CreatedDate time.Time `json:"createdDate"`
+	UpdatedDate time.Time `json:"updatedDate"`
+	// Version counts the updates stored, so a writer holding an old copy
+	// cannot overwrite a newer one.
+	Version int `json:"version"`
+}
+
+func (t *Triage) Transition(to State, actor string, comment string, at time.Time) error {
+	if !to.IsValid() {
+		return fmt.Errorf("%w: %q", ErrUnknownState, to)
+	}
+	if !t.State.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, t.State, to)
+	}
+	t.record(Event{
+		Type:      EventTransition,
+		Actor:     actor,
+		From:      t.State,
+		To:        to,
+		Comment:   comment,
+		Timestamp: at,
+	})
+	t.State = to
+	return nil
+}
+
+func (t *Triage) Assign(assignee string, actor string, at time.Time) {
+	t.record(Event{
+		Type:      EventAssigned,
+		Actor:     actor,
+		Assignee:  assignee,
+		Timestamp: at,
+	})
+	t.Assignee = assignee
+}
+
+func (t *Triage) AddNote(author string, text string, at time.Time) {
+	t.Notes = append(t.Notes, Note{Author: author, Text: text, CreatedDate: at})
+	t.record(Event{
+		Type:      EventNote,
+		Actor:     author,
+		Comment:   text,
+		Timestamp: at,
+	})
+}
+
+func (e Event) Equal(other Event) bool {
+	return e.Type == other.Type && e.Actor == other.Actor && e.From == other.From && e.To == other.To &&
+		e.Assignee == other.Assignee && e.Comment == other.Comment && e.Timestamp.Equal(other.Timestamp)
+}
+
+// VerifyUpdate checks that updated only adds to the stored triage: its
+// history starts with the stored history, and replaying the events after it
+// from the stored state gives the updated state, assignee and notes using
+// legal transitions only.
+func (t Triage) VerifyUpdate(stored Triage) error {
+	if t.Id != stored.Id || t.CveId != stored.CveId || !t.CreatedDate.Equal(stored.CreatedDate) {
+		return fmt.Errorf("%w: the triage identity changed", ErrHistoryRewritten)
+	}
+	if len(t.History) < len(stored.History) || len(t.Notes) < len(stored.Notes) {
+		return fmt.Errorf("%w: events or notes were removed", ErrHistoryRewritten)
+	}
+	for index, event := range stored.History {
+		if !event.Equal(t.History[index]) {
+			return fmt.Errorf("%w: event %d was changed", ErrHistoryRewritten, index)
+		}
+	}
+	for index, note := range stored.Notes {
+		if note.Author != t.Notes[index].Author || note.Text != t.Notes[index].Text || !note.CreatedDate.Equal(t.Notes[index].CreatedDate) {
+			return fmt.Errorf("%w: note %d was changed", ErrHistoryRewritten, index)
+		}
+	}
+
+	replayed := stored.Copy()
+	for _, event := range t.History[len(stored.History):] {
+		switch event.Type {
+		case EventTransition:
+			if event.From != replayed.State {
+				return fmt.Errorf("%w: transition from %s recorded in state %s", ErrInvalidTransition, event.From, replayed.State)
+			}
+			if err := replayed.Transition(event.To, event.Actor, event.Comment, event.Timestamp); err != nil {
+				return err
+			}
+		case EventAssigned:
+			replayed.Assign(event.Assignee, event.Actor, event.Timestamp)
+		case EventNote:
+			replayed.AddNote(event.Actor, event.Comment, event.Timestamp)
+		default:
+			return fmt.Errorf("%w: %s events cannot be appended", ErrHistoryRewritten, event.Type)
+		}
+	}
+	if replayed.State != t.State || replayed.Assignee != t.Assignee || len(replayed.Notes) != len(t.Notes) {
+		return fmt.Errorf("%w: the triage does not match its history", ErrHistoryRewritten)
+	}
+	return nil
+}
+
+func (t *Triage) record(event Event) {
+	t.History = append(t.History, event)
+	t.UpdatedDate = event.Timestamp
+}
+
+// Copy returns a triage whose notes and history do not share storage with t.
+func (t Triage) Copy() Triage {
+	t.Notes = append([]Note{}, t.Notes...)
+	t.History = append([]Event{}, t.History...)
+	return t
+}
+
+func NewTriage(vuln vulnerability.Vulnerability, actor string) (Triage, error) {
+	if vuln.CveId == "" {
+		return Triage{}, fmt.Errorf("cannot triage a vulnerability without a cve id")
+	}
+	now := time.Now().UTC()
+	return Triage{
+		Id:          uuid.New().String(),
+		CveId:       vuln.CveId,
+		State:       StateNew,
+		Notes:       []Note{},
+		History:     []Event{{Type: EventCreated, Actor: actor, To: StateNew, Timestamp: now}},
+		CreatedDate: now,
+		UpdatedDate: now,
+	}, nil
+}
+
+func MustNewTriage(vuln vulnerability.Vulnerability, actor string) Triage {
+	triage, err := NewTriage(vuln, actor)
+	if err != nil {
+		panic(err)
+	}
+	return triage
+}
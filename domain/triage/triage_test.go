@@ -0,0 +1,39 @@
+package triage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func TestTransitionsAreEnforcedAndAudited(t *testing.T) {
+	triage := MustNewTriage(vulnerability.Vulnerability{CveId: "CVE-2021-44228"}, "scanner")
+	at := time.Date(2021, 12, 14, 9, 0, 0, 0, time.UTC)
+
+	if err := triage.Transition(StateMitigated, "alice", "", at); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition moving new to mitigated, got %v", err)
+	}
+
+	triage.Assign("alice", "bob", at)
+	steps := []State{StateInvestigating, StateAffected, StateMitigated, StateClosed}
+	for _, step := range steps {
+		if err := triage.Transition(step, "alice", "", at); err != nil {
+			t.Fatalf("failed to transition to %s: %s", step, err)
+		}
+	}
+	triage.AddNote("alice", "patched to 2.17.1", at)
+
+	if triage.State != StateClosed || triage.Assignee != "alice" {
+		t.Errorf("unexpected triage state: %s assigned to %s", triage.State, triage.Assignee)
+	}
+	// created, assigned, four transitions and a note
+	if len(triage.History) != 7 {
+		t.Errorf("expected 7 audit events, got %d", len(triage.History))
+	}
+	last := triage.History[len(triage.History)-2]
+	if last.From != StateMitigated || last.To != StateClosed || last.Actor != "alice" {
+		t.Errorf("unexpected audit event: %+v", last)
+	}
+}
@@ -0,0 +1,194 @@
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const CycloneDxSpecVersion = "1.5"
+
+type cycloneDxBom struct {
+	BomFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber,omitempty"`
+	Version         int                      `json:"version"`
+	Metadata        cycloneDxMetadata        `json:"metadata"`
+	Vulnerabilities []cycloneDxVulnerability `json:"vulnerabilities"`
+}
+
+type cycloneDxMetadata struct {
+	Timestamp *time.Time        `json:"timestamp,omitempty"`
+	Authors   []cycloneDxAuthor `json:"authors,omitempty"`
+}
+
+type cycloneDxAuthor struct {
+	Name string `json:"name"`
+}
+
+type cycloneDxVulnerability struct {
+	BomRef   string             `json:"bom-ref,omitempty"`
+	Id       string             `json:"id"`
+	Analysis cycloneDxAnalysis  `json:"analysis"`
+	Affects  []cycloneDxAffects `json:"affects"`
+}
+
+type cycloneDxAffects struct {
+	Ref string `json:"ref"`
+}
+
+type cycloneDxAnalysis struct {
+	State         string     `json:"state"`
+	Justification string     `json:"justification,omitempty"`
+	Response      []string   `json:"response,omitempty"`
+	Detail        string     `json:"detail,omitempty"`
+	FirstIssued   *time.Time `json:"firstIssued,omitempty"`
+	LastUpdated   *time.Time `json:"lastUpdated,omitempty"`
+}
+
+var cycloneDxStates = map[string]Status{
+	"not_affected":           StatusNotAffected,
+	"false_positive":         StatusNotAffected,
+	"exploitable":            StatusAffected,
+	"in_triage":              StatusUnderInvestigation,
+	"resolved":               StatusFixed,
+	"resolved_with_pedigree": StatusFixed,
+}
+
+var statusCycloneDxStates = map[Status]string{
+	StatusNotAffected:        "not_affected",
+	StatusAffected:           "exploitable",
+	StatusUnderInvestigation: "in_triage",
+	StatusFixed:              "resolved",
+}
+
+// CycloneDX justifications are finer grained than OpenVEX ones, so the
+// mapping in each direction is lossy.
+var cycloneDxJustifications = map[string]Justification{
+	"code_not_present":                JustificationVulnerableCodeNotPresent,
+	"code_not_reachable":              JustificationVulnerableCodeNotInExecutePath,
+	"requires_configuration":          JustificationVulnerableCodeCannotBeControlledByAdversary,
+	"requires_dependency":             JustificationVulnerableCodeCannotBeControlledByAdversary,
+	"requires_environment":            JustificationVulnerableCodeCannotBeControlledByAdversary,
+	"protected_by_compiler":           JustificationInlineMitigationsAlreadyExist,
+	"protected_at_runtime":            JustificationInlineMitigationsAlreadyExist,
+	"protected_at_perimeter":          JustificationInlineMitigationsAlreadyExist,
+	"protected_by_mitigating_control": JustificationInlineMitigationsAlreadyExist,
+}
+
+var justificationCycloneDxJustifications = map[Justification]string{
+	JustificationComponentNotPresent:                         "code_not_present",
+	JustificationVulnerableCodeNotPresent:                    "code_not_present",
+	JustificationVulnerableCodeNotInExecutePath:              "code_not_reachable",
+	JustificationVulnerableCodeCannotBeControlledByAdversary: "requires_environment",
+	JustificationInlineMitigationsAlreadyExist:               "protected_by_mitigating_control",
+}
+
+func ReadCycloneDx(r io.Reader) (*Document, error) {
+	var in cycloneDxBom
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("failed to decode cyclonedx document: %s", err)
+	}
+	if in.BomFormat != "CycloneDX" {
+		return nil, fmt.Errorf("%w: unexpected bom format %q", ErrUnsupportedVexVersion, in.BomFormat)
+	}
+
+	document := Document{
+		Id:         in.SerialNumber,
+		Version:    in.Version,
+		Statements: []Statement{},
+	}
+	if in.Metadata.Timestamp != nil {
+		document.Timestamp = in.Metadata.Timestamp.UTC()
+	}
+	if len(in.Metadata.Authors) > 0 {
+		document.Author = in.Metadata.Authors[0].Name
+	}
+
+	for _, vuln := range in.Vulnerabilities {
+		// A vulnerability listed without analysis makes no VEX statement.
+		if vuln.Analysis.State == "" {
+			continue
+		}
+		status, ok := cycloneDxStates[vuln.Analysis.State]
+		if !ok {
+			return nil, fmt.Errorf("%w: cyclonedx analysis state %q", ErrInvalidStatus, vuln.Analysis.State)
+		}
+
+		timestamp := document.Timestamp
+		if vuln.Analysis.LastUpdated != nil {
+			timestamp = vuln.Analysis.LastUpdated.UTC()
+		} else if vuln.Analysis.FirstIssued != nil {
+			timestamp = vuln.Analysis.FirstIssued.UTC()
+		}
+
+		for _, affects := range vuln.Affects {
+			statement := Statement{
+				CveId:         vuln.Id,
+				Product:       affects.Ref,
+				Status:        status,
+				Justification: cycloneDxJustifications[vuln.Analysis.Justification],
+				Timestamp:     timestamp,
+			}
+			if status == StatusNotAffected {
+				statement.ImpactStatement = vuln.Analysis.Detail
+				if vuln.Analysis.State == "false_positive" && statement.ImpactStatement == "" {
+					statement.ImpactStatement = "false positive"
+				}
+			} else {
+				statement.ActionStatement = vuln.Analysis.Detail
+			}
+			if err := document.AddStatement(statement); err != nil {
+				return nil, fmt.Errorf("invalid statement for %s on %s: %w", statement.CveId, statement.Product, err)
+			}
+		}
+	}
+
+	return &document, nil
+}
+
+func WriteCycloneDx(w io.Writer, document Document) error {
+	serialNumber := document.Id
+	if !strings.HasPrefix(serialNumber, "urn:uuid:") {
+		serialNumber = "urn:uuid:" + uuid.New().String()
+	}
+	timestamp := document.Timestamp
+	out := cycloneDxBom{
+		BomFormat:       "CycloneDX",
+		SpecVersion:     CycloneDxSpecVersion,
+		SerialNumber:    serialNumber,
+		Version:         document.Version,
+		Metadata:        cycloneDxMetadata{Timestamp: &timestamp},
+		Vulnerabilities: []cycloneDxVulnerability{},
+	}
+	if document.Author != "" {
+		out.Metadata.Authors = append(out.Metadata.Authors, cycloneDxAuthor{Name: document.Author})
+	}
+
+	for _, statement := range document.Statements {
+		lastUpdated := statement.Timestamp
+		vuln := cycloneDxVulnerability{
+			BomRef: statement.Id,
+			Id:     statement.CveId,
+			Analysis: cycloneDxAnalysis{
+				State:         statusCycloneDxStates[statement.Status],
+				Justification: justificationCycloneDxJustifications[statement.Justification],
+				Detail:        statement.ImpactStatement,
+				LastUpdated:   &lastUpdated,
+			},
+			Affects: []cycloneDxAffects{{Ref: statement.Product}},
+		}
+		if statement.Status != StatusNotAffected {
+			vuln.Analysis.Detail = statement.ActionStatement
+		}
+		out.Vulnerabilities = append(out.Vulnerabilities, vuln)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
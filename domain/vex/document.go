@@ -0,0 +1,96 @@
+package vex
+
+import (
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/google/uuid"
+)
+
+type Document struct {
+	Id         string      `json:"id"`
+	Author     string      `json:"author"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Version    int         `json:"version"`
+	Statements []Statement `json:"statements"`
+}
+
+func (d *Document) AddStatement(statement Statement) error {
+	if err := statement.Validate(); err != nil {
+		return err
+	}
+	if statement.Id == "" {
+		statement.Id = uuid.New().String()
+	}
+	if statement.Timestamp.IsZero() {
+		statement.Timestamp = d.Timestamp
+	}
+	d.Statements = append(d.Statements, statement)
+	return nil
+}
+
+func (d *Document) StatementsFor(cveId string) []Statement {
+	statements := []Statement{}
+	for _, statement := range d.Statements {
+		if strings.EqualFold(statement.CveId, cveId) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// Latest returns the most recent statement made about the product for the
+// vulnerability. When timestamps are equal the statement added last wins.
+func (d *Document) Latest(cveId string, product string) (Statement, bool) {
+	var latest Statement
+	found := false
+	for _, statement := range d.Statements {
+		if !strings.EqualFold(statement.CveId, cveId) || statement.Product != product {
+			continue
+		}
+		if !found || !statement.Timestamp.Before(latest.Timestamp) {
+			latest = statement
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// Affects reports whether the product should be considered exposed to the
+// vulnerability. Without a statement the product is assumed to be affected.
+func (d *Document) Affects(vuln vulnerability.Vulnerability, product string) bool {
+	statement, ok := d.Latest(vuln.CveId, product)
+	if !ok {
+		return true
+	}
+	return statement.Affected()
+}
+
+func (d *Document) AffectedVulnerabilities(product string, vulns []vulnerability.Vulnerability) []vulnerability.Vulnerability {
+	affected := []vulnerability.Vulnerability{}
+	for _, vuln := range vulns {
+		if d.Affects(vuln, product) {
+			affected = append(affected, vuln)
+		}
+	}
+	return affected
+}
+
+func NewDocument(author string) (Document, error) {
+	return Document{
+		Id:         "urn:uuid:" + uuid.New().String(),
+		Author:     author,
+		Timestamp:  time.Now().UTC(),
+		Version:    1,
+		Statements: []Statement{},
+	}, nil
+}
+
+func MustNewDocument(author string) Document {
+	document, err := NewDocument(author)
+	if err != nil {
+		panic(err)
+	}
+	return document
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vex"
+)
+
+type MemoryRepository struct {
+	statements map[string]vex.Statement
+	lock       *sync.RWMutex
+}
+
+func (mr MemoryRepository) Add(ctx context.Context, statement vex.Statement) error {
+	if err := statement.Validate(); err != nil {
+		return err
+	}
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.statements[statement.Id]; ok {
+		return vex.ErrStatementAlreadyExists
+	}
+	mr.statements[statement.Id] = statement
+	return nil
+}
+
+func (mr MemoryRepository) GetById(ctx context.Context, id string) (*vex.Statement, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	statement, ok := mr.statements[id]
+	if !ok {
+		return nil, vex.ErrStatementNotFound
+	}
+	return &statement, nil
+}
+
+func (mr MemoryRepository) GetByCveId(ctx context.Context, cveId string) ([]vex.Statement, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	statements := []vex.Statement{}
+	for _, statement := range mr.statements {
+		if strings.EqualFold(statement.CveId, cveId) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+}
+
+func (mr MemoryRepository) GetByProduct(ctx context.Context, product string) ([]vex.Statement, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	statements := []vex.Statement{}
+	for _, statement := range mr.statements {
+		if statement.Product == product {
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+}
+
+func (mr MemoryRepository) Delete(ctx context.Context, id string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.statements[id]; !ok {
+		return vex.ErrStatementNotFound
+	}
+	delete(mr.statements, id)
+	return nil
+}
+
+func NewMemoryStatementRepository() (vex.StatementRepository, error) {
+	return MemoryRepository{
+		statements: make(map[string]vex.Statement),
+		lock:       &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewMemoryStatementRepository() vex.StatementRepository {
+	repo, err := NewMemoryStatementRepository()
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
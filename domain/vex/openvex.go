@@ -0,0 +1,144 @@
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const OpenVexContext = "https://openvex.dev/ns/v0.2.0"
+
+type openVexDocument struct {
+	Context    string             `json:"@context"`
+	Id         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  *time.Time         `json:"timestamp,omitempty"`
+	Version    int                `json:"version"`
+	Statements []openVexStatement `json:"statements"`
+}
+
+type openVexStatement struct {
+	Vulnerability   openVexVulnerability `json:"vulnerability"`
+	Products        []openVexProduct     `json:"products"`
+	Status          Status               `json:"status"`
+	Justification   Justification        `json:"justification,omitempty"`
+	ImpactStatement string               `json:"impact_statement,omitempty"`
+	ActionStatement string               `json:"action_statement,omitempty"`
+	Timestamp       *time.Time           `json:"timestamp,omitempty"`
+}
+
+// openVexVulnerability accepts both the v0.2 object form and the bare string
+// used by earlier OpenVEX drafts.
+type openVexVulnerability struct {
+	Name string `json:"name"`
+}
+
+func (v *openVexVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		v.Name = name
+		return nil
+	}
+	var object struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	v.Name = object.Name
+	return nil
+}
+
+type openVexProduct struct {
+	Id string `json:"@id"`
+}
+
+func (p *openVexProduct) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		p.Id = id
+		return nil
+	}
+	var object struct {
+		Id string `json:"@id"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	p.Id = object.Id
+	return nil
+}
+
+func ReadOpenVex(r io.Reader) (*Document, error) {
+	var in openVexDocument
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("failed to decode openvex document: %s", err)
+	}
+	if !strings.HasPrefix(in.Context, "https://openvex.dev/ns") {
+		return nil, fmt.Errorf("%w: unexpected openvex context %q", ErrUnsupportedVexVersion, in.Context)
+	}
+
+	document := Document{
+		Id:         in.Id,
+		Author:     in.Author,
+		Version:    in.Version,
+		Statements: []Statement{},
+	}
+	if in.Timestamp != nil {
+		document.Timestamp = in.Timestamp.UTC()
+	}
+
+	for _, inStatement := range in.Statements {
+		timestamp := document.Timestamp
+		if inStatement.Timestamp != nil {
+			timestamp = inStatement.Timestamp.UTC()
+		}
+		for _, product := range inStatement.Products {
+			statement := Statement{
+				CveId:           inStatement.Vulnerability.Name,
+				Product:         product.Id,
+				Status:          inStatement.Status,
+				Justification:   inStatement.Justification,
+				ImpactStatement: inStatement.ImpactStatement,
+				ActionStatement: inStatement.ActionStatement,
+				Timestamp:       timestamp,
+			}
+			if err := document.AddStatement(statement); err != nil {
+				return nil, fmt.Errorf("invalid statement for %s on %s: %w", statement.CveId, statement.Product, err)
+			}
+		}
+	}
+
+	return &document, nil
+}
+
+func WriteOpenVex(w io.Writer, document Document) error {
+	timestamp := document.Timestamp
+	out := openVexDocument{
+		Context:    OpenVexContext,
+		Id:         document.Id,
+		Author:     document.Author,
+		Timestamp:  &timestamp,
+		Version:    document.Version,
+		Statements: []openVexStatement{},
+	}
+
+	for _, statement := range document.Statements {
+		statementTimestamp := statement.Timestamp
+		out.Statements = append(out.Statements, openVexStatement{
+			Vulnerability:   openVexVulnerability{Name: statement.CveId},
+			Products:        []openVexProduct{{Id: statement.Product}},
+			Status:          statement.Status,
+			Justification:   statement.Justification,
+			ImpactStatement: statement.ImpactStatement,
+			ActionStatement: statement.ActionStatement,
+			Timestamp:       &statementTimestamp,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
@@ -0,0 +1,19 @@
+package vex
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrStatementNotFound      = errors.New("the vex statement was not found")
+	ErrStatementAlreadyExists = errors.New("the vex statement already exists")
+)
+
+type StatementRepository interface {
+	Add(ctx context.Context, statement Statement) error
+	GetById(ctx context.Context, id string) (*Statement, error)
+	GetByCveId(ctx context.Context, cveId string) ([]Statement, error)
+	GetByProduct(ctx context.Context, product string) ([]Statement, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,122 @@
+package vex
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidStatus         = errors.New("the vex status is not recognised")
+	ErrInvalidJustification  = errors.New("the vex justification is not recognised")
+	ErrMissingJustification  = errors.New("a not_affected statement requires a justification or impact statement")
+	ErrMissingVulnerability  = errors.New("the vex statement has no vulnerability")
+	ErrMissingProduct        = errors.New("the vex statement has no product")
+	ErrUnsupportedVexVersion = errors.New("the vex document format is not supported")
+)
+
+type Status string
+
+const (
+	StatusNotAffected        Status = "not_affected"
+	StatusAffected           Status = "affected"
+	StatusFixed              Status = "fixed"
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusNotAffected, StatusAffected, StatusFixed, StatusUnderInvestigation:
+		return true
+	}
+	return false
+}
+
+type Justification string
+
+const (
+	JustificationComponentNotPresent                         Justification = "component_not_present"
+	JustificationVulnerableCodeNotPresent                    Justification = "vulnerable_code_not_present"
+	JustificationVulnerableCodeNotInExecutePath              Justification = "vulnerable_code_not_in_execute_path"
+	JustificationVulnerableCodeCannotBeControlledByAdversary Justification = "vulnerable_code_cannot_be_controlled_by_adversary"
+	JustificationInlineMitigationsAlreadyExist               Justification = "inline_mitigations_already_exist"
+)
+
+func (j Justification) IsValid() bool {
+	switch j {
+	case JustificationComponentNotPresent,
+		JustificationVulnerableCodeNotPresent,
+		JustificationVulnerableCodeNotInExecutePath,
+		JustificationVulnerableCodeCannotBeControlledByAdversary,
+		JustificationInlineMitigationsAlreadyExist:
+		return true
+	}
+	return false
+}
+
+type Statement struct {
+	Id              string        `json:"id"`
+	CveId           string        `json:"cveId"`
+	Product         string        `json:"product"`
+	Status          Status        `json:"status"`
+	Justification   Justification `json:"justification,omitempty"`
+	ImpactStatement string        `json:"impactStatement,omitempty"`
+	ActionStatement string        `json:"actionStatement,omitempty"`
+	Timestamp       time.Time     `json:"timestamp"`
+}
+
+func (s Statement) Validate() error {
+	if s.CveId == "" {
+		return ErrMissingVulnerability
+	}
+	if s.Product == "" {
+		return ErrMissingProduct
+	}
+	if !s.Status.IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, s.Status)
+	}
+	if s.Justification != "" && !s.Justification.IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidJustification, s.Justification)
+	}
+	if s.Status == StatusNotAffected && s.Justification == "" && s.ImpactStatement == "" {
+		return ErrMissingJustification
+	}
+	return nil
+}
+
+// Affected reports whether the statement leaves the product exposed to the
+// vulnerability. Statements still under investigation are treated as affected.
+func (s Statement) Affected() bool {
+	return s.Status == StatusAffected || s.Status == StatusUnderInvestigation
+}
+
+func NewStatement(vuln vulnerability.Vulnerability, product string, status Status) (Statement, error) {
+	statement := Statement{
+		Id:        uuid.New().String(),
+		CveId:     vuln.CveId,
+		Product:   product,
+		Status:    status,
+		Timestamp: time.Now().UTC(),
+	}
+	if statement.CveId == "" {
+		return Statement{}, ErrMissingVulnerability
+	}
+	if statement.Product == "" {
+		return Statement{}, ErrMissingProduct
+	}
+	if !status.IsValid() {
+		return Statement{}, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+	return statement, nil
+}
+
+func MustNewStatement(vuln vulnerability.Vulnerability, product string, status Status) Statement {
+	statement, err := NewStatement(vuln, product, status)
+	if err != nil {
+		panic(err)
+	}
+	return statement
+}
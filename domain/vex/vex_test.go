@@ -0,0 +1,110 @@
+package vex
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const testOpenVex = `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://example.com/vex/2021-44228",
+  "author": "Product Security",
+  "timestamp": "2021-12-14T10:00:00Z",
+  "version": 1,
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2021-44228"},
+      "products": [{"@id": "pkg:maven/com.example/app@1.0.0"}, "pkg:maven/com.example/app@1.1.0"],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": "CVE-2021-44228",
+      "products": ["pkg:maven/com.example/app@1.1.0"],
+      "status": "affected",
+      "action_statement": "Upgrade to 1.1.1",
+      "timestamp": "2021-12-15T10:00:00Z"
+    }
+  ]
+}`
+
+func TestReadOpenVexAndAffects(t *testing.T) {
+	document, err := ReadOpenVex(strings.NewReader(testOpenVex))
+	if err != nil {
+		t.Fatalf("failed to read openvex document: %s", err)
+	}
+	if len(document.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(document.Statements))
+	}
+
+	log4shell := vulnerability.Vulnerability{CveId: "CVE-2021-44228"}
+	if document.Affects(log4shell, "pkg:maven/com.example/app@1.0.0") {
+		t.Errorf("expected 1.0.0 to be not affected")
+	}
+	if !document.Affects(log4shell, "pkg:maven/com.example/app@1.1.0") {
+		t.Errorf("expected the later affected statement for 1.1.0 to win")
+	}
+	if !document.Affects(log4shell, "pkg:maven/com.example/other@1.0.0") {
+		t.Errorf("expected products without statements to be treated as affected")
+	}
+}
+
+func TestCycloneDxRoundTrip(t *testing.T) {
+	document := MustNewDocument("Product Security")
+	statement := MustNewStatement(vulnerability.Vulnerability{CveId: "CVE-2021-45046"}, "pkg:maven/com.example/app@1.0.0", StatusNotAffected)
+	statement.Justification = JustificationVulnerableCodeNotInExecutePath
+	statement.ImpactStatement = "JNDI lookups are disabled"
+	statement.Timestamp = time.Date(2021, 12, 16, 0, 0, 0, 0, time.UTC)
+	if err := document.AddStatement(statement); err != nil {
+		t.Fatalf("failed to add statement: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCycloneDx(&buf, document); err != nil {
+		t.Fatalf("failed to write cyclonedx document: %s", err)
+	}
+	parsed, err := ReadCycloneDx(&buf)
+	if err != nil {
+		t.Fatalf("failed to read cyclonedx document: %s", err)
+	}
+	if len(parsed.Statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(parsed.Statements))
+	}
+	got := parsed.Statements[0]
+	if got.Status != StatusNotAffected || got.Justification != statement.Justification || got.ImpactStatement != statement.ImpactStatement {
+		t.Errorf("statement did not survive round trip: %+v", got)
+	}
+	if !got.Timestamp.Equal(statement.Timestamp) {
+		t.Errorf("expected timestamp %s, got %s", statement.Timestamp, got.Timestamp)
+	}
+}
+
+func TestNotAffectedRequiresJustification(t *testing.T) {
+	document := MustNewDocument("Product Security")
+	statement := MustNewStatement(vulnerability.Vulnerability{CveId: "CVE-2021-45046"}, "pkg:maven/com.example/app@1.0.0", StatusNotAffected)
+	if err := document.AddStatement(statement); err != ErrMissingJustification {
+		t.Errorf("expected ErrMissingJustification, got %v", err)
+	}
+}
+
+func TestReadCycloneDxSkipsUnanalysed(t *testing.T) {
+	bom := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "vulnerabilities": [
+    {"id": "CVE-2021-44228", "affects": [{"ref": "pkg:maven/com.example/app@1.0.0"}]},
+    {"id": "CVE-2021-45046", "affects": [{"ref": "pkg:maven/com.example/app@1.0.0"}], "analysis": {"state": "exploitable", "detail": "Upgrade to 1.1.1"}}
+  ]
+}`
+	document, err := ReadCycloneDx(strings.NewReader(bom))
+	if err != nil {
+		t.Fatalf("failed to read cyclonedx document: %s", err)
+	}
+	if len(document.Statements) != 1 || document.Statements[0].CveId != "CVE-2021-45046" {
+		t.Errorf("expected only the analysed vulnerability, got %+v", document.Statements)
+	}
+}
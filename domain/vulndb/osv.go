@@ -0,0 +1,163 @@
+package vulndb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+const OsvSchemaVersion = "1.3.1"
+
+// Entry is an OSV record as served from ID/<id>.json in a Go vulnerability
+// database (https://go.dev/security/vuln/database).
+type Entry struct {
+	SchemaVersion string      `json:"schema_version"`
+	Id            string      `json:"id"`
+	Modified      time.Time   `json:"modified"`
+	Published     *time.Time  `json:"published,omitempty"`
+	Aliases       []string    `json:"aliases,omitempty"`
+	Summary       string      `json:"summary,omitempty"`
+	Details       string      `json:"details"`
+	Affected      []Affected  `json:"affected"`
+	References    []Reference `json:"references,omitempty"`
+}
+
+type Affected struct {
+	Package           Package           `json:"package"`
+	Ranges            []Range           `json:"ranges,omitempty"`
+	EcosystemSpecific EcosystemSpecific `json:"ecosystem_specific"`
+}
+
+type Package struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+type EcosystemSpecific struct {
+	Imports []Import `json:"imports,omitempty"`
+}
+
+type Import struct {
+	Path    string   `json:"path"`
+	Goos    []string `json:"goos,omitempty"`
+	Goarch  []string `json:"goarch,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+type Reference struct {
+	Type string `json:"type"`
+	Url  string `json:"url"`
+}
+
+// referenceTypes maps NVD reference tags onto OSV reference types. Untagged
+// references are WEB.
+var referenceTypes = map[string]string{
+	"patch":                "FIX",
+	"vendor advisory":      "ADVISORY",
+	"third party advisory": "ADVISORY",
+	"issue tracking":       "REPORT",
+	"exploit":              "EVIDENCE",
+	"release notes":        "WEB",
+	"mailing list":         "DISCUSSION",
+}
+
+// ToEntry converts the Go ecosystem products of a vulnerability to an OSV
+// entry. The CVE id is the entry id. It returns ErrNoGoModules when the
+// vulnerability affects no Go modules.
+func ToEntry(vuln vulnerability.Vulnerability) (Entry, error) {
+	entry := Entry{
+		SchemaVersion: OsvSchemaVersion,
+		Id:            vuln.CveId,
+		Modified:      modified(vuln),
+		Summary:       summary(vuln.Description),
+		Details:       vuln.Description,
+		Affected:      []Affected{},
+	}
+	if !vuln.PublishedDate.IsZero() {
+		published := vuln.PublishedDate.UTC()
+		entry.Published = &published
+	}
+
+	for _, product := range vuln.Affected {
+		if product.Ecosystem != vulnerability.EcosystemGo || product.Product == "" {
+			continue
+		}
+		affected := Affected{Package: Package{Name: product.Product, Ecosystem: vulnerability.EcosystemGo}}
+		if len(product.Ranges) > 0 {
+			semver := Range{Type: "SEMVER", Events: []Event{}}
+			for _, versionRange := range product.Ranges {
+				semver.Events = append(semver.Events, Event{Introduced: osvVersion(versionRange.Introduced, "0")})
+				if versionRange.Fixed != "" {
+					semver.Events = append(semver.Events, Event{Fixed: osvVersion(versionRange.Fixed, "")})
+				}
+			}
+			affected.Ranges = []Range{semver}
+		}
+		for _, packageImport := range product.Imports {
+			affected.EcosystemSpecific.Imports = append(affected.EcosystemSpecific.Imports, Import{
+				Path:    packageImport.Path,
+				Goos:    packageImport.Goos,
+				Goarch:  packageImport.Goarch,
+				Symbols: packageImport.Symbols,
+			})
+		}
+		entry.Affected = append(entry.Affected, affected)
+	}
+	if len(entry.Affected) == 0 {
+		return Entry{}, ErrNoGoModules
+	}
+
+	for _, reference := range vuln.References {
+		referenceType := "WEB"
+		for _, tag := range reference.Tags {
+			if mapped, ok := referenceTypes[strings.ToLower(tag)]; ok {
+				referenceType = mapped
+				break
+			}
+		}
+		entry.References = append(entry.References, Reference{Type: referenceType, Url: reference.Url.String()})
+	}
+	return entry, nil
+}
+
+func modified(vuln vulnerability.Vulnerability) time.Time {
+	if !vuln.LastModified.IsZero() {
+		return vuln.LastModified.UTC()
+	}
+	return vuln.PublishedDate.UTC()
+}
+
+// osvVersion strips the v prefix Go module versions carry, as OSV SEMVER
+// ranges expect bare versions.
+func osvVersion(version string, empty string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" {
+		return empty
+	}
+	return version
+}
+
+// summary is the first sentence of the description, which govulncheck shows
+// alongside the id.
+func summary(description string) string {
+	description = strings.TrimSpace(description)
+	if end := strings.Index(description, ". "); end >= 0 {
+		description = description[:end]
+	}
+	description = strings.TrimSuffix(description, ".")
+	if runes := []rune(description); len(runes) > 120 {
+		description = strings.TrimSpace(string(runes[:117])) + "..."
+	}
+	return description
+}
@@ -0,0 +1,188 @@
+package vulndb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+var ErrNoGoModules = errors.New("the vulnerability affects no go modules")
+
+// DatabaseIndex is index/db.json.
+type DatabaseIndex struct {
+	Modified time.Time `json:"modified"`
+}
+
+// ModuleIndex is an element of index/modules.json. Fixed is the highest
+// fixed version of the module for each vulnerability.
+type ModuleIndex struct {
+	Path  string            `json:"path"`
+	Vulns []ModuleVulnIndex `json:"vulns"`
+}
+
+type ModuleVulnIndex struct {
+	Id       string    `json:"id"`
+	Modified time.Time `json:"modified"`
+	Fixed    string    `json:"fixed,omitempty"`
+}
+
+// VulnIndex is an element of index/vulns.json.
+type VulnIndex struct {
+	Id       string    `json:"id"`
+	Modified time.Time `json:"modified"`
+	Aliases  []string  `json:"aliases,omitempty"`
+}
+
+// Write exports the vulnerabilities affecting Go modules into dir using the
+// Go vulnerability database layout, so govulncheck can be pointed at it with
+// -db file:///path/to/dir. Vulnerabilities with no Go modules are skipped.
+// The index files are written last so a reader never sees an index naming a
+// missing entry.
+func Write(dir string, vulns []vulnerability.Vulnerability) error {
+	entries := []Entry{}
+	for _, vuln := range vulns {
+		entry, err := ToEntry(vuln)
+		if errors.Is(err, ErrNoGoModules) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Id < entries[b].Id
+	})
+
+	for _, subdir := range []string{"ID", "index"} {
+		if err := os.MkdirAll(filepath.Join(dir, subdir), 0755); err != nil {
+			return fmt.Errorf("failed to create vulndb directory: %s", err)
+		}
+	}
+
+	database := DatabaseIndex{}
+	vulnIndex := []VulnIndex{}
+	modules := make(map[string]*ModuleIndex)
+	for _, entry := range entries {
+		if err := writeJson(filepath.Join(dir, "ID", entry.Id+".json"), entry); err != nil {
+			return err
+		}
+		if entry.Modified.After(database.Modified) {
+			database.Modified = entry.Modified
+		}
+		vulnIndex = append(vulnIndex, VulnIndex{Id: entry.Id, Modified: entry.Modified, Aliases: entry.Aliases})
+		for _, affected := range entry.Affected {
+			module, ok := modules[affected.Package.Name]
+			if !ok {
+				module = &ModuleIndex{Path: affected.Package.Name, Vulns: []ModuleVulnIndex{}}
+				modules[affected.Package.Name] = module
+			}
+			module.Vulns = append(module.Vulns, ModuleVulnIndex{Id: entry.Id, Modified: entry.Modified, Fixed: latestFixed(affected)})
+		}
+	}
+
+	moduleIndex := make([]ModuleIndex, 0, len(modules))
+	for _, module := range modules {
+		moduleIndex = append(moduleIndex, *module)
+	}
+	sort.Slice(moduleIndex, func(a, b int) bool {
+		return moduleIndex[a].Path < moduleIndex[b].Path
+	})
+
+	if err := writeJson(filepath.Join(dir, "index", "vulns.json"), vulnIndex); err != nil {
+		return err
+	}
+	if err := writeJson(filepath.Join(dir, "index", "modules.json"), moduleIndex); err != nil {
+		return err
+	}
+	return writeJson(filepath.Join(dir, "index", "db.json"), database)
+}
+
+func WriteFromRepository(ctx context.Context, repository vulnerability.VulnerabilityRepository, dir string) error {
+	collection, err := repository.List(ctx)
+	if err != nil {
+		return err
+	}
+	return Write(dir, collection.Vulnerabilities)
+}
+
+func writeJson(path string, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %s", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %s", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func latestFixed(affected Affected) string {
+	latest := ""
+	for _, r := range affected.Ranges {
+		for _, event := range r.Events {
+			if event.Fixed != "" && (latest == "" || compareSemver(event.Fixed, latest) > 0) {
+				latest = event.Fixed
+			}
+		}
+	}
+	return latest
+}
+
+// compareSemver orders bare semantic versions. Pre-release identifiers are
+// compared as a whole, which is enough to pick the highest fixed version.
+func compareSemver(a string, b string) int {
+	aVersion, aPre := splitPrerelease(a)
+	bVersion, bPre := splitPrerelease(b)
+	aParts := strings.Split(aVersion, ".")
+	bParts := strings.Split(bVersion, ".")
+	for i := 0; i < 3; i++ {
+		if diff := versionPart(aParts, i) - versionPart(bParts, i); diff != 0 {
+			if diff < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	}
+	return 1
+}
+
+func splitPrerelease(version string) (string, string) {
+	if plus := strings.Index(version, "+"); plus >= 0 {
+		version = version[:plus]
+	}
+	if dash := strings.Index(version, "-"); dash >= 0 {
+		return version[:dash], version[dash+1:]
+	}
+	return version, ""
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	number, err := strconv.Atoi(parts[i])
+	if err != nil {
+		return 0
+	}
+	return number
+}
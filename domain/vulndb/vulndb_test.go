@@ -0,0 +1,119 @@
+package vulndb
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+func readJson(t *testing.T, path string, value interface{}) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", path, err)
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		t.Fatalf("failed to decode %s: %s", path, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	advisory, _ := url.Parse("https://go.dev/issue/56152")
+	vulns := []vulnerability.Vulnerability{
+		{
+			CveId:         "CVE-2022-41717",
+			Description:   "An attacker can cause excessive memory growth in a Go server accepting HTTP/2 requests. HTTP/2 server connections contain a cache of HTTP header keys.",
+			PublishedDate: time.Date(2022, 12, 8, 19, 15, 0, 0, time.UTC),
+			LastModified:  time.Date(2022, 12, 12, 17, 0, 0, 0, time.UTC),
+			Affected: []vulnerability.AffectedProduct{
+				{
+					Ecosystem: vulnerability.EcosystemGo,
+					Product:   "golang.org/x/net",
+					Ranges:    []vulnerability.VersionRange{{Fixed: "v0.4.0"}},
+					Imports:   []vulnerability.PackageImport{{Path: "golang.org/x/net/http2", Symbols: []string{"Server.ServeConn"}}},
+				},
+				{
+					Ecosystem: vulnerability.EcosystemGo,
+					Product:   "stdlib",
+					Ranges:    []vulnerability.VersionRange{{Fixed: "1.18.9"}, {Introduced: "1.19.0", Fixed: "1.19.4"}},
+				},
+			},
+			References: []vulnerability.Reference{{Url: *advisory, Tags: []string{"Issue Tracking"}}},
+		},
+		{
+			CveId:        "CVE-2022-32149",
+			Description:  "An attacker may cause a denial of service by crafting an Accept-Language header.",
+			LastModified: time.Date(2022, 10, 14, 0, 0, 0, 0, time.UTC),
+			Affected: []vulnerability.AffectedProduct{
+				{Ecosystem: vulnerability.EcosystemGo, Product: "golang.org/x/text", Ranges: []vulnerability.VersionRange{{Fixed: "v0.3.8"}}},
+			},
+		},
+		{
+			CveId:    "CVE-2021-44228",
+			Affected: []vulnerability.AffectedProduct{{Vendor: "apache", Product: "log4j"}},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := Write(dir, vulns); err != nil {
+		t.Fatalf("failed to write vulndb: %s", err)
+	}
+
+	var database DatabaseIndex
+	readJson(t, filepath.Join(dir, "index", "db.json"), &database)
+	if !database.Modified.Equal(vulns[0].LastModified) {
+		t.Errorf("failed to set database modified time, got %s", database.Modified)
+	}
+
+	var vulnIndex []VulnIndex
+	readJson(t, filepath.Join(dir, "index", "vulns.json"), &vulnIndex)
+	if len(vulnIndex) != 2 || vulnIndex[0].Id != "CVE-2022-32149" {
+		t.Errorf("failed to index go vulnerabilities only, got %+v", vulnIndex)
+	}
+
+	var moduleIndex []ModuleIndex
+	readJson(t, filepath.Join(dir, "index", "modules.json"), &moduleIndex)
+	paths := []string{}
+	for _, module := range moduleIndex {
+		paths = append(paths, module.Path)
+		if module.Path == "stdlib" && module.Vulns[0].Fixed != "1.19.4" {
+			t.Errorf("failed to take the highest fixed version, got %s", module.Vulns[0].Fixed)
+		}
+	}
+	if !reflect.DeepEqual(paths, []string{"golang.org/x/net", "golang.org/x/text", "stdlib"}) {
+		t.Errorf("failed to index modules, got %v", paths)
+	}
+
+	var entry Entry
+	readJson(t, filepath.Join(dir, "ID", "CVE-2022-41717.json"), &entry)
+	if entry.Summary != "An attacker can cause excessive memory growth in a Go server accepting HTTP/2 requests" {
+		t.Errorf("failed to summarise, got %q", entry.Summary)
+	}
+	events := entry.Affected[0].Ranges[0].Events
+	if !reflect.DeepEqual(events, []Event{{Introduced: "0"}, {Fixed: "0.4.0"}}) {
+		t.Errorf("failed to convert range, got %+v", events)
+	}
+	if imports := entry.Affected[0].EcosystemSpecific.Imports; len(imports) != 1 || imports[0].Symbols[0] != "Server.ServeConn" {
+		t.Errorf("failed to convert imports, got %+v", imports)
+	}
+	if len(entry.References) != 1 || entry.References[0].Type != "REPORT" {
+		t.Errorf("failed to convert references, got %+v", entry.References)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ID", "CVE-2021-44228.json")); !os.IsNotExist(err) {
+		t.Errorf("failed to skip vulnerability without go modules")
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	ordered := []string{"0.3.8", "0.4.0-rc.1", "0.4.0", "0.10.0", "1.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		if compareSemver(ordered[i-1], ordered[i]) >= 0 || compareSemver(ordered[i], ordered[i-1]) <= 0 {
+			t.Errorf("failed to order %s before %s", ordered[i-1], ordered[i])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package vulnerability
+
+import (
+	"strings"
+)
+
+// DefaultLanguage is the language Vulnerability.Description is kept in when
+// a description in it is available.
+const DefaultLanguage = "en"
+
+// Description is the vulnerability description in one language, tagged with
+// a BCP 47 language tag such as "en" or "es-ES".
+type Description struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+// AddDescription records the description in lang, replacing any earlier one
+// with the same tag, and keeps Description in the default language.
+func (v *Vulnerability) AddDescription(lang string, value string) {
+	lang = strings.TrimSpace(lang)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	replaced := false
+	for i, existing := range v.Descriptions {
+		if strings.EqualFold(existing.Lang, lang) {
+			v.Descriptions[i].Value = value
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		v.Descriptions = append(v.Descriptions, Description{Lang: lang, Value: value})
+	}
+	v.Description = v.DescriptionIn(DefaultLanguage).Value
+}
+
+// DescriptionIn returns the description in the first of the preferred
+// languages available. Each preference matches its exact tag first and then
+// any description in the same base language, so "es-MX" falls back to "es"
+// or "es-ES". Failing all of them the default language is used, then the
+// first description recorded, then Description untagged.
+func (v Vulnerability) DescriptionIn(preferred ...string) Description {
+	for _, lang := range append(append([]string{}, preferred...), DefaultLanguage) {
+		if description, ok := v.descriptionMatching(lang); ok {
+			return description
+		}
+	}
+	if len(v.Descriptions) > 0 {
+		return v.Descriptions[0]
+	}
+	return Description{Value: v.Description}
+}
+
+func (v Vulnerability) descriptionMatching(lang string) (Description, bool) {
+	for _, description := range v.Descriptions {
+		if strings.EqualFold(description.Lang, lang) {
+			return description, true
+		}
+	}
+	base := baseLanguage(lang)
+	for _, description := range v.Descriptions {
+		if baseLanguage(description.Lang) == base {
+			return description, true
+		}
+	}
+	return Description{}, false
+}
+
+// Languages returns the language tags descriptions are available in.
+func (v Vulnerability) Languages() []string {
+	languages := make([]string, 0, len(v.Descriptions))
+	for _, description := range v.Descriptions {
+		languages = append(languages, description.Lang)
+	}
+	return languages
+}
+
+func baseLanguage(lang string) string {
+	if dash := strings.IndexAny(lang, "-_"); dash >= 0 {
+		lang = lang[:dash]
+	}
+	return strings.ToLower(lang)
+}
@@ -0,0 +1,43 @@
+package vulnerability
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDescriptionIn(t *testing.T) {
+	vuln := Vulnerability{CveId: "CVE-2021-44228"}
+	vuln.AddDescription("es", "Las características JNDI de Apache Log4j2 no protegen contra LDAP controlado por el atacante.")
+	if vuln.Description != vuln.Descriptions[0].Value {
+		t.Errorf("failed to use the only description as the default, got %q", vuln.Description)
+	}
+	vuln.AddDescription("en", "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP.")
+	vuln.AddDescription("EN", "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP and other endpoints.")
+	if vuln.Description != "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP and other endpoints." {
+		t.Errorf("failed to keep the description in the default language, got %q", vuln.Description)
+	}
+	if !reflect.DeepEqual(vuln.Languages(), []string{"es", "en"}) {
+		t.Errorf("failed to replace description with the same tag, got %v", vuln.Languages())
+	}
+
+	tests := []struct {
+		preferred []string
+		lang      string
+	}{
+		{[]string{"es"}, "es"},
+		{[]string{"es-MX"}, "es"},
+		{[]string{"fr", "es"}, "es"},
+		{[]string{"ja"}, "en"},
+		{nil, "en"},
+	}
+	for _, test := range tests {
+		if description := vuln.DescriptionIn(test.preferred...); description.Lang != test.lang {
+			t.Errorf("failed to resolve %v, expected %s got %s", test.preferred, test.lang, description.Lang)
+		}
+	}
+
+	untagged := Vulnerability{Description: "An untagged description."}
+	if description := untagged.DescriptionIn("fr"); description.Value != untagged.Description || description.Lang != "" {
+		t.Errorf("failed to fall back to the untagged description, got %+v", description)
+	}
+}
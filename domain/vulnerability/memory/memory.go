@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/carbonrook/cvewatch-domain/domain/vulnerability"
+)
+
+type MemoryRepository struct {
+	vulnerabilities map[string]vulnerability.Vulnerability
+	lock            *sync.RWMutex
+}
+
+func (mr MemoryRepository) Get(ctx context.Context, cveId string) (*vulnerability.Vulnerability, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	vuln, ok := mr.vulnerabilities[cveId]
+	if !ok {
+		return nil, vulnerability.ErrVulnerabilityNotFound
+	}
+	return &vuln, nil
+}
+
+func (mr MemoryRepository) Add(ctx context.Context, vuln vulnerability.Vulnerability) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.vulnerabilities[vuln.CveId]; ok {
+		return vulnerability.ErrVulnerabilityAlreadyExists
+	}
+	mr.vulnerabilities[vuln.CveId] = vuln
+	return nil
+}
+
+func (mr MemoryRepository) Update(ctx context.Context, cveId string, vuln *vulnerability.Vulnerability) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.vulnerabilities[cveId]; !ok {
+		return vulnerability.ErrVulnerabilityNotFound
+	}
+	mr.vulnerabilities[cveId] = *vuln
+	return nil
+}
+
+func (mr MemoryRepository) Delete(ctx context.Context, cveId string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if _, ok := mr.vulnerabilities[cveId]; !ok {
+		return vulnerability.ErrVulnerabilityNotFound
+	}
+	delete(mr.vulnerabilities, cveId)
+	return nil
+}
+
+func (mr MemoryRepository) List(ctx context.Context) (*vulnerability.VulnerabilityCollection, error) {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+	collection := &vulnerability.VulnerabilityCollection{Vulnerabilities: []vulnerability.Vulnerability{}}
+	for _, vuln := range mr.vulnerabilities {
+		collection.Vulnerabilities = append(collection.Vulnerabilities, vuln)
+	}
+	sort.Slice(collection.Vulnerabilities, func(i, j int) bool {
+		return collection.Vulnerabilities[i].CveId < collection.Vulnerabilities[j].CveId
+	})
+	return collection, nil
+}
+
+func NewMemoryVulnerabilityRepository() (vulnerability.VulnerabilityRepository, error) {
+	return MemoryRepository{
+		vulnerabilities: make(map[string]vulnerability.Vulnerability),
+		lock:            &sync.RWMutex{},
+	}, nil
+}
+
+func MustNewMemoryVulnerabilityRepository() vulnerability.VulnerabilityRepository {
+	repo, err := NewMemoryVulnerabilityRepository()
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
@@ -15,4 +15,5 @@ type VulnerabilityRepository interface {
 	Add(ctx context.Context, vulnerability Vulnerability) error
 	Update(ctx context.Context, cveId string, vulnerability *Vulnerability) error
 	Delete(ctx context.Context, cveId string) error
+	List(ctx context.Context) (*VulnerabilityCollection, error)
 }
@@ -0,0 +1,239 @@
+package vulnerability
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownSeverity = errors.New("the severity rating is not recognised")
+
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityNone
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityUnknown:  "UNKNOWN",
+	SeverityNone:     "NONE",
+	SeverityLow:      "LOW",
+	SeverityMedium:   "MEDIUM",
+	SeverityHigh:     "HIGH",
+	SeverityCritical: "CRITICAL",
+}
+
+// severityRatings maps CVSS qualitative ratings and the vendor specific
+// vocabularies (Red Hat, Microsoft, SUSE, Ubuntu, Debian, GitHub) onto the
+// normalised scale.
+var severityRatings = map[string]Severity{
+	"unknown":       SeverityUnknown,
+	"none":          SeverityNone,
+	"informational": SeverityLow,
+	"negligible":    SeverityLow,
+	"unimportant":   SeverityLow,
+	"low":           SeverityLow,
+	"medium":        SeverityMedium,
+	"moderate":      SeverityMedium,
+	"high":          SeverityHigh,
+	"important":     SeverityHigh,
+	"critical":      SeverityCritical,
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return severityNames[SeverityUnknown]
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+func (s Severity) AtLeast(severity Severity) bool {
+	return s >= severity
+}
+
+func ParseSeverity(rating string) (Severity, error) {
+	severity, ok := severityRatings[strings.ToLower(strings.TrimSpace(rating))]
+	if !ok {
+		return SeverityUnknown, fmt.Errorf("%w: %q", ErrUnknownSeverity, rating)
+	}
+	return severity, nil
+}
+
+// SeverityFromCvssScore applies the qualitative rating scale for the given
+// CVSS major version. CVSS 2 has no critical or none band.
+func SeverityFromCvssScore(version string, score float64) Severity {
+	if strings.HasPrefix(version, "2") {
+		switch {
+		case score >= 7.0:
+			return SeverityHigh
+		case score >= 4.0:
+			return SeverityMedium
+		default:
+			return SeverityLow
+		}
+	}
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0.0:
+		return SeverityLow
+	default:
+		return SeverityNone
+	}
+}
+
+type SeveritySource string
+
+const (
+	SeveritySourceCvss4  SeveritySource = "cvss4"
+	SeveritySourceCvss3  SeveritySource = "cvss3"
+	SeveritySourceCvss2  SeveritySource = "cvss2"
+	SeveritySourceVendor SeveritySource = "vendor"
+)
+
+type SeverityPolicy struct {
+	// Precedence lists the sources to consult, first match wins.
+	Precedence []SeveritySource `json:"precedence"`
+	// VendorPrecedence orders vendor ratings by their Source. When empty, or
+	// when none of the listed vendors rated the vulnerability, the highest
+	// vendor rating is used.
+	VendorPrecedence []string `json:"vendorPrecedence,omitempty"`
+}
+
+func DefaultSeverityPolicy() SeverityPolicy {
+	return SeverityPolicy{
+		Precedence: []SeveritySource{
+			SeveritySourceCvss4,
+			SeveritySourceCvss3,
+			SeveritySourceVendor,
+			SeveritySourceCvss2,
+		},
+	}
+}
+
+type SeverityAssessment struct {
+	Severity    Severity       `json:"severity"`
+	Source      SeveritySource `json:"source,omitempty"`
+	Explanation string         `json:"explanation"`
+}
+
+func (v Vulnerability) EffectiveSeverity(policy SeverityPolicy) SeverityAssessment {
+	skipped := []string{}
+	for _, source := range policy.Precedence {
+		severity, reason, ok := v.severityFrom(source, policy)
+		if !ok {
+			skipped = append(skipped, reason)
+			continue
+		}
+		explanation := reason
+		if len(skipped) > 0 {
+			explanation = fmt.Sprintf("%s (skipped: %s)", reason, strings.Join(skipped, "; "))
+		}
+		return SeverityAssessment{
+			Severity:    severity,
+			Source:      source,
+			Explanation: explanation,
+		}
+	}
+
+	explanation := "no severity source configured"
+	if len(skipped) > 0 {
+		explanation = strings.Join(skipped, "; ")
+	}
+	return SeverityAssessment{
+		Severity:    SeverityUnknown,
+		Explanation: explanation,
+	}
+}
+
+func (v Vulnerability) severityFrom(source SeveritySource, policy SeverityPolicy) (Severity, string, bool) {
+	switch source {
+	case SeveritySourceCvss4:
+		if v.Cvss4.CvssVector == "" && v.Cvss4.BaseSeverity == "" {
+			return SeverityUnknown, "cvss4 not available", false
+		}
+		return cvssSeverity("cvss4", "4.0", v.Cvss4.BaseSeverity, v.Cvss4.BaseScore)
+	case SeveritySourceCvss3:
+		if v.Cvss3.CvssVector == "" && v.Cvss3.BaseSeverity == "" {
+			return SeverityUnknown, "cvss3 not available", false
+		}
+		return cvssSeverity("cvss3", "3", v.Cvss3.BaseSeverity, v.Cvss3.BaseScore)
+	case SeveritySourceCvss2:
+		if v.Cvss2.CvssVector == "" && v.BaseMetric2.Severity == "" {
+			return SeverityUnknown, "cvss2 not available", false
+		}
+		return cvssSeverity("cvss2", "2", v.BaseMetric2.Severity, v.Cvss2.BaseScore)
+	case SeveritySourceVendor:
+		return v.vendorSeverity(policy.VendorPrecedence)
+	}
+	return SeverityUnknown, fmt.Sprintf("unsupported severity source %q", source), false
+}
+
+func cvssSeverity(name string, version string, rating string, score float64) (Severity, string, bool) {
+	if rating != "" {
+		severity, err := ParseSeverity(rating)
+		if err == nil && severity != SeverityUnknown {
+			return severity, fmt.Sprintf("%s base severity %s (base score %.1f)", name, rating, score), true
+		}
+	}
+	severity := SeverityFromCvssScore(version, score)
+	return severity, fmt.Sprintf("%s base score %.1f rated %s", name, score, severity), true
+}
+
+func (v Vulnerability) vendorSeverity(precedence []string) (Severity, string, bool) {
+	if len(v.VendorSeverities) == 0 {
+		return SeverityUnknown, "no vendor rating available", false
+	}
+
+	for _, vendor := range precedence {
+		for _, rating := range v.VendorSeverities {
+			if !strings.EqualFold(rating.Source, vendor) {
+				continue
+			}
+			severity, err := ParseSeverity(rating.Rating)
+			if err != nil || severity == SeverityUnknown {
+				continue
+			}
+			return severity, fmt.Sprintf("vendor rating %q from %s", rating.Rating, rating.Source), true
+		}
+	}
+
+	highest := SeverityUnknown
+	var chosen VendorSeverity
+	for _, rating := range v.VendorSeverities {
+		severity, err := ParseSeverity(rating.Rating)
+		if err != nil || severity == SeverityUnknown {
+			continue
+		}
+		if severity > highest {
+			highest = severity
+			chosen = rating
+		}
+	}
+	if highest == SeverityUnknown {
+		return SeverityUnknown, "no recognised vendor rating", false
+	}
+	return highest, fmt.Sprintf("highest vendor rating %q from %s", chosen.Rating, chosen.Source), true
+}
@@ -0,0 +1,65 @@
+package vulnerability
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEffectiveSeverityPrecedence(t *testing.T) {
+	vuln := Vulnerability{
+		CveId:       "CVE-2021-44228",
+		Cvss3:       Cvss3{Version: "3.1", CvssVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", BaseScore: 10.0, BaseSeverity: "CRITICAL"},
+		BaseMetric2: BaseMetric2{Severity: "HIGH"},
+		Cvss2:       Cvss2{Version: "2.0", CvssVector: "AV:N/AC:M/Au:N/C:C/I:C/A:C", BaseScore: 9.3},
+		VendorSeverities: []VendorSeverity{
+			{Source: "Red Hat", Rating: "Important"},
+			{Source: "Ubuntu", Rating: "high"},
+		},
+	}
+
+	assessment := vuln.EffectiveSeverity(DefaultSeverityPolicy())
+	if assessment.Severity != SeverityCritical || assessment.Source != SeveritySourceCvss3 {
+		t.Errorf("expected critical from cvss3, got %s from %s", assessment.Severity, assessment.Source)
+	}
+	if !strings.Contains(assessment.Explanation, "cvss4 not available") {
+		t.Errorf("expected explanation to mention the skipped cvss4 source: %s", assessment.Explanation)
+	}
+
+	vendorFirst := SeverityPolicy{Precedence: []SeveritySource{SeveritySourceVendor, SeveritySourceCvss3}, VendorPrecedence: []string{"red hat"}}
+	assessment = vuln.EffectiveSeverity(vendorFirst)
+	if assessment.Severity != SeverityHigh || assessment.Source != SeveritySourceVendor {
+		t.Errorf("expected high from vendor, got %s from %s", assessment.Severity, assessment.Source)
+	}
+
+	// A preferred vendor rating the issue unknown gives way to the others.
+	vuln.VendorSeverities[0].Rating = "unknown"
+	assessment = vuln.EffectiveSeverity(vendorFirst)
+	if assessment.Severity != SeverityHigh || !strings.Contains(assessment.Explanation, "Ubuntu") {
+		t.Errorf("expected high from ubuntu, got %s: %s", assessment.Severity, assessment.Explanation)
+	}
+	vuln.VendorSeverities = vuln.VendorSeverities[:1]
+	assessment = vuln.EffectiveSeverity(vendorFirst)
+	if assessment.Severity != SeverityCritical || assessment.Source != SeveritySourceCvss3 {
+		t.Errorf("expected an unknown vendor rating to fall back to cvss3, got %s from %s", assessment.Severity, assessment.Source)
+	}
+}
+
+func TestSeverityFromCvssScore(t *testing.T) {
+	cases := []struct {
+		version  string
+		score    float64
+		expected Severity
+	}{
+		{"3.1", 0.0, SeverityNone},
+		{"3.1", 3.9, SeverityLow},
+		{"4.0", 6.9, SeverityMedium},
+		{"3.0", 9.0, SeverityCritical},
+		{"2.0", 9.3, SeverityHigh},
+		{"2.0", 0.0, SeverityLow},
+	}
+	for _, c := range cases {
+		if got := SeverityFromCvssScore(c.version, c.score); got != c.expected {
+			t.Errorf("cvss %s score %.1f: expected %s, got %s", c.version, c.score, c.expected, got)
+		}
+	}
+}
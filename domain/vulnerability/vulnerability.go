@@ -2,21 +2,39 @@ package vulnerability
 
 import (
 	"net/url"
+	"strings"
 	"time"
 )
 
 type Vulnerability struct {
-	CveId         string      `json:"cveId"`
-	Assigner      string      `json:"assigner"`
-	Description   string      `json:"description"`
-	PublishedDate time.Time   `json:"publishedDate"`
-	LastModified  time.Time   `json:"lastModified"`
-	BaseMetric3   BaseMetric3 `json:"baseMetric3"`
-	Cvss3         Cvss3       `json:"cvss3"`
-	BaseMetric2   BaseMetric2 `json:"baseMetric2"`
-	Cvss2         Cvss2       `json:"cvss2"`
-	Cwes          []Cwe       `json:"cwes"`
-	References    []Reference `json:"references"`
+	CveId             string            `json:"cveId"`
+	Assigner          string            `json:"assigner"`
+	Description       string            `json:"description"`
+	Descriptions      []Description     `json:"descriptions,omitempty"`
+	PublishedDate     time.Time         `json:"publishedDate"`
+	LastModified      time.Time         `json:"lastModified"`
+	Cvss4             Cvss4             `json:"cvss4"`
+	BaseMetric3       BaseMetric3       `json:"baseMetric3"`
+	Cvss3             Cvss3             `json:"cvss3"`
+	BaseMetric2       BaseMetric2       `json:"baseMetric2"`
+	Cvss2             Cvss2             `json:"cvss2"`
+	VendorSeverities  []VendorSeverity  `json:"vendorSeverities,omitempty"`
+	Kev               Kev               `json:"kev"`
+	Epss              Epss              `json:"epss"`
+	Affected          []AffectedProduct `json:"affected,omitempty"`
+	DistributionFixes []DistributionFix `json:"distributionFixes,omitempty"`
+	Remediations      []Remediation     `json:"remediations,omitempty"`
+	Exploitability    []Exploitability  `json:"exploitability,omitempty"`
+	Cwes              []Cwe             `json:"cwes"`
+	Techniques        []Technique       `json:"techniques,omitempty"`
+	References        []Reference       `json:"references"`
+}
+
+type Cvss4 struct {
+	Version      string  `json:"version"`
+	CvssVector   string  `json:"cvssVector"`
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity"`
 }
 
 type BaseMetric3 struct {
@@ -62,10 +80,153 @@ type Cvss2 struct {
 	BaseScore             float64 `json:"baseScore"`
 }
 
+type VendorSeverity struct {
+	Source string `json:"source"`
+	Rating string `json:"rating"`
+}
+
+// Kev holds the CISA Known Exploited Vulnerabilities catalog entry, if any.
+type Kev struct {
+	DateAdded                  time.Time `json:"dateAdded"`
+	DueDate                    time.Time `json:"dueDate"`
+	RequiredAction             string    `json:"requiredAction"`
+	KnownRansomwareCampaignUse bool      `json:"knownRansomwareCampaignUse"`
+}
+
+func (k Kev) Listed() bool {
+	return !k.DateAdded.IsZero()
+}
+
+type Epss struct {
+	Score      float64   `json:"score"`
+	Percentile float64   `json:"percentile"`
+	Date       time.Time `json:"date"`
+}
+
+// AffectedProduct is a vendor product or, when Ecosystem is set, a package in
+// a language ecosystem. For the Go ecosystem Product holds the module path.
+type AffectedProduct struct {
+	Vendor    string          `json:"vendor"`
+	Product   string          `json:"product"`
+	Cpe       string          `json:"cpe,omitempty"`
+	Ecosystem string          `json:"ecosystem,omitempty"`
+	Ranges    []VersionRange  `json:"ranges,omitempty"`
+	Imports   []PackageImport `json:"imports,omitempty"`
+	// Cvss is the score the vendor gave this product when it differs per
+	// product, as in CVRF score sets.
+	Cvss *Cvss3 `json:"cvss,omitempty"`
+}
+
+const EcosystemGo = "Go"
+
+// VersionRange is a semantic version range, introduced inclusive and fixed
+// exclusive. An empty Introduced means every version before Fixed and an
+// empty Fixed means no fix has been released.
+type VersionRange struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+// PackageImport narrows a Go module to the vulnerable package, its symbols
+// and the platforms it is vulnerable on.
+type PackageImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols,omitempty"`
+	Goos    []string `json:"goos,omitempty"`
+	Goarch  []string `json:"goarch,omitempty"`
+}
+
+type FixStatus string
+
+const (
+	FixStatusFixed       FixStatus = "fixed"
+	FixStatusAffected    FixStatus = "affected"
+	FixStatusNotAffected FixStatus = "not-affected"
+	FixStatusWillNotFix  FixStatus = "will-not-fix"
+	FixStatusUnknown     FixStatus = "unknown"
+)
+
+// DistributionFix records whether a Linux distribution has shipped a fix for
+// the vulnerability in one of its packages.
+type DistributionFix struct {
+	Distribution string    `json:"distribution"`
+	Release      string    `json:"release"`
+	Package      string    `json:"package"`
+	Status       FixStatus `json:"status"`
+	FixedVersion string    `json:"fixedVersion,omitempty"`
+	Advisory     string    `json:"advisory,omitempty"`
+	Priority     string    `json:"priority,omitempty"`
+}
+
+type RemediationType string
+
+const (
+	RemediationVendorFix     RemediationType = "vendor-fix"
+	RemediationWorkaround    RemediationType = "workaround"
+	RemediationMitigation    RemediationType = "mitigation"
+	RemediationNoneAvailable RemediationType = "none-available"
+	RemediationWillNotFix    RemediationType = "will-not-fix"
+)
+
+// Remediation is a vendor supplied fix or workaround, such as a Microsoft
+// security update identified by its KB number.
+type Remediation struct {
+	Source          string          `json:"source"`
+	Type            RemediationType `json:"type"`
+	Description     string          `json:"description,omitempty"`
+	Kb              string          `json:"kb,omitempty"`
+	Url             string          `json:"url,omitempty"`
+	Products        []string        `json:"products,omitempty"`
+	FixedBuild      string          `json:"fixedBuild,omitempty"`
+	Supersedes      string          `json:"supersedes,omitempty"`
+	RestartRequired bool            `json:"restartRequired"`
+}
+
+const (
+	ExploitationDetected   = "Exploitation Detected"
+	ExploitationMoreLikely = "Exploitation More Likely"
+	ExploitationLessLikely = "Exploitation Less Likely"
+	ExploitationUnlikely   = "Exploitation Unlikely"
+)
+
+// Exploitability is a vendor assessment of how likely exploitation is, in
+// the style of the Microsoft Exploitability Index.
+type Exploitability struct {
+	Source            string `json:"source"`
+	PubliclyDisclosed bool   `json:"publiclyDisclosed"`
+	Exploited         bool   `json:"exploited"`
+	LatestRelease     string `json:"latestRelease,omitempty"`
+	OlderRelease      string `json:"olderRelease,omitempty"`
+}
+
+// Likely reports whether the vendor has seen exploitation or rates it more
+// likely on any release.
+func (e Exploitability) Likely() bool {
+	if e.Exploited {
+		return true
+	}
+	for _, assessment := range []string{e.LatestRelease, e.OlderRelease} {
+		if strings.EqualFold(assessment, ExploitationDetected) || strings.EqualFold(assessment, ExploitationMoreLikely) {
+			return true
+		}
+	}
+	return false
+}
+
 type Cwe struct {
 	Id string `json:"id"`
 }
 
+// Technique is a MITRE ATT&CK technique the vulnerability enables. Via
+// records how it was linked, either the CVE mapping or the CWE and CAPEC
+// attack patterns it was reached through.
+type Technique struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Tactics []string `json:"tactics,omitempty"`
+	Via     []string `json:"via"`
+}
+
 type Reference struct {
 	Url    url.URL  `json:"url"`
 	Name   string   `json:"name"`
@@ -73,6 +234,51 @@ type Reference struct {
 	Tags   []string `json:"tags"`
 }
 
+// ExploitAvailable reports whether any reference is tagged as an exploit.
+func (v Vulnerability) ExploitAvailable() bool {
+	for _, reference := range v.References {
+		for _, tag := range reference.Tags {
+			if strings.EqualFold(tag, "Exploit") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExploitationLikely reports whether any vendor assessment rates exploitation
+// as detected or more likely.
+func (v Vulnerability) ExploitationLikely() bool {
+	for _, assessment := range v.Exploitability {
+		if assessment.Likely() {
+			return true
+		}
+	}
+	return false
+}
+
+// AddDistributionFix records the fix, replacing any earlier entry for the same
+// distribution, release and package.
+func (v *Vulnerability) AddDistributionFix(fix DistributionFix) {
+	for i, existing := range v.DistributionFixes {
+		if existing.Distribution == fix.Distribution && existing.Release == fix.Release && existing.Package == fix.Package {
+			v.DistributionFixes[i] = fix
+			return
+		}
+	}
+	v.DistributionFixes = append(v.DistributionFixes, fix)
+}
+
+func (v *Vulnerability) AddVendorSeverity(severity VendorSeverity) {
+	for i, existing := range v.VendorSeverities {
+		if strings.EqualFold(existing.Source, severity.Source) {
+			v.VendorSeverities[i] = severity
+			return
+		}
+	}
+	v.VendorSeverities = append(v.VendorSeverities, severity)
+}
+
 type VulnerabilityCollection struct {
 	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
 }
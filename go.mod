@@ -4,4 +4,4 @@ go 1.17
 
 require github.com/google/uuid v1.3.0
 
-require github.com/elastic/go-elasticsearch v0.0.0 // indirect
+require github.com/elastic/go-elasticsearch v0.0.0